@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"uof-service/database"
+	"uof-service/services"
+)
+
+const usage = `API Key 管理工具
+
+用法:
+  apikey issue -name <name> -role <read|trader|admin>   签发新的 API Key
+  apikey revoke -id <id>                                吊销 API Key
+  apikey list                                           列出所有 API Key
+
+环境变量:
+  DATABASE_URL  数据库连接地址
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	// 从环境变量获取数据库 URL
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set")
+	}
+
+	db, err := database.Connect(dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	keys := services.NewAPIKeyService(db)
+
+	switch os.Args[1] {
+	case "issue":
+		fs := flag.NewFlagSet("issue", flag.ExitOnError)
+		name := fs.String("name", "", "API Key 名称 (例如使用方/系统名)")
+		role := fs.String("role", string(services.RoleRead), "角色: read, trader, admin")
+		fs.Parse(os.Args[2:])
+
+		r, err := services.ParseAPIKeyRole(*role)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		plain, key, err := keys.Issue(*name, r)
+		if err != nil {
+			log.Fatalf("Failed to issue API key: %v", err)
+		}
+
+		fmt.Printf("ID:     %d\n", key.ID)
+		fmt.Printf("Name:   %s\n", key.Name)
+		fmt.Printf("Role:   %s\n", key.Role)
+		fmt.Printf("Key:    %s\n", plain)
+		fmt.Println("\n⚠️  请妥善保存, 该 Key 不会再次显示")
+
+	case "revoke":
+		fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+		id := fs.String("id", "", "API Key ID")
+		fs.Parse(os.Args[2:])
+
+		keyID, err := strconv.Atoi(*id)
+		if err != nil {
+			log.Fatalf("Invalid id: %s", *id)
+		}
+
+		if err := keys.Revoke(keyID); err != nil {
+			log.Fatalf("Failed to revoke API key: %v", err)
+		}
+		fmt.Printf("✅ API key %d revoked (服务端缓存最多 60 秒后失效)\n", keyID)
+
+	case "list":
+		list, err := keys.List()
+		if err != nil {
+			log.Fatalf("Failed to list API keys: %v", err)
+		}
+
+		fmt.Printf("%-5s %-14s %-8s %-20s %-20s %s\n", "ID", "PREFIX", "ROLE", "CREATED", "LAST USED", "NAME")
+		for _, key := range list {
+			lastUsed := "-"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.Format("2006-01-02 15:04:05")
+			}
+			name := key.Name
+			if key.RevokedAt != nil {
+				name += " (revoked)"
+			}
+			fmt.Printf("%-5d %-14s %-8s %-20s %-20s %s\n",
+				key.ID, key.KeyPrefix, key.Role, key.CreatedAt.Format("2006-01-02 15:04:05"), lastUsed, name)
+		}
+
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+}
@@ -51,6 +51,9 @@ type Config struct {
 	CleanupRetainDaysBets        int // bet_stops, bet_settlements 保留天数
 	CleanupRetainDaysLiveData    int // ld_events, ld_lineups 保留天数
 	CleanupRetainDaysEvents      int // tracked_events, ld_matches 保留天数
+	CleanupRetainDaysAudit       int // audit_log 保留天数
+	CleanupRetainDaysCandles     int // odds_candles 保留天数
+	CleanupRetainDaysClosingLines int // closing_lines 保留天数
 	
 	// Producer 监控配置
 	ProducerCheckIntervalSeconds int // 检查间隔（秒）
@@ -58,6 +61,27 @@ type Config struct {
 	
 	// 订阅同步配置
 	SubscriptionSyncIntervalMinutes int // 订阅同步间隔(分钟)
+	
+	// 投注校验配置
+	BetOddsTolerance float64 // 允许的赔率下降比例 (0.05 = 5%)
+	
+	// 利润率配置
+	MarginProfileRefreshSeconds int // 利润率配置缓存刷新间隔(秒)
+	MarginAnomalyTolerance      float64 // 返还率高于预期多少时标记异常 (0.10 = 10 个百分点)
+	
+	// 赔率告警配置
+	OddsAlertRuleRefreshSeconds int // 告警规则缓存刷新间隔(秒)
+	OddsAlertNotifyPerMinute    int // 每分钟最多发送的告警通知数 (0 表示不限)
+	
+	// 鉴权配置
+	APIAuthEnabled bool     // 是否要求 API Key (REST 和 WebSocket)
+	AllowedOrigins []string // 允许的跨域来源 (为空表示允许所有)
+	
+	// 多语言配置
+	Languages []string // 加载名称的语言 (第一个为 en, 缺少翻译时回退到 en)
+	
+	// 参赛方资料配置
+	CompetitorProfileTTLHours int // 参赛方资料缓存有效期(小时), 过期后重新请求
 }
 
 func Load() *Config {
@@ -130,6 +154,9 @@ func Load() *Config {
 		CleanupRetainDaysBets:      getEnvInt("CLEANUP_RETAIN_DAYS_BETS", 2),       // 投注记录默认保留 2 天
 		CleanupRetainDaysLiveData:  getEnvInt("CLEANUP_RETAIN_DAYS_LIVEDATA", 2),   // Live Data 默认保留 2 天
 		CleanupRetainDaysEvents:    getEnvInt("CLEANUP_RETAIN_DAYS_EVENTS", 2),    // 赛事信息默认保留 2 天
+		CleanupRetainDaysAudit:     getEnvInt("CLEANUP_RETAIN_DAYS_AUDIT", 90),    // 审计日志默认保留 90 天
+		CleanupRetainDaysCandles:   getEnvInt("CLEANUP_RETAIN_DAYS_CANDLES", 30),  // 赔率 K 线默认保留 30 天
+		CleanupRetainDaysClosingLines: getEnvInt("CLEANUP_RETAIN_DAYS_CLOSING_LINES", 365), // 收盘赔率默认保留 365 天 (CLV 分析)
 		
 		// Producer 监控配置
 		ProducerCheckIntervalSeconds: getEnvInt("PRODUCER_CHECK_INTERVAL_SECONDS", 60),   // 默认每 60 秒检查一次
@@ -137,6 +164,27 @@ func Load() *Config {
 		
 		// 订阅同步配置
 		SubscriptionSyncIntervalMinutes: getEnvInt("SUBSCRIPTION_SYNC_INTERVAL_MINUTES", 5), // 默认每 5 分钟同步一次
+		
+		// 投注校验配置
+		BetOddsTolerance: getEnvFloat("BET_ODDS_TOLERANCE", 0.0), // 默认不允许赔率下降
+		
+		// 利润率配置
+		MarginProfileRefreshSeconds: getEnvInt("MARGIN_PROFILE_REFRESH_SECONDS", 30),
+		MarginAnomalyTolerance:      getEnvFloat("MARGIN_ANOMALY_TOLERANCE", 0.10),
+		
+		// 赔率告警配置
+		OddsAlertRuleRefreshSeconds: getEnvInt("ODDS_ALERT_RULE_REFRESH_SECONDS", 30),
+		OddsAlertNotifyPerMinute:    getEnvInt("ODDS_ALERT_NOTIFY_PER_MINUTE", 10),
+		
+		// 鉴权配置
+		APIAuthEnabled: getEnv("API_AUTH_ENABLED", "false") == "true", // 默认关闭, 用 cmd/apikey 发放 key 后再开启, 避免升级后所有客户端被拒绝
+		AllowedOrigins: getAllowedOrigins(),
+		
+		// 多语言配置
+		Languages: getLanguages(),
+		
+		// 参赛方资料配置
+		CompetitorProfileTTLHours: getEnvInt("COMPETITOR_PROFILE_TTL_HOURS", 24),
 	}
 }
 
@@ -182,11 +230,46 @@ func getEnvInt(key string, defaultValue int) int {
 	return result
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var result float64
+	if _, err := fmt.Sscanf(value, "%g", &result); err != nil {
+		return defaultValue
+	}
+	return result
+}
+
 func getRecoveryProducts() []string {
 	products := getEnv("RECOVERY_PRODUCTS", "liveodds,pre")
 	return strings.Split(products, ",")
 }
 
+func getAllowedOrigins() []string {
+	origins := []string{}
+	for _, origin := range strings.Split(getEnv("ALLOWED_ORIGINS", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// getLanguages 解析 LANGUAGES (例如 "en,zh,pt"), en 总是包含且排在第一位
+func getLanguages() []string {
+	languages := []string{"en"}
+	for _, lang := range strings.Split(getEnv("LANGUAGES", "en"), ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || lang == "en" {
+			continue
+		}
+		languages = append(languages, lang)
+	}
+	return languages
+}
+
 func getProducts() []string {
 	products := getEnv("PRODUCTS", "liveodds,pre")
 	return strings.Split(products, ",")
@@ -95,6 +95,8 @@ func Migrate(db *sql.DB) error {
     home_team_name VARCHAR(255),
     away_team_id VARCHAR(100),
     away_team_name VARCHAR(255),
+    event_type VARCHAR(30),
+    competitors JSONB,
     home_score INTEGER,
     away_score INTEGER,
     schedule_time TIMESTAMP,
@@ -132,6 +134,12 @@ func Migrate(db *sql.DB) error {
     favourite BOOLEAN,
     home_team_name VARCHAR(200),
     away_team_name VARCHAR(200),
+    overround DECIMAL(8, 4),
+    raw_overround DECIMAL(8, 4),
+    margin_anomaly VARCHAR(20),
+    margin_timestamp BIGINT,
+    is_main_line BOOLEAN DEFAULT FALSE,
+    groups VARCHAR(200),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE (event_id, sr_market_id, specifiers)
@@ -145,6 +153,8 @@ func Migrate(db *sql.DB) error {
     outcome_id VARCHAR(200) NOT NULL,
     outcome_name VARCHAR(200),
     odds_value DECIMAL(10, 2),
+    raw_odds_value DECIMAL(10, 2),
+    margin_profile_id INTEGER,
     probability DECIMAL(5, 4),
     active BOOLEAN DEFAULT TRUE,
     timestamp BIGINT,
@@ -325,6 +335,282 @@ func Migrate(db *sql.DB) error {
     UNIQUE (market_id, outcome_id)
 );`,
 		
+		// API Key (只保存 SHA-256 哈希)
+		`CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);`,
+		
+		// 审计日志 (管理操作)
+		`CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id INTEGER,
+    api_key_name VARCHAR(200),
+    role VARCHAR(20),
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    route VARCHAR(200),
+    params JSONB,
+    status_code INTEGER,
+    success BOOLEAN,
+    result TEXT,
+    duration_ms INTEGER,
+    remote_addr VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 结算账本 (只追加, 每个结果按版本记录结算/回滚/再结算)
+		`CREATE TABLE IF NOT EXISTS settlement_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    producer_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    version INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    result INTEGER,
+    void_factor DECIMAL(5, 4),
+    dead_heat_factor DECIMAL(10, 8),
+    win_factor DECIMAL(12, 8) NOT NULL DEFAULT 0,
+    refund_factor DECIMAL(5, 4) NOT NULL DEFAULT 0,
+    outcome VARCHAR(20),
+    stage VARCHAR(20),
+    certainty INTEGER,
+    flipped_from_id BIGINT,
+    message_timestamp BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, sr_market_id, specifiers, outcome_id, version)
+);`,
+		
+		// 投注取消时间窗口 (rollback_bet_cancel 只标记回滚, 不删除)
+		`CREATE TABLE IF NOT EXISTS bet_cancel_windows (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    producer_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    void_reason INTEGER,
+    start_time BIGINT,
+    end_time BIGINT,
+    superseded_by VARCHAR(100),
+    message_timestamp BIGINT NOT NULL,
+    rollback_timestamp BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 利润率配置 (sport_id / tournament_id / sr_market_id 为 NULL 表示不限)
+		`CREATE TABLE IF NOT EXISTS margin_profiles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    sport_id VARCHAR(50),
+    tournament_id VARCHAR(100),
+    sr_market_id VARCHAR(50),
+    mode VARCHAR(20) NOT NULL DEFAULT 'target',
+    margin DECIMAL(6, 4) NOT NULL,
+    min_odds DECIMAL(10, 2),
+    max_odds DECIMAL(10, 2),
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 收盘赔率 (开赛时最后的 prematch 赔率, 每个结果只记录一次)
+		`CREATE TABLE IF NOT EXISTS closing_lines (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    market_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    outcome_name VARCHAR(200),
+    odds_value DECIMAL(10, 2),
+    raw_odds_value DECIMAL(10, 2),
+    active BOOLEAN,
+    producer_id INTEGER,
+    odds_timestamp BIGINT,
+    trigger VARCHAR(30) NOT NULL,
+    captured_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, sr_market_id, specifiers, outcome_id)
+);`,
+		
+		// 盘口返还率历史 (返还率变化时记录)
+		`CREATE TABLE IF NOT EXISTS market_margin_history (
+    id BIGSERIAL PRIMARY KEY,
+    market_id INTEGER NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT,
+    overround DECIMAL(8, 4) NOT NULL,
+    raw_overround DECIMAL(8, 4),
+    expected_overround DECIMAL(8, 4),
+    anomaly VARCHAR(20),
+    timestamp BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 赔率告警规则 (按运动 / 盘口配置)
+		`CREATE TABLE IF NOT EXISTS odds_alert_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL,
+    sport_id VARCHAR(50),
+    sr_market_id VARCHAR(50),
+    producer_id INTEGER,
+    threshold DECIMAL(10, 4) NOT NULL,
+    window_seconds INTEGER NOT NULL,
+    max_updates INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 300,
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 触发的赔率告警
+		`CREATE TABLE IF NOT EXISTS odds_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id INTEGER,
+    rule_name VARCHAR(200) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sport_id VARCHAR(50),
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT,
+    outcome_id VARCHAR(200),
+    producer_id INTEGER,
+    value DECIMAL(10, 4) NOT NULL,
+    threshold DECIMAL(10, 4) NOT NULL,
+    window_seconds INTEGER NOT NULL,
+    odds_from DECIMAL(10, 2),
+    odds_to DECIMAL(10, 2),
+    message TEXT NOT NULL,
+    timestamp BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 赔率 K 线 (1s / 10s / 1m / 5m, 入库时增量计算)
+		`CREATE TABLE IF NOT EXISTS odds_candles (
+    id BIGSERIAL PRIMARY KEY,
+    market_id INTEGER NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    interval VARCHAR(5) NOT NULL,
+    bucket_start BIGINT NOT NULL,
+    open DECIMAL(10, 2) NOT NULL,
+    high DECIMAL(10, 2) NOT NULL,
+    low DECIMAL(10, 2) NOT NULL,
+    close DECIMAL(10, 2) NOT NULL,
+    changes INTEGER NOT NULL DEFAULT 0,
+    suspended_ms BIGINT NOT NULL DEFAULT 0,
+    last_timestamp BIGINT NOT NULL,
+    closing_suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start)
+);`,
+		
+		// 比赛时间线 (sport_event_status 变化: 比分 / 阶段 / 牌 / 角球 / 时钟)
+		`CREATE TABLE IF NOT EXISTS match_timeline (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    entry_type VARCHAR(30) NOT NULL,
+    team VARCHAR(10),
+    value INTEGER NOT NULL DEFAULT 0,
+    home_score INTEGER,
+    away_score INTEGER,
+    status VARCHAR(10),
+    match_status VARCHAR(10),
+    match_time VARCHAR(20),
+    description TEXT,
+    producer_id INTEGER,
+    uof_timestamp BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 完整赛事状态 (sport_event_status, 每场一行; 来源 odds_change / fixture / summary)
+		`CREATE TABLE IF NOT EXISTS event_status (
+    event_id VARCHAR(100) PRIMARY KEY,
+    status VARCHAR(30),
+    match_status VARCHAR(50),
+    home_score INTEGER,
+    away_score INTEGER,
+    winner_id VARCHAR(100),
+    clock JSONB,
+    period_scores JSONB,
+    statistics JSONB,
+    sport_details JSONB,
+    source VARCHAR(20) NOT NULL,
+    uof_timestamp BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 多语言名称 (参赛方 / 球员 / 赛事 / 联赛 / 运动 / 比赛状态, 来自 Sports API)
+		`CREATE TABLE IF NOT EXISTS localized_names (
+    urn VARCHAR(150) NOT NULL,
+    lang VARCHAR(10) NOT NULL,
+    name TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (urn, lang)
+);`,
+		
+		// 其它语言的盘口 / 结果名称模板 (outcome_id 为空表示盘口名称)
+		`CREATE TABLE IF NOT EXISTS market_description_translations (
+    market_id VARCHAR(50) NOT NULL,
+    outcome_id VARCHAR(200) NOT NULL DEFAULT '',
+    lang VARCHAR(10) NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (market_id, outcome_id, lang)
+);`,
+		
+		// 参赛方资料 (Sports API competitor profile, 超过 TTL 后刷新)
+		`CREATE TABLE IF NOT EXISTS competitors (
+    competitor_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    abbreviation VARCHAR(20),
+    country VARCHAR(100),
+    country_code VARCHAR(10),
+    gender VARCHAR(20),
+    age_group VARCHAR(20),
+    sport_id VARCHAR(50),
+    venue JSONB,
+    jerseys JSONB,
+    manager JSONB,
+    fetched_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
+		// 参赛方阵容 (球员信息在 players 表)
+		`CREATE TABLE IF NOT EXISTS competitor_players (
+    competitor_id VARCHAR(50) NOT NULL,
+    player_id VARCHAR(50) NOT NULL,
+    player_type VARCHAR(50),
+    jersey_number INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (competitor_id, player_id)
+);`,
+		
+		// 盘口描述变化记录 (刷新时与旧描述比较)
+		`CREATE TABLE IF NOT EXISTS market_description_changes (
+    id BIGSERIAL PRIMARY KEY,
+    market_id VARCHAR(50) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    outcome_id VARCHAR(200),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`,
+		
 	}
 	
 	for _, sql := range tables {
@@ -338,6 +624,7 @@ func Migrate(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_tracked_events_event_id ON tracked_events(event_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_tracked_events_sport_id ON tracked_events(sport_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_tracked_events_schedule_time ON tracked_events(schedule_time)`,
+		`CREATE INDEX IF NOT EXISTS idx_tracked_events_event_type ON tracked_events(event_type)`,
 		`CREATE INDEX IF NOT EXISTS idx_tracked_events_subscribed ON tracked_events(subscribed)`,
 		
 		`CREATE INDEX IF NOT EXISTS idx_markets_event_id ON markets(event_id)`,
@@ -358,6 +645,7 @@ func Migrate(db *sql.DB) error {
 		
 		`CREATE INDEX IF NOT EXISTS idx_bet_settlements_event_id ON bet_settlements(event_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_bet_cancels_event_id ON bet_cancels(event_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_bet_stops_event_id ON bet_stops(event_id, timestamp)`,
 		
 		`CREATE INDEX IF NOT EXISTS idx_categories_sport_id ON categories(sport_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_tournaments_sport_id ON tournaments(sport_id)`,
@@ -367,6 +655,37 @@ func Migrate(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_recovery_status_request_id ON recovery_status(request_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_recovery_status_product_id ON recovery_status(product_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_recovery_status_status ON recovery_status(status)`,
+		
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix)`,
+		
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_api_key_id ON audit_log(api_key_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_route ON audit_log(route)`,
+		
+		// bet_settlement / rollback 处理使用 ON CONFLICT, 需要对应的唯一索引
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_settlements_unique ON bet_settlements(event_id, sr_market_id, specifiers, outcome_id, producer_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_rollback_bet_settlements_unique ON rollback_bet_settlements(event_id, sr_market_id, specifiers, producer_id)`,
+		
+		`CREATE INDEX IF NOT EXISTS idx_settlement_ledger_event_id ON settlement_ledger(event_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_settlement_ledger_flipped_from ON settlement_ledger(flipped_from_id) WHERE flipped_from_id IS NOT NULL`,
+		
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_cancels_unique ON bet_cancels(event_id, sr_market_id, specifiers, producer_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_rollback_bet_cancels_unique ON rollback_bet_cancels(event_id, sr_market_id, specifiers, producer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_bet_cancel_windows_market ON bet_cancel_windows(event_id, sr_market_id, specifiers)`,
+		
+		`CREATE INDEX IF NOT EXISTS idx_market_margin_history_event ON market_margin_history(event_id, sr_market_id, timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_market_margin_history_created_at ON market_margin_history(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_odds_alerts_event ON odds_alerts(event_id, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_odds_alerts_created_at ON odds_alerts(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_odds_candles_lookup ON odds_candles(event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_odds_candles_created_at ON odds_candles(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_match_timeline_event ON match_timeline(event_id, uof_timestamp)`,
+		`CREATE INDEX IF NOT EXISTS idx_match_timeline_created_at ON match_timeline(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_status_updated_at ON event_status(updated_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_markets_margin_anomaly ON markets(margin_timestamp DESC) WHERE margin_anomaly IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_competitors_fetched_at ON competitors(fetched_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_competitor_players_player ON competitor_players(player_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_market_description_changes_market ON market_description_changes(market_id, id DESC)`,
 	}
 	
 	for _, sql := range indexes {
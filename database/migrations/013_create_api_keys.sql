@@ -0,0 +1,19 @@
+-- Migration 013: 创建 API Key 表
+-- 用于 REST / WebSocket 鉴权, 只保存 key 的 SHA-256 哈希
+-- role: read / trader / admin
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix);
+
+-- 完成
+SELECT '✅ Migration 013: api_keys table created' AS status;
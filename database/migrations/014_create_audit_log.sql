@@ -0,0 +1,26 @@
+-- Migration 014: 创建审计日志表
+-- 记录所有修改状态的 API 请求: 谁 (API Key)、做了什么、参数、结果和耗时
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id INTEGER,
+    api_key_name VARCHAR(200),
+    role VARCHAR(20),
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    route VARCHAR(200),
+    params JSONB,
+    status_code INTEGER,
+    success BOOLEAN,
+    result TEXT,
+    duration_ms INTEGER,
+    remote_addr VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_api_key_id ON audit_log(api_key_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_route ON audit_log(route);
+
+-- 完成
+SELECT '✅ Migration 014: audit_log table created' AS status;
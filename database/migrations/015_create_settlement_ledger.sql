@@ -0,0 +1,34 @@
+-- Migration 015: 创建结算账本
+-- 每个结果的结算、回滚和再结算都作为不可修改的版本记录, 并计算有效赔付因子
+
+CREATE TABLE IF NOT EXISTS settlement_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    producer_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    version INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,          -- settle / resettle / rollback
+    result INTEGER,
+    void_factor DECIMAL(5, 4),
+    dead_heat_factor DECIMAL(10, 8),
+    win_factor DECIMAL(12, 8) NOT NULL DEFAULT 0,    -- result * (1 - void_factor) * dead_heat_factor
+    refund_factor DECIMAL(5, 4) NOT NULL DEFAULT 0,  -- void_factor
+    outcome VARCHAR(20),                  -- win / lose / void / half_win / half_lose / dead_heat / rolled_back
+    certainty INTEGER,
+    message_timestamp BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, sr_market_id, specifiers, outcome_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_settlement_ledger_event_id ON settlement_ledger(event_id);
+
+-- bet_settlement / rollback 处理使用 ON CONFLICT, 需要对应的唯一索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_settlements_unique
+    ON bet_settlements(event_id, sr_market_id, specifiers, outcome_id, producer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rollback_bet_settlements_unique
+    ON rollback_bet_settlements(event_id, sr_market_id, specifiers, producer_id);
+
+-- 完成
+SELECT '✅ Migration 015: settlement_ledger table created' AS status;
@@ -0,0 +1,28 @@
+-- Migration 016: 创建投注取消时间窗口表
+-- 每条 bet_cancel 按市场记录一个时间窗口, rollback_bet_cancel 只标记回滚时间, 保留历史
+
+CREATE TABLE IF NOT EXISTS bet_cancel_windows (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    producer_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    void_reason INTEGER,
+    start_time BIGINT,                    -- 为空表示从开盘起
+    end_time BIGINT,                      -- 为空表示直到结束
+    superseded_by VARCHAR(100),
+    message_timestamp BIGINT NOT NULL,
+    rollback_timestamp BIGINT,            -- 非空表示已被 rollback_bet_cancel 回滚
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bet_cancel_windows_market ON bet_cancel_windows(event_id, sr_market_id, specifiers);
+
+-- bet_cancel / rollback 处理使用 ON CONFLICT, 需要对应的唯一索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_cancels_unique
+    ON bet_cancels(event_id, sr_market_id, specifiers, producer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rollback_bet_cancels_unique
+    ON rollback_bet_cancels(event_id, sr_market_id, specifiers, producer_id);
+
+-- 完成
+SELECT '✅ Migration 016: bet_cancel_windows table created' AS status;
@@ -0,0 +1,21 @@
+-- Migration 017: 结算生命周期
+-- stage: early (certainty 1) / confirmed (certainty 2) / rolled_back
+-- flipped_from_id: 确认结算与之前的提前结算结果不同时, 指向该提前结算条目
+
+ALTER TABLE settlement_ledger ADD COLUMN IF NOT EXISTS stage VARCHAR(20);
+ALTER TABLE settlement_ledger ADD COLUMN IF NOT EXISTS flipped_from_id BIGINT;
+
+-- 回填已有条目的 stage
+UPDATE settlement_ledger
+SET stage = CASE
+    WHEN action = 'rollback' THEN 'rolled_back'
+    WHEN certainty >= 2 THEN 'confirmed'
+    ELSE 'early'
+END
+WHERE stage IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_settlement_ledger_flipped_from
+    ON settlement_ledger(flipped_from_id) WHERE flipped_from_id IS NOT NULL;
+
+-- 完成
+SELECT '✅ Migration 017: settlement lifecycle columns added' AS status;
@@ -0,0 +1,30 @@
+-- Migration 018: 利润率配置
+-- margin_profiles: 按运动 / 联赛 / 盘口类型配置利润率, NULL 表示不限
+--   mode = target: 把盘口总返还率调整到 1 + margin
+--   mode = add:    在收到的总返还率上加 margin
+-- odds.odds_value 为发布赔率, raw_odds_value 为 Betradar 原始赔率
+
+CREATE TABLE IF NOT EXISTS margin_profiles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    sport_id VARCHAR(50),
+    tournament_id VARCHAR(100),
+    sr_market_id VARCHAR(50),
+    mode VARCHAR(20) NOT NULL DEFAULT 'target',
+    margin DECIMAL(6, 4) NOT NULL,
+    min_odds DECIMAL(10, 2),
+    max_odds DECIMAL(10, 2),
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE odds ADD COLUMN IF NOT EXISTS raw_odds_value DECIMAL(10, 2);
+ALTER TABLE odds ADD COLUMN IF NOT EXISTS margin_profile_id INTEGER;
+
+-- 已有赔率未应用利润率, 原始赔率即发布赔率
+UPDATE odds SET raw_odds_value = odds_value WHERE raw_odds_value IS NULL;
+
+-- 完成
+SELECT '✅ Migration 018: margin_profiles created' AS status;
@@ -0,0 +1,33 @@
+-- Migration 019: 盘口返还率统计
+-- markets.overround / raw_overround: 发布 / 原始赔率的总返还率 Σ 1/odds (1.05 表示 105%)
+-- markets.margin_anomaly: under_round (低于 100%) / over_round (远高于预期)
+-- market_margin_history: 返还率变化时记录, 用于利润率趋势
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS overround DECIMAL(8, 4);
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS raw_overround DECIMAL(8, 4);
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS margin_anomaly VARCHAR(20);
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS margin_timestamp BIGINT;
+
+CREATE TABLE IF NOT EXISTS market_margin_history (
+    id BIGSERIAL PRIMARY KEY,
+    market_id INTEGER NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT,
+    overround DECIMAL(8, 4) NOT NULL,
+    raw_overround DECIMAL(8, 4),
+    expected_overround DECIMAL(8, 4),
+    anomaly VARCHAR(20),
+    timestamp BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_margin_history_event
+    ON market_margin_history(event_id, sr_market_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_market_margin_history_created_at
+    ON market_margin_history(created_at);
+CREATE INDEX IF NOT EXISTS idx_markets_margin_anomaly
+    ON markets(margin_timestamp DESC) WHERE margin_anomaly IS NOT NULL;
+
+-- 完成
+SELECT '✅ Migration 019: market margin analytics added' AS status;
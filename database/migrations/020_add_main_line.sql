@@ -0,0 +1,8 @@
+-- Migration 020: 主盘口标记
+-- 让球 (hcp) / 大小球 (total) 盘口族中, 结果概率最接近平手盘的线标记为主盘口
+-- 每次 odds_change 入库后重新计算
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS is_main_line BOOLEAN DEFAULT FALSE;
+
+-- 完成
+SELECT '✅ Migration 020: markets.is_main_line added' AS status;
@@ -0,0 +1,24 @@
+-- Migration 021: 收盘赔率快照
+-- 开赛时 (live producer 接管 / sport_event_status 变为 live / 盘口移交 -2) 保存每个结果最后的 prematch 赔率
+-- 每个结果只记录一次, 用于 CLV 分析和部分促销结算
+
+CREATE TABLE IF NOT EXISTS closing_lines (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    market_id INTEGER,
+    sr_market_id VARCHAR(200) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    outcome_name VARCHAR(200),
+    odds_value DECIMAL(10, 2),
+    raw_odds_value DECIMAL(10, 2),
+    active BOOLEAN,
+    producer_id INTEGER,
+    odds_timestamp BIGINT,
+    trigger VARCHAR(30) NOT NULL,
+    captured_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, sr_market_id, specifiers, outcome_id)
+);
+
+-- 完成
+SELECT '✅ Migration 021: closing_lines created' AS status;
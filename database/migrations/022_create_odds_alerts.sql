@@ -0,0 +1,48 @@
+-- Migration 022: 赔率异动告警
+-- odds_alert_rules: 按运动 / 盘口配置的告警规则 (price_move / suspension_count / prematch_drift)
+-- odds_alerts: 触发的告警, 同时推送到 WebSocket trader 告警流和飞书通知
+
+CREATE TABLE IF NOT EXISTS odds_alert_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL,
+    sport_id VARCHAR(50),
+    sr_market_id VARCHAR(50),
+    producer_id INTEGER,
+    threshold DECIMAL(10, 4) NOT NULL,
+    window_seconds INTEGER NOT NULL,
+    max_updates INTEGER NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 300,
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS odds_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id INTEGER,
+    rule_name VARCHAR(200) NOT NULL,
+    rule_type VARCHAR(30) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sport_id VARCHAR(50),
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT,
+    outcome_id VARCHAR(200),
+    producer_id INTEGER,
+    value DECIMAL(10, 4) NOT NULL,
+    threshold DECIMAL(10, 4) NOT NULL,
+    window_seconds INTEGER NOT NULL,
+    odds_from DECIMAL(10, 2),
+    odds_to DECIMAL(10, 2),
+    message TEXT NOT NULL,
+    timestamp BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_odds_alerts_event ON odds_alerts(event_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_odds_alerts_created_at ON odds_alerts(created_at);
+
+-- 完成
+SELECT '✅ Migration 022: odds_alert_rules and odds_alerts created' AS status;
@@ -0,0 +1,31 @@
+-- Migration 023: 赔率 K 线
+-- 每个结果按 1s / 10s / 1m / 5m 聚合 open / high / low / close、变化次数和暂停时长, 在赔率入库时增量计算
+-- 最后一根 K 线的 last_timestamp / closing_suspended 记录当前状态, 用于计算跨区间的暂停时长
+
+CREATE TABLE IF NOT EXISTS odds_candles (
+    id BIGSERIAL PRIMARY KEY,
+    market_id INTEGER NOT NULL,
+    event_id VARCHAR(100) NOT NULL,
+    sr_market_id VARCHAR(50) NOT NULL,
+    specifiers TEXT NOT NULL DEFAULT '',
+    outcome_id VARCHAR(200) NOT NULL,
+    interval VARCHAR(5) NOT NULL,
+    bucket_start BIGINT NOT NULL,
+    open DECIMAL(10, 2) NOT NULL,
+    high DECIMAL(10, 2) NOT NULL,
+    low DECIMAL(10, 2) NOT NULL,
+    close DECIMAL(10, 2) NOT NULL,
+    changes INTEGER NOT NULL DEFAULT 0,
+    suspended_ms BIGINT NOT NULL DEFAULT 0,
+    last_timestamp BIGINT NOT NULL,
+    closing_suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (market_id, outcome_id, interval, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_odds_candles_lookup ON odds_candles(event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start DESC);
+CREATE INDEX IF NOT EXISTS idx_odds_candles_created_at ON odds_candles(created_at);
+
+-- 完成
+SELECT '✅ Migration 023: odds_candles created' AS status;
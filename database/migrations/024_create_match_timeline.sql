@@ -0,0 +1,26 @@
+-- Migration 024: 比赛时间线
+-- 由 odds_change 中 sport_event_status 的变化生成: 状态 / 阶段 / 比分 / 牌 / 角球 / 时钟停止 / 补时
+-- uof_timestamp 为 odds_change 的时间戳, 便于与赔率变化对齐
+
+CREATE TABLE IF NOT EXISTS match_timeline (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(100) NOT NULL,
+    entry_type VARCHAR(30) NOT NULL,
+    team VARCHAR(10),
+    value INTEGER NOT NULL DEFAULT 0,
+    home_score INTEGER,
+    away_score INTEGER,
+    status VARCHAR(10),
+    match_status VARCHAR(10),
+    match_time VARCHAR(20),
+    description TEXT,
+    producer_id INTEGER,
+    uof_timestamp BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_timeline_event ON match_timeline(event_id, uof_timestamp);
+CREATE INDEX IF NOT EXISTS idx_match_timeline_created_at ON match_timeline(created_at);
+
+-- 完成
+SELECT '✅ Migration 024: match_timeline created' AS status;
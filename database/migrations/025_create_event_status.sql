@@ -0,0 +1,24 @@
+-- Migration 025: 完整赛事状态
+-- 保存 sport_event_status 的全部内容: 状态 / 比分 / 胜者 / 时钟 / 分段比分 / 统计 / 运动特有属性
+-- 来源为 odds_change、fixture 或 REST sport_event_summary; 消息中缺少的部分保留原值
+
+CREATE TABLE IF NOT EXISTS event_status (
+    event_id VARCHAR(100) PRIMARY KEY,
+    status VARCHAR(30),
+    match_status VARCHAR(50),
+    home_score INTEGER,
+    away_score INTEGER,
+    winner_id VARCHAR(100),
+    clock JSONB,
+    period_scores JSONB,
+    statistics JSONB,
+    sport_details JSONB,
+    source VARCHAR(20) NOT NULL,
+    uof_timestamp BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_status_updated_at ON event_status(updated_at);
+
+-- 完成
+SELECT '✅ Migration 025: event_status created' AS status;
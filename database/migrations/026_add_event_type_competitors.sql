@@ -0,0 +1,14 @@
+-- Migration 026: 非对阵赛事 (outright / stage / season / simple_tournament) 支持
+-- event_type 为赛事 URN 的类型 (match / stage / season / ...), 旧数据为空按 match 处理
+-- competitors 为完整参赛方列表 (JSON 数组), 非对阵赛事没有 home / away
+
+ALTER TABLE tracked_events ADD COLUMN IF NOT EXISTS event_type VARCHAR(30);
+ALTER TABLE tracked_events ADD COLUMN IF NOT EXISTS competitors JSONB;
+
+UPDATE tracked_events SET event_type = split_part(event_id, ':', 2)
+WHERE event_type IS NULL AND event_id LIKE '%:%:%';
+
+CREATE INDEX IF NOT EXISTS idx_tracked_events_event_type ON tracked_events(event_type);
+
+-- 完成
+SELECT '✅ Migration 026: tracked_events.event_type / competitors added' AS status;
@@ -0,0 +1,22 @@
+-- Migration 027: 多语言名称
+-- localized_names: 参赛方 / 球员 / 赛事 / 联赛 / 运动 / 比赛状态在各语言 (LANGUAGES) 下的名称
+-- market_description_translations: 其它语言的盘口 / 结果名称模板, outcome_id 为空表示盘口名称
+
+CREATE TABLE IF NOT EXISTS localized_names (
+    urn VARCHAR(150) NOT NULL,
+    lang VARCHAR(10) NOT NULL,
+    name TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (urn, lang)
+);
+
+CREATE TABLE IF NOT EXISTS market_description_translations (
+    market_id VARCHAR(50) NOT NULL,
+    outcome_id VARCHAR(200) NOT NULL DEFAULT '',
+    lang VARCHAR(10) NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (market_id, outcome_id, lang)
+);
+
+-- 完成
+SELECT '✅ Migration 027: localized_names / market_description_translations created' AS status;
@@ -0,0 +1,34 @@
+-- Migration 028: 参赛方资料
+-- competitors: Sports API competitor profile (简称 / 国家 / 性别 / 年龄组 / 主场 / 球衣 / 主教练), 超过 COMPETITOR_PROFILE_TTL_HOURS 后刷新
+-- competitor_players: 参赛方阵容, 球员信息写入 players 表
+
+CREATE TABLE IF NOT EXISTS competitors (
+    competitor_id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    abbreviation VARCHAR(20),
+    country VARCHAR(100),
+    country_code VARCHAR(10),
+    gender VARCHAR(20),
+    age_group VARCHAR(20),
+    sport_id VARCHAR(50),
+    venue JSONB,
+    jerseys JSONB,
+    manager JSONB,
+    fetched_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS competitor_players (
+    competitor_id VARCHAR(50) NOT NULL,
+    player_id VARCHAR(50) NOT NULL,
+    player_type VARCHAR(50),
+    jersey_number INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (competitor_id, player_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_competitors_fetched_at ON competitors(fetched_at);
+CREATE INDEX IF NOT EXISTS idx_competitor_players_player ON competitor_players(player_id);
+
+-- 完成
+SELECT '✅ Migration 028: competitors / competitor_players created' AS status;
@@ -0,0 +1,18 @@
+-- Migration 029: 盘口描述变化记录
+-- 刷新市场描述 (定时 / 手动 / feed 变化通知) 时与旧描述比较, 每项变化一行
+-- change_type: added / removed / market_name / groups / specifiers / outcome / mapping
+
+CREATE TABLE IF NOT EXISTS market_description_changes (
+    id BIGSERIAL PRIMARY KEY,
+    market_id VARCHAR(50) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    outcome_id VARCHAR(200),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_description_changes_market ON market_description_changes(market_id, id DESC);
+
+-- 完成
+SELECT '✅ Migration 029: market_description_changes created' AS status;
@@ -0,0 +1,12 @@
+-- Migration 030: 盘口组
+-- groups 来自 market_descriptions.groups, 格式 "all|score|regular_play" (与 bet_stop 的 groups 属性一致)
+-- 用于按组暂停 (bet_stop)、盘口标签页和赛事按盘口组筛选
+
+ALTER TABLE markets ADD COLUMN IF NOT EXISTS groups VARCHAR(200);
+
+UPDATE markets m SET groups = md.groups
+FROM market_descriptions md
+WHERE md.market_id = m.sr_market_id AND m.groups IS NULL AND COALESCE(md.groups, '') != '';
+
+-- 完成
+SELECT '✅ Migration 030: markets.groups added' AS status;
@@ -0,0 +1,10 @@
+-- Migration 031: K 线按 (event_id, sr_market_id, specifiers, outcome_id) 定位
+-- markets.id 在重置数据库后会被新盘口复用, 按 market_id 查找上一根 K 线会继承其它赛事的收盘价 / 暂停状态
+
+ALTER TABLE odds_candles DROP CONSTRAINT IF EXISTS odds_candles_market_id_outcome_id_interval_bucket_start_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_odds_candles_natural_key
+    ON odds_candles(event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start);
+
+-- 完成
+SELECT '✅ Migration 031: odds_candles keyed by event / market / specifiers / outcome' AS status;
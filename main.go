@@ -84,12 +84,26 @@ func main() {
 	// 创建消息存储服务
 	messageStore := services.NewMessageStore(db)
 	
+	// 创建多语言名称服务 (LANGUAGES)
+	localizationService := services.NewLocalizationService(db, cfg.AccessToken, cfg.APIBaseURL, cfg.Languages)
+	if err := localizationService.Start(); err != nil {
+		logger.Errorf("[Localization] ⚠️  Failed to start: %v", err)
+	}
+	
 	// 创建 Players 服务
 	playersService := services.NewPlayersService(cfg.AccessToken, cfg.APIBaseURL, db)
+	playersService.SetLocalizationService(localizationService)
 	if err := playersService.Start(); err != nil {
 		logger.Errorf("[PlayersService] ⚠️  Failed to start: %v", err)
 	}
 	
+	// 创建参赛方资料服务
+	competitorService := services.NewCompetitorService(cfg.AccessToken, cfg.APIBaseURL, db, time.Duration(cfg.CompetitorProfileTTLHours)*time.Hour)
+	competitorService.SetPlayersService(playersService)
+	if err := competitorService.Start(); err != nil {
+		logger.Errorf("[CompetitorService] ⚠️  Failed to start: %v", err)
+	}
+	
 	// 创建 Schedule 服务
 	scheduleService := services.NewScheduleService(db, cfg.AccessToken, cfg.APIBaseURL)
 	
@@ -112,6 +126,7 @@ func main() {
 	marketDescService := services.NewMarketDescriptionsService(cfg.AccessToken, cfg.APIBaseURL)
 	marketDescService.SetDatabase(db) // 注入数据库连接 (可选)
 	marketDescService.SetPlayersService(playersService) // 注入球员服务 (可选)
+	marketDescService.SetLocalizationService(localizationService) // 注入多语言名称服务 (可选)
 	if err := marketDescService.Start(); err != nil {
 		logger.Errorf("[MarketDescService] ⚠️  Failed to start: %v", err)
 	} else {
@@ -170,7 +185,8 @@ func main() {
 			// -------------------------------------------------------------------
 			// 创建 Message Processor 实例
 			// 注意：这里需要 wsHub 和 marketDescService，因为业务逻辑已迁移到这里
-			processor := services.NewMessageProcessor(cfg, messageStore, broker, wsHub, marketDescService)
+			processor := services.NewMessageProcessor(cfg, messageStore, broker, wsHub, marketDescService, larkNotifier)
+			processor.SetLocalizationService(localizationService)
 			
 			// 定义需要处理的消息类型 (Topic)
 			messageTypes := []string{
@@ -182,6 +198,7 @@ func main() {
 				"fixture_change", 
 				"rollback_bet_settlement", 
 				"rollback_bet_cancel",
+				"market_descriptions_changed",
 			}
 			
 			// 为每种消息类型启动一个独立的消费者
@@ -193,8 +210,15 @@ func main() {
 			
 			logger.Println("[Processor] ✅ Message Processor started for all business topics")
 
+	// 创建静态数据服务 (Web 服务器查询作废原因描述时使用其缓存)
+	staticDataService := services.NewStaticDataService(db, cfg.AccessToken, cfg.APIBaseURL)
+	staticDataService.SetLocalizationService(localizationService)
+
 	// 启动Web服务器
 	server := web.NewServer(cfg, db, wsHub, larkNotifier, marketDescService)
+	server.SetLocalizationService(localizationService)
+	server.SetCompetitorService(competitorService)
+	server.SetStaticDataService(staticDataService)
 	
 	go func() {
 		if err := server.Start(); err != nil {
@@ -224,7 +248,6 @@ func main() {
 	logger.Println("Match monitor started (hourly)")
 	
 	// 启动静态数据服务 (每周刷新一次)
-	staticDataService := services.NewStaticDataService(db, cfg.AccessToken, cfg.APIBaseURL)
 	if err := staticDataService.Start(); err != nil {
 		logger.Errorf("[StaticData] ⚠️  Failed to start: %v", err)
 	} else {
@@ -259,6 +282,9 @@ func main() {
 		RetainDaysBets:     cfg.CleanupRetainDaysBets,
 		RetainDaysLiveData: cfg.CleanupRetainDaysLiveData,
 		RetainDaysEvents:   cfg.CleanupRetainDaysEvents,
+		RetainDaysAudit:    cfg.CleanupRetainDaysAudit,
+		RetainDaysCandles:  cfg.CleanupRetainDaysCandles,
+		RetainDaysClosingLines: cfg.CleanupRetainDaysClosingLines,
 	}
 	dataCleanup := services.NewDataCleanupService(db, cleanupConfig)
 	
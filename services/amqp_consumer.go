@@ -1,14 +1,13 @@
 package services
 
 import (
-	"bytes"
-	"encoding/xml"
 	"time"
 
 	"github.com/streadway/amqp"
 
 	"uof-service/config"
 	"uof-service/logger"
+	"uof-service/uof"
 )
 
 // MessageBroadcaster 接口用于广播消息，避免循环依赖
@@ -101,8 +100,23 @@ func (c *AMQPConsumer) processMessage(msg amqp.Delivery) {
 	routingKey := msg.RoutingKey
 	xmlContent := string(msg.Body)
 
-	// 解析消息类型
-	messageType, eventID, productID, sportID, timestamp := c.parseMessage(xmlContent)
+	// 解码消息 (只解析一次, 类型化的消息随 BrokerMessage 传给各处理器)
+	decoded, err := uof.Decode(msg.Body)
+	if err != nil {
+		logger.Errorf("Failed to decode message (routing key %s): %v", routingKey, err)
+		decoded = &uof.Message{Raw: msg.Body}
+	}
+	messageType := decoded.Type
+	eventID := decoded.EventID
+	timestamp := decoded.Timestamp
+	var productID *int
+	if decoded.ProductID != 0 {
+		productID = &decoded.ProductID
+	}
+	var sportID *string
+	if decoded.SportID != "" {
+		sportID = &decoded.SportID
+	}
 
 	// 统计消息
 	if messageType != "" && c.statsTracker != nil {
@@ -120,9 +134,10 @@ func (c *AMQPConsumer) processMessage(msg amqp.Delivery) {
 	if c.broker != nil && messageType != "" {
 		topic := GetTopicName(messageType)
 		brokerMsg := BrokerMessage{
-			Topic: topic,
-			Key:   eventID, // 使用 eventID 作为 Key，确保同一赛事的顺序性
-			Value: msg.Body, // 发送原始字节，避免二次转换
+			Topic:   topic,
+			Key:     eventID, // 使用 eventID 作为 Key，确保同一赛事的顺序性
+			Value:   msg.Body, // 发送原始字节，避免二次转换
+			Message: decoded,
 		}
 		if err := c.broker.Produce(brokerMsg); err != nil {
 			logger.Errorf("Failed to produce message to broker topic %s: %v", topic, err)
@@ -132,54 +147,16 @@ func (c *AMQPConsumer) processMessage(msg amqp.Delivery) {
 	
 	// 仅保留 Ingestor 必须处理的逻辑：alive 和 snapshot_complete
 	switch messageType {
-	case "alive":
-		c.handleAlive(xmlContent)
-	case "snapshot_complete":
-		c.handleSnapshotComplete(xmlContent)
+	case uof.TypeAlive:
+		c.handleAlive(decoded.Alive)
+	case uof.TypeSnapshotComplete:
+		c.handleSnapshotComplete(decoded.SnapshotComplete)
 	}
 	
 	// 移除 WebSocket 广播逻辑，这应该由 MessageProcessor 或单独的模块处理
 	// 移除所有业务处理逻辑 (odds_change, bet_stop, fixture, etc.)
 }
 
-// parseMessage 解析消息基本信息
-func (c *AMQPConsumer) parseMessage(xmlContent string) (messageType, eventID string, productID *int, sportID *string, timestamp int64) {
-	type BaseMessage struct {
-		EventID   string `xml:"event_id,attr"`
-		ProductID int    `xml:"product,attr"`
-		SportID   string `xml:"sport_id,attr"`
-		Timestamp int64  `xml:"timestamp,attr"`
-	}
-
-	decoder := xml.NewDecoder(bytes.NewReader([]byte(xmlContent)))
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			break
-		}
-		if startElement, ok := token.(xml.StartElement); ok {
-			messageType = startElement.Name.Local
-			break
-		}
-	}
-
-	var base BaseMessage
-	xml.Unmarshal([]byte(xmlContent), &base)
-
-	if base.EventID != "" {
-		eventID = base.EventID
-	}
-	if base.ProductID != 0 {
-		productID = &base.ProductID
-	}
-	if base.SportID != "" {
-		sportID = &base.SportID
-	}
-	timestamp = base.Timestamp
-
-	return
-}
-
 // extractMessageData 提取用于广播的附加数据
 func (c *AMQPConsumer) extractMessageData(messageType, xmlContent string) interface{} {
 	// ... (此处省略了具体实现，与原文件一致)
@@ -187,19 +164,7 @@ func (c *AMQPConsumer) extractMessageData(messageType, xmlContent string) interf
 }
 
 // handleAlive 处理 alive 消息
-func (c *AMQPConsumer) handleAlive(xmlContent string) {
-	type AliveMessage struct {
-		ProductID  int   `xml:"product,attr"`
-		Timestamp  int64 `xml:"timestamp,attr"`
-		Subscribed int   `xml:"subscribed,attr"`
-	}
-
-	var alive AliveMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &alive); err != nil {
-		logger.Errorf("Failed to parse alive message: %v", err)
-		return
-	}
-
+func (c *AMQPConsumer) handleAlive(alive *uof.Alive) {
 	if err := c.messageStore.UpdateProducerStatus(alive.ProductID, alive.Timestamp, alive.Subscribed); err != nil {
 		logger.Errorf("Failed to update producer status: %v", err)
 	}
@@ -208,19 +173,7 @@ func (c *AMQPConsumer) handleAlive(xmlContent string) {
 // 移除所有业务处理函数，它们将被 MessageProcessor 模块取代
 
 // handleSnapshotComplete 处理 snapshot_complete 消息
-func (c *AMQPConsumer) handleSnapshotComplete(xmlContent string) {
-	type SnapshotComplete struct {
-		ProductID int   `xml:"product,attr"`
-		RequestID int   `xml:"request_id,attr"`
-		Timestamp int64 `xml:"timestamp,attr"`
-	}
-
-	var snapshot SnapshotComplete
-	if err := xml.Unmarshal([]byte(xmlContent), &snapshot); err != nil {
-		logger.Errorf("Failed to parse snapshot_complete: %v", err)
-		return
-	}
-
+func (c *AMQPConsumer) handleSnapshotComplete(snapshot *uof.SnapshotComplete) {
 	if err := c.messageStore.UpdateRecoveryCompleted(snapshot.RequestID, snapshot.ProductID, snapshot.Timestamp); err != nil {
 		logger.Errorf("Failed to update recovery status: %v", err)
 	}
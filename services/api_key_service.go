@@ -0,0 +1,239 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"uof-service/logger"
+)
+
+// APIKeyRole API Key 角色
+type APIKeyRole string
+
+const (
+	RoleRead   APIKeyRole = "read"   // 只读查询
+	RoleTrader APIKeyRole = "trader" // 交易员: 只读 + 单场操作
+	RoleAdmin  APIKeyRole = "admin"  // 管理员: 所有操作
+)
+
+// roleLevels 角色权限等级, 高等级包含低等级权限
+var roleLevels = map[APIKeyRole]int{
+	RoleRead:   1,
+	RoleTrader: 2,
+	RoleAdmin:  3,
+}
+
+// ParseAPIKeyRole 解析角色名称
+func ParseAPIKeyRole(role string) (APIKeyRole, error) {
+	r := APIKeyRole(role)
+	if _, ok := roleLevels[r]; !ok {
+		return "", fmt.Errorf("invalid role: %s (expected read, trader or admin)", role)
+	}
+	return r, nil
+}
+
+// Allows 判断角色是否具有 required 角色的权限
+func (r APIKeyRole) Allows(required APIKeyRole) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// APIKey API Key 信息 (不含明文)
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Role       APIKeyRole `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type cachedAPIKey struct {
+	key       *APIKey // nil 表示无效 key
+	expiresAt time.Time
+}
+
+// APIKeyService API Key 管理和校验
+type APIKeyService struct {
+	db       *sql.DB
+	cache    map[string]cachedAPIKey // key_hash -> APIKey
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+}
+
+// apiKeyPrefix 明文 key 前缀, 方便识别
+const apiKeyPrefix = "uof_"
+
+// NewAPIKeyService 创建 API Key 服务
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{
+		db:       db,
+		cache:    make(map[string]cachedAPIKey),
+		cacheTTL: 60 * time.Second,
+	}
+}
+
+// hashAPIKey 计算 key 的 SHA-256 哈希
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue 签发新的 API Key, 返回明文 key (只在签发时可见)
+func (s *APIKeyService) Issue(name string, role APIKeyRole) (string, *APIKey, error) {
+	if name == "" {
+		return "", nil, fmt.Errorf("name is required")
+	}
+	if _, err := ParseAPIKeyRole(string(role)); err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	plain := apiKeyPrefix + hex.EncodeToString(buf)
+	prefix := plain[:len(apiKeyPrefix)+8]
+
+	key := &APIKey{Name: name, KeyPrefix: prefix, Role: role}
+	err := s.db.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, role, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`, name, prefix, hashAPIKey(plain), string(role)).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	logger.Printf("[APIKeyService] ✅ Issued API key %d (%s, role=%s)", key.ID, prefix, role)
+	return plain, key, nil
+}
+
+// Revoke 吊销 API Key (按 ID)
+func (s *APIKeyService) Revoke(id int) error {
+	result, err := s.db.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %d not found or already revoked", id)
+	}
+
+	// 清空缓存, 使吊销立即生效
+	s.mu.Lock()
+	s.cache = make(map[string]cachedAPIKey)
+	s.mu.Unlock()
+
+	logger.Printf("[APIKeyService] 🔒 Revoked API key %d", id)
+	return nil
+}
+
+// List 列出所有 API Key
+func (s *APIKeyService) List() ([]APIKey, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, key_prefix, role, created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		var key APIKey
+		var role string
+		var lastUsedAt, revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.KeyPrefix, &role, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		key.Role = APIKeyRole(role)
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Authenticate 校验明文 key, 返回有效的 APIKey; 无效时返回 nil
+func (s *APIKeyService) Authenticate(plain string) *APIKey {
+	if plain == "" {
+		return nil
+	}
+
+	hash := hashAPIKey(plain)
+	now := time.Now()
+
+	s.mu.RLock()
+	cached, ok := s.cache[hash]
+	s.mu.RUnlock()
+	if ok && now.Before(cached.expiresAt) {
+		// trader / admin key 命中缓存时仍检查是否已吊销 (cmd/apikey revoke 在另一进程中执行, 只清空自己的缓存)
+		if cached.key != nil && cached.key.Role.Allows(RoleTrader) && s.isRevoked(cached.key.ID) {
+			s.mu.Lock()
+			s.cache[hash] = cachedAPIKey{key: nil, expiresAt: now.Add(s.cacheTTL)}
+			s.mu.Unlock()
+			return nil
+		}
+		return cached.key
+	}
+
+	var key APIKey
+	var role string
+	err := s.db.QueryRow(`
+		SELECT id, name, key_prefix, role, created_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, hash).Scan(&key.ID, &key.Name, &key.KeyPrefix, &role, &key.CreatedAt)
+
+	var result *APIKey
+	switch {
+	case err == nil:
+		key.Role = APIKeyRole(role)
+		result = &key
+		// 只在缓存未命中时更新 (每个 key 最多每个缓存周期一次), 失败不影响鉴权
+		if _, err := s.db.Exec(`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, key.ID); err != nil {
+			logger.Errorf("[APIKeyService] Failed to update last_used_at for api key %d: %v", key.ID, err)
+		}
+	case err == sql.ErrNoRows:
+		result = nil
+	default:
+		// 数据库错误不缓存
+		logger.Errorf("[APIKeyService] Failed to authenticate api key: %v", err)
+		return nil
+	}
+
+	s.mu.Lock()
+	if len(s.cache) > 10000 {
+		// 防止大量无效 key 撑大缓存
+		s.cache = make(map[string]cachedAPIKey)
+	}
+	s.cache[hash] = cachedAPIKey{key: result, expiresAt: now.Add(s.cacheTTL)}
+	s.mu.Unlock()
+
+	return result
+}
+
+// isRevoked 检查 key 是否已被吊销 (数据库错误时按未吊销处理, 与缓存命中时的行为一致)
+func (s *APIKeyService) isRevoked(id int) bool {
+	var revoked bool
+	if err := s.db.QueryRow(`SELECT revoked_at IS NOT NULL FROM api_keys WHERE id = $1`, id).Scan(&revoked); err != nil {
+		if err == sql.ErrNoRows {
+			return true
+		}
+		logger.Errorf("[APIKeyService] Failed to check revocation of api key %d: %v", id, err)
+		return false
+	}
+	return revoked
+}
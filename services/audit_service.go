@@ -0,0 +1,165 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"uof-service/logger"
+)
+
+// AuditEntry 审计日志条目
+type AuditEntry struct {
+	ID         int64                  `json:"id"`
+	APIKeyID   *int                   `json:"api_key_id,omitempty"`
+	APIKeyName string                 `json:"api_key_name,omitempty"`
+	Role       string                 `json:"role,omitempty"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	Route      string                 `json:"route"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	StatusCode int                    `json:"status_code"`
+	Success    bool                   `json:"success"`
+	Result     string                 `json:"result,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditFilter 审计日志查询条件
+type AuditFilter struct {
+	APIKeyID *int
+	Route    string
+	Method   string
+	Success  *bool
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuditService 审计日志服务
+type AuditService struct {
+	db *sql.DB
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record 写入一条审计日志
+func (s *AuditService) Record(entry *AuditEntry) error {
+	var params []byte
+	if entry.Params != nil {
+		data, err := json.Marshal(entry.Params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal params: %w", err)
+		}
+		params = data
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO audit_log (
+			api_key_id, api_key_name, role, method, path, route, params,
+			status_code, success, result, duration_ms, remote_addr, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+	`,
+		entry.APIKeyID, entry.APIKeyName, entry.Role, entry.Method, entry.Path, entry.Route, params,
+		entry.StatusCode, entry.Success, entry.Result, entry.DurationMs, entry.RemoteAddr,
+	)
+	if err != nil {
+		logger.Errorf("[AuditService] Failed to record %s %s: %v", entry.Method, entry.Path, err)
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Query 查询审计日志, 返回结果和总数
+func (s *AuditService) Query(filter AuditFilter) ([]AuditEntry, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	addCondition := func(expr string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(expr, len(args)))
+	}
+
+	if filter.APIKeyID != nil {
+		addCondition("api_key_id = $%d", *filter.APIKeyID)
+	}
+	if filter.Route != "" {
+		addCondition("route = $%d", filter.Route)
+	}
+	if filter.Method != "" {
+		addCondition("method = $%d", strings.ToUpper(filter.Method))
+	}
+	if filter.Success != nil {
+		addCondition("success = $%d", *filter.Success)
+	}
+	if filter.Since != nil {
+		addCondition("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("created_at < $%d", *filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM audit_log "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit, filter.Offset)
+
+	query := fmt.Sprintf(`
+		SELECT id, api_key_id, COALESCE(api_key_name, ''), COALESCE(role, ''), method, path,
+		       COALESCE(route, ''), params, COALESCE(status_code, 0), COALESCE(success, false),
+		       COALESCE(result, ''), COALESCE(duration_ms, 0), COALESCE(remote_addr, ''), created_at
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		var apiKeyID sql.NullInt64
+		var params []byte
+		if err := rows.Scan(
+			&entry.ID, &apiKeyID, &entry.APIKeyName, &entry.Role, &entry.Method, &entry.Path,
+			&entry.Route, &params, &entry.StatusCode, &entry.Success,
+			&entry.Result, &entry.DurationMs, &entry.RemoteAddr, &entry.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if apiKeyID.Valid {
+			id := int(apiKeyID.Int64)
+			entry.APIKeyID = &id
+		}
+		if len(params) > 0 {
+			json.Unmarshal(params, &entry.Params)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
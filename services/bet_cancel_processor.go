@@ -1,50 +1,33 @@
 package services
 
 import (
-	"os"
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
-	)
+	"os"
+	"strconv"
+
+	"uof-service/uof"
+)
 
 // BetCancelProcessor Bet Cancel 消息处理器
 type BetCancelProcessor struct {
-db     *sql.DB
-logger *log.Logger
-}
-
-// BetCancelMessage Bet Cancel 消息结构
-type BetCancelMessage struct {
-XMLName      xml.Name `xml:"bet_cancel"`
-EventID      string   `xml:"event_id,attr"`
-ProductID    int      `xml:"product,attr"`
-Timestamp    int64    `xml:"timestamp,attr"`
-StartTime    *int64   `xml:"start_time,attr"`
-EndTime      *int64   `xml:"end_time,attr"`
-SupercededBy *string  `xml:"superceded_by,attr"`
-Market       []struct {
-ID         string `xml:"id,attr"`
-Specifiers string `xml:"specifiers,attr"`
-VoidReason *int   `xml:"void_reason,attr"`
-} `xml:"market"`
+db      *sql.DB
+cancels *BetCancelService
+logger  *log.Logger
 }
 
 // NewBetCancelProcessor 创建 Bet Cancel 处理器
 func NewBetCancelProcessor(db *sql.DB) *BetCancelProcessor {
 return &BetCancelProcessor{
-db:     db,
-logger: log.New(os.Stdout, "", log.LstdFlags),
+db:      db,
+cancels: NewBetCancelService(db),
+logger:  log.New(os.Stdout, "", log.LstdFlags),
 }
 }
 
 // ProcessBetCancel 处理 Bet Cancel 消息并更新 market status
-func (p *BetCancelProcessor) ProcessBetCancel(xmlContent string) error {
-var betCancel BetCancelMessage
-if err := xml.Unmarshal([]byte(xmlContent), &betCancel); err != nil {
-return fmt.Errorf("failed to parse bet_cancel message: %w", err)
-}
-
+func (p *BetCancelProcessor) ProcessBetCancel(betCancel *uof.BetCancel) error {
 // 开始事务
 tx, err := p.db.Begin()
 if err != nil {
@@ -53,13 +36,8 @@ return fmt.Errorf("failed to begin transaction: %w", err)
 	defer tx.Rollback()
 	
 		// 遍历所有市场
-		for _, market := range betCancel.Market {
-			var marketID int64
-			marketID, err = ExtractMarketIDFromURN(market.ID)
-			if err != nil {
-			p.logger.Printf("Warning: failed to extract market ID from URN %s: %v", market.ID, err)
-			continue
-		}
+		for _, market := range betCancel.Markets {
+			marketID := strconv.Itoa(market.ID)
 
 	// 存储到 bet_cancels 表
 query := `
@@ -109,14 +87,20 @@ WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3
 	}
 }
 
+// 记录取消时间窗口 (与原始记录同一事务)
+windows, err := p.cancels.RecordCancel(tx, betCancel)
+if err != nil {
+return fmt.Errorf("failed to record bet cancel windows: %w", err)
+}
+
 // 提交事务
 if err := tx.Commit(); err != nil {
 return fmt.Errorf("failed to commit transaction: %w", err)
 }
 
 // 输出自然语言日志
-p.logger.Printf("[bet_cancel] 比赛 %s 的 %d个市场已取消",
-betCancel.EventID, len(betCancel.Market))
+p.logger.Printf("[bet_cancel] 比赛 %s 的 %d个市场已取消, 新增 %d 个取消窗口",
+betCancel.EventID, len(betCancel.Markets), windows)
 
 return nil
 }
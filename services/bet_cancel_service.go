@@ -0,0 +1,240 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"uof-service/uof"
+)
+
+// BetCancelWindow 投注取消时间窗口
+// start_time 为空表示从开盘起, end_time 为空表示直到结束; 两者都为空表示该市场所有投注作废
+type BetCancelWindow struct {
+	ID                    int64     `json:"id"`
+	EventID               string    `json:"event_id"`
+	ProducerID            int       `json:"producer_id"`
+	SRMarketID            string    `json:"sr_market_id"`
+	Specifiers            string    `json:"specifiers"`
+	VoidReason            *int      `json:"void_reason"`
+	VoidReasonDescription string    `json:"void_reason_description,omitempty"`
+	StartTime             *int64    `json:"start_time"`
+	EndTime               *int64    `json:"end_time"`
+	SupersededBy          string    `json:"superseded_by,omitempty"`
+	MessageTimestamp      int64     `json:"message_timestamp"`
+	RolledBack            bool      `json:"rolled_back"`
+	RollbackTimestamp     *int64    `json:"rollback_timestamp,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// Covers 判断投注时间是否落在窗口内 (毫秒时间戳, 左闭右开)
+func (w *BetCancelWindow) Covers(placedAt int64) bool {
+	if w.StartTime != nil && placedAt < *w.StartTime {
+		return false
+	}
+	if w.EndTime != nil && placedAt >= *w.EndTime {
+		return false
+	}
+	return true
+}
+
+// BetVoidStatus 投注作废查询结果
+type BetVoidStatus struct {
+	EventID               string                 `json:"event_id"`
+	SRMarketID            string                 `json:"sr_market_id"`
+	Specifiers            string                 `json:"specifiers"`
+	OutcomeID             string                 `json:"outcome_id,omitempty"`
+	PlacedAt              int64                  `json:"placed_at"`
+	Voided                bool                   `json:"voided"`
+	VoidReason            *int                   `json:"void_reason"`
+	VoidReasonDescription string                 `json:"void_reason_description,omitempty"`
+	SupersededBy          string                 `json:"superseded_by,omitempty"`
+	Windows               []BetCancelWindow      `json:"windows"`
+	Settlement            *SettlementLedgerEntry `json:"settlement"`
+}
+
+// BetCancelService 投注取消窗口服务
+type BetCancelService struct {
+	db         *sql.DB
+	ledger     *SettlementLedgerService
+	staticData *StaticDataService // 作废原因描述 (可选)
+}
+
+// NewBetCancelService 创建投注取消窗口服务
+func NewBetCancelService(db *sql.DB) *BetCancelService {
+	return &BetCancelService{
+		db:     db,
+		ledger: NewSettlementLedgerService(db),
+	}
+}
+
+// SetStaticDataService 设置静态数据服务 (可选, 设置后查询结果带 void_reason_description)
+func (s *BetCancelService) SetStaticDataService(staticData *StaticDataService) {
+	s.staticData = staticData
+}
+
+// RecordCancel 在事务内为 bet_cancel 的每个市场写入取消窗口
+// 已存在相同 (含 void_reason) 且未回滚的窗口时跳过 (重复消息、recovery 重放);
+// void_reason 不同时写入新窗口, 查询时较新的窗口覆盖旧的作废原因
+func (s *BetCancelService) RecordCancel(tx *sql.Tx, betCancel *uof.BetCancel) (int, error) {
+	supersededBy := ""
+	if betCancel.SupercededBy != nil {
+		supersededBy = *betCancel.SupercededBy
+	}
+
+	recorded := 0
+	for _, market := range betCancel.Markets {
+		result, err := tx.Exec(`
+			INSERT INTO bet_cancel_windows (
+				event_id, producer_id, sr_market_id, specifiers, void_reason,
+				start_time, end_time, superseded_by, message_timestamp, created_at
+			)
+			SELECT $1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NOW()
+			WHERE NOT EXISTS (
+				SELECT 1 FROM bet_cancel_windows
+				WHERE event_id = $1 AND sr_market_id = $3 AND specifiers = $4
+				  AND start_time IS NOT DISTINCT FROM $6::BIGINT
+				  AND end_time IS NOT DISTINCT FROM $7::BIGINT
+				  AND void_reason IS NOT DISTINCT FROM $5::INTEGER
+				  AND rollback_timestamp IS NULL
+			)
+		`,
+			betCancel.EventID, betCancel.ProductID, strconv.Itoa(market.ID), market.Specifiers, market.VoidReason,
+			betCancel.StartTime, betCancel.EndTime, supersededBy, betCancel.Timestamp,
+		)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to insert bet cancel window: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			recorded++
+		}
+	}
+
+	return recorded, nil
+}
+
+// RecordRollback 在事务内回滚匹配的取消窗口
+// rollback_bet_cancel 带有原 bet_cancel 的 start_time/end_time, 按此精确匹配
+func (s *BetCancelService) RecordRollback(tx *sql.Tx, rollback *uof.RollbackBetCancel) (int, error) {
+	rolledBack := 0
+	for _, market := range rollback.Markets {
+		result, err := tx.Exec(`
+			UPDATE bet_cancel_windows
+			SET rollback_timestamp = $5
+			WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3
+			  AND start_time IS NOT DISTINCT FROM $6::BIGINT
+			  AND end_time IS NOT DISTINCT FROM $7::BIGINT
+			  AND rollback_timestamp IS NULL
+			  AND message_timestamp <= $5
+			  AND ($4 = 0 OR producer_id = $4)
+		`,
+			rollback.EventID, strconv.Itoa(market.ID), market.Specifiers, rollback.ProductID,
+			rollback.Timestamp, rollback.StartTime, rollback.EndTime,
+		)
+		if err != nil {
+			return rolledBack, fmt.Errorf("failed to roll back bet cancel window: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rolledBack += int(n)
+		}
+	}
+
+	return rolledBack, nil
+}
+
+// GetWindows 获取赛事的所有取消窗口 (包括已回滚的)
+func (s *BetCancelService) GetWindows(eventID string) ([]BetCancelWindow, error) {
+	return s.queryWindows(`WHERE w.event_id = $1`, eventID)
+}
+
+// CheckVoided 判断在 placedAt (毫秒) 投注该结果是否被作废
+// 只考虑未回滚的窗口; 多个窗口同时命中时使用最新的一条的 void_reason
+func (s *BetCancelService) CheckVoided(eventID, marketID, specifiers, outcomeID string, placedAt int64) (*BetVoidStatus, error) {
+	marketID = NormalizeMarketID(marketID)
+	windows, err := s.queryWindows(
+		`WHERE w.event_id = $1 AND w.sr_market_id = $2 AND w.specifiers = $3 AND w.rollback_timestamp IS NULL`,
+		eventID, marketID, specifiers,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BetVoidStatus{
+		EventID:    eventID,
+		SRMarketID: marketID,
+		Specifiers: specifiers,
+		OutcomeID:  outcomeID,
+		PlacedAt:   placedAt,
+		Windows:    make([]BetCancelWindow, 0),
+	}
+
+	for _, w := range windows {
+		if !w.Covers(placedAt) {
+			continue
+		}
+		status.Windows = append(status.Windows, w)
+		// windows 按 message_timestamp 升序, 后命中的覆盖前面的
+		status.Voided = true
+		status.VoidReason = w.VoidReason
+		status.VoidReasonDescription = w.VoidReasonDescription
+		status.SupersededBy = w.SupersededBy
+	}
+
+	if outcomeID != "" {
+		settlement, err := s.ledger.GetOutcome(eventID, marketID, specifiers, outcomeID)
+		if err != nil {
+			return nil, err
+		}
+		status.Settlement = settlement
+	}
+
+	return status, nil
+}
+
+func (s *BetCancelService) queryWindows(where string, args ...interface{}) ([]BetCancelWindow, error) {
+	rows, err := s.db.Query(`
+		SELECT w.id, w.event_id, COALESCE(w.producer_id, 0), w.sr_market_id, w.specifiers, w.void_reason,
+		       w.start_time, w.end_time, COALESCE(w.superseded_by, ''),
+		       w.message_timestamp, w.rollback_timestamp, w.created_at
+		FROM bet_cancel_windows w
+		`+where+`
+		ORDER BY w.message_timestamp, w.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bet cancel windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := make([]BetCancelWindow, 0)
+	for rows.Next() {
+		var w BetCancelWindow
+		var voidReason sql.NullInt64
+		var startTime, endTime, rollbackTimestamp sql.NullInt64
+		if err := rows.Scan(
+			&w.ID, &w.EventID, &w.ProducerID, &w.SRMarketID, &w.Specifiers, &voidReason,
+			&startTime, &endTime, &w.SupersededBy,
+			&w.MessageTimestamp, &rollbackTimestamp, &w.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan bet cancel window: %w", err)
+		}
+		w.VoidReason = nullIntPtr(voidReason)
+		if w.VoidReason != nil {
+			w.VoidReasonDescription = s.staticData.VoidReasonDescription(*w.VoidReason)
+		}
+		w.StartTime = nullInt64Ptr(startTime)
+		w.EndTime = nullInt64Ptr(endTime)
+		w.RollbackTimestamp = nullInt64Ptr(rollbackTimestamp)
+		w.RolledBack = w.RollbackTimestamp != nil
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	i := v.Int64
+	return &i
+}
@@ -2,61 +2,35 @@ package services
 
 import (
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+
+	"uof-service/uof"
 )
 
 // BetSettlementParser Bet Settlement 消息解析器
 type BetSettlementParser struct {
-	db     *sql.DB
-	logger *log.Logger
-}
-
-// BetSettlementMessage Bet Settlement 消息结构
-type BetSettlementMessage struct {
-	XMLName   xml.Name `xml:"bet_settlement"`
-	EventID   string   `xml:"event_id,attr"`
-	ProductID int      `xml:"product,attr"`
-	Timestamp int64    `xml:"timestamp,attr"`
-	Certainty int      `xml:"certainty,attr"`
-	Outcomes  struct {
-		Markets []SettlementMarket `xml:"market"`
-	} `xml:"outcomes"`
-}
-
-// SettlementMarket 结算市场
-type SettlementMarket struct {
-	ID         string             `xml:"id,attr"`
-	Specifiers string             `xml:"specifiers,attr"`
-	VoidFactor *float64           `xml:"void_factor,attr"` // 可选
-	Outcomes   []SettlementOutcome `xml:"outcome"`
-}
-
-// SettlementOutcome 结算结果
-type SettlementOutcome struct {
-	ID             string   `xml:"id,attr"`
-	Result         int      `xml:"result,attr"` // -1=void, 0=lose, 1=win, 0.5=half-win
-	VoidFactor     *float64 `xml:"void_factor,attr"` // 可选,outcome 级别
-	DeadHeatFactor *float64 `xml:"dead_heat_factor,attr"` // 可选
+	db       *sql.DB
+	ledger   *SettlementLedgerService
+	notifier *LarkNotifier
+	logger   *log.Logger
 }
 
 // NewBetSettlementParser 创建 Bet Settlement 解析器
-func NewBetSettlementParser(db *sql.DB) *BetSettlementParser {
+// notifier 可为 nil, 用于提前结算在确认时结果变化的告警
+func NewBetSettlementParser(db *sql.DB, notifier *LarkNotifier) *BetSettlementParser {
 	return &BetSettlementParser{
-		db:     db,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		db:       db,
+		ledger:   NewSettlementLedgerService(db),
+		notifier: notifier,
+		logger:   log.New(os.Stdout, "", log.LstdFlags),
 	}
 }
 
-// ParseAndStore 解析并存储 Bet Settlement 消息
-func (p *BetSettlementParser) ParseAndStore(xmlContent string) error {
-	var settlement BetSettlementMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &settlement); err != nil {
-		return fmt.Errorf("failed to parse bet_settlement message: %w", err)
-	}
-
+// Store 存储已解码的 Bet Settlement 消息
+func (p *BetSettlementParser) Store(settlement *uof.BetSettlement) error {
 	// 日志在处理完成后输出
 
 	// 开始事务
@@ -68,11 +42,7 @@ func (p *BetSettlementParser) ParseAndStore(xmlContent string) error {
 
 		// 遍历所有市场
 		for _, market := range settlement.Outcomes.Markets {
-			marketID, err := ExtractMarketIDFromURN(market.ID)
-			if err != nil {
-				p.logger.Printf("Warning: failed to extract market ID from URN %s: %v", market.ID, err)
-				continue
-			}
+			marketID := strconv.Itoa(market.ID)
 
 			// 遍历所有结果
 		for _, outcome := range market.Outcomes {
@@ -134,6 +104,12 @@ func (p *BetSettlementParser) ParseAndStore(xmlContent string) error {
 			}
 	}
 
+	// 写入结算账本 (与原始记录同一事务)
+	ledgerEntries, flips, err := p.ledger.RecordSettlement(tx, settlement)
+	if err != nil {
+		return fmt.Errorf("failed to record settlement ledger: %w", err)
+	}
+
 	// 提交事务
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -158,8 +134,16 @@ func (p *BetSettlementParser) ParseAndStore(xmlContent string) error {
 		certaintyText = fmt.Sprintf("certainty=%d", settlement.Certainty)
 	}
 	
-	p.logger.Printf("[bet_settlement] 比赛 %s 的 %d个市场已结算: %d个结果 (%s)",
-		settlement.EventID, len(settlement.Outcomes.Markets), outcomeCount, certaintyText)
+	p.logger.Printf("[bet_settlement] 比赛 %s 的 %d个市场已结算: %d个结果 (%s), 账本新增 %d 条",
+		settlement.EventID, len(settlement.Outcomes.Markets), outcomeCount, certaintyText, ledgerEntries)
+
+	// 提前结算在确认时结果变化: 已按 certainty 1 派彩的投注需要人工处理
+	if len(flips) > 0 {
+		p.logger.Printf("[bet_settlement] ⚠️ 比赛 %s 有 %d 个结果在确认时与提前结算不同", settlement.EventID, len(flips))
+		if p.notifier != nil {
+			go p.notifier.NotifySettlementFlips(flips)
+		}
+	}
 
 	return nil
 }
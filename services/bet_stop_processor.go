@@ -2,11 +2,11 @@ package services
 
 import (
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
 	"os"
 
+	"uof-service/uof"
 )
 
 // BetStopProcessor Bet Stop 消息处理器
@@ -15,16 +15,6 @@ type BetStopProcessor struct {
 	logger *log.Logger
 }
 
-// BetStopMessage Bet Stop 消息结构
-type BetStopMessage struct {
-	XMLName      xml.Name `xml:"bet_stop"`
-	EventID      string   `xml:"event_id,attr"`
-	ProductID    int      `xml:"product,attr"`
-	Timestamp    int64    `xml:"timestamp,attr"`
-	MarketStatus *int     `xml:"market_status,attr"` // 可选
-	Groups       string   `xml:"groups,attr"`        // "all" 或 "1|2|3"
-}
-
 // NewBetStopProcessor 创建 Bet Stop 处理器
 func NewBetStopProcessor(db *sql.DB) *BetStopProcessor {
 	return &BetStopProcessor{
@@ -34,12 +24,7 @@ func NewBetStopProcessor(db *sql.DB) *BetStopProcessor {
 }
 
 // ProcessBetStop 处理 Bet Stop 消息并更新 market status
-func (p *BetStopProcessor) ProcessBetStop(xmlContent string) error {
-	var betStop BetStopMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &betStop); err != nil {
-		return fmt.Errorf("failed to parse bet_stop message: %w", err)
-	}
-
+func (p *BetStopProcessor) ProcessBetStop(betStop *uof.BetStop) error {
 	// 日志在更新后输出
 
 	// 根据 groups 更新 market status
@@ -51,7 +36,7 @@ func (p *BetStopProcessor) ProcessBetStop(xmlContent string) error {
 }
 
 // updateMarketStatus 更新市场状态
-func (p *BetStopProcessor) updateMarketStatus(betStop BetStopMessage) error {
+func (p *BetStopProcessor) updateMarketStatus(betStop *uof.BetStop) error {
 	// 确定要设置的状态值
 	// 根据 Betradar 文档:
 	// - bet_stop 通常表示市场暂停 (suspended)
@@ -64,51 +49,53 @@ func (p *BetStopProcessor) updateMarketStatus(betStop BetStopMessage) error {
 		targetStatus = *betStop.MarketStatus
 	}
 
+	// 记录 bet_stop, 供投注校验判断盘口是否在 bet_stop 之后重新开盘
+	if _, err := p.db.Exec(`
+		INSERT INTO bet_stops (event_id, product_id, timestamp, groups, market_status)
+		VALUES ($1, $2, $3, $4, $5)
+	`, betStop.EventID, betStop.ProductID, betStop.Timestamp, betStop.Groups, fmt.Sprintf("%d", targetStatus)); err != nil {
+		p.logger.Printf("[bet_stop] ⚠️  Failed to record bet_stop for %s: %v", betStop.EventID, err)
+	}
+
 	// 根据 groups 字段更新不同的市场
-	if betStop.Groups == "all" || betStop.Groups == "" {
-		// 更新该赛事的所有市场
-				eventID, err := ExtractEventIDFromURN(betStop.EventID)
-				if err != nil {
-					return fmt.Errorf("failed to extract event ID from URN: %w", err)
-				}
-				query := `
-					UPDATE markets 
-					SET status = $1, updated_at = NOW()
-					WHERE event_id = $2
-				`
-				result, err := p.db.Exec(query, targetStatus, eventID)
-			if err != nil {
-				return fmt.Errorf("failed to update all markets: %w", err)
-				}
-			rowsAffected, _ := result.RowsAffected()
-		p.logger.Printf("[bet_stop] 比赛 %s 的所有市场已暂停 (%d个市场)",
-			betStop.EventID, rowsAffected)
+	// 注意: markets.event_id 存储的是完整 URN (sr:match:xxx / sr:stage:xxx / sr:season:xxx 等)
+	// 非对阵赛事 (outright) 的 bet_stop 同样按 event_id 暂停全部盘口, 不依赖主客队信息
+	// groups 格式: "all" 或 "score|half" (market group), 与 markets.groups (来自 market_descriptions) 有交集的盘口被暂停
+	// 没有 groups 信息的盘口无法判断所属组, 按组暂停时同样暂停
+	query := `
+		UPDATE markets 
+		SET status = $1, updated_at = NOW()
+		WHERE event_id = $2 AND status NOT IN ('-3', '-4') -- 已结算/已取消的盘口不受影响
+	`
+	args := []interface{}{targetStatus, betStop.EventID}
+	if !isAllMarketGroups(betStop.Groups) {
+		query += ` AND (groups IS NULL OR groups = '' OR string_to_array(groups, '|') && string_to_array($3, '|'))`
+		args = append(args, betStop.Groups)
+	}
+	result, err := p.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update markets: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
 
+	if isAllMarketGroups(betStop.Groups) {
+		p.logger.Printf("[bet_stop] 赛事 %s 的所有市场已暂停 (%d个市场)",
+			betStop.EventID, rowsAffected)
 	} else {
-		// 更新特定市场组
-		// groups 格式: "1|2|3" 表示 market group IDs
-		// 注意: 这需要 market_descriptions 中的 group 信息
-		
-		// 简化实现: 如果 groups 不是 "all",暂时也更新所有市场
-		// TODO: 实现基于 market group 的筛选
-				eventID, err := ExtractEventIDFromURN(betStop.EventID)
-				if err != nil {
-					return fmt.Errorf("failed to extract event ID from URN: %w", err)
-				}
-				query := `
-					UPDATE markets 
-					SET status = $1, updated_at = NOW()
-					WHERE event_id = $2
-				`
-				result, err := p.db.Exec(query, targetStatus, eventID)
-			if err != nil {
-				return fmt.Errorf("failed to update markets by groups: %w", err)
-				}
-			rowsAffected, _ := result.RowsAffected()
-		p.logger.Printf("[bet_stop] 比赛 %s 的市场组 %s 已暂停 (%d个市场)",
+		p.logger.Printf("[bet_stop] 赛事 %s 的市场组 %s 已暂停 (%d个市场)",
 			betStop.EventID, betStop.Groups, rowsAffected)
 	}
 
 	return nil
 }
 
+// isAllMarketGroups 判断 bet_stop 的 groups 是否表示全部盘口 (为空或包含 "all")
+func isAllMarketGroups(groups string) bool {
+	list := SplitMarketGroups(groups)
+	for _, group := range list {
+		if group == MarketGroupAll {
+			return true
+		}
+	}
+	return len(list) == 0
+}
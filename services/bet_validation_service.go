@@ -0,0 +1,254 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// 拒绝原因
+const (
+	RejectMarketNotFound   = "market_not_found"
+	RejectMarketNotActive  = "market_not_active"
+	RejectBetStop          = "bet_stop"
+	RejectOutcomeNotFound  = "outcome_not_found"
+	RejectOutcomeInactive  = "outcome_inactive"
+	RejectOddsChanged      = "odds_changed"
+	RejectProducerDown     = "producer_down"
+	RejectProducerMismatch = "producer_mismatch"
+	RejectInvalidSelection = "invalid_selection"
+)
+
+// marketStatusNames 盘口状态名称
+var marketStatusNames = map[int]string{
+	1:  "active",
+	0:  "inactive",
+	-1: "suspended",
+	-2: "handed_over",
+	-3: "settled",
+	-4: "cancelled",
+}
+
+// BetSelection 投注选项
+type BetSelection struct {
+	EventID       string  `json:"event_id"`
+	SRMarketID    string  `json:"sr_market_id"`
+	Specifiers    string  `json:"specifiers"`
+	OutcomeID     string  `json:"outcome_id"`
+	RequestedOdds float64 `json:"odds"`
+	ProducerID    int     `json:"producer_id"` // 可选, 0 表示不校验来源
+}
+
+// SelectionValidation 单个选项的校验结果
+type SelectionValidation struct {
+	Index         int      `json:"index"`
+	EventID       string   `json:"event_id"`
+	SRMarketID    string   `json:"sr_market_id"`
+	Specifiers    string   `json:"specifiers"`
+	OutcomeID     string   `json:"outcome_id"`
+	RequestedOdds float64  `json:"requested_odds"`
+	CurrentOdds   *float64 `json:"current_odds"`
+	MarketStatus  *int     `json:"market_status"`
+	ProducerID    int      `json:"producer_id"`
+	Accepted      bool     `json:"accepted"`
+	Reasons       []string `json:"reasons"`
+	Messages      []string `json:"messages,omitempty"`
+}
+
+// BetSlipValidation 投注单校验结果
+type BetSlipValidation struct {
+	Accepted   bool                  `json:"accepted"`
+	Tolerance  float64               `json:"tolerance"`
+	Selections []SelectionValidation `json:"selections"`
+}
+
+// BetValidationService 投注选项校验服务
+type BetValidationService struct {
+	db              *sql.DB
+	producerMonitor *ProducerMonitor
+	tolerance       float64
+}
+
+// NewBetValidationService 创建投注校验服务
+// tolerance: 允许的赔率下降比例, 例如 0.05 表示当前赔率不低于请求赔率的 95% 即可接受
+func NewBetValidationService(db *sql.DB, producerMonitor *ProducerMonitor, tolerance float64) *BetValidationService {
+	return &BetValidationService{
+		db:              db,
+		producerMonitor: producerMonitor,
+		tolerance:       tolerance,
+	}
+}
+
+// ValidateSlip 校验投注单中的每个选项
+// acceptHigherOdds 为 true 时赔率上升总是接受, 否则赔率上升也需在容差内
+func (s *BetValidationService) ValidateSlip(selections []BetSelection, acceptHigherOdds bool) (*BetSlipValidation, error) {
+	health, err := s.producerHealth()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BetSlipValidation{
+		Accepted:   len(selections) > 0,
+		Tolerance:  s.tolerance,
+		Selections: make([]SelectionValidation, 0, len(selections)),
+	}
+
+	for i, selection := range selections {
+		v, err := s.validateSelection(i, selection, health, acceptHigherOdds)
+		if err != nil {
+			return nil, err
+		}
+		if !v.Accepted {
+			result.Accepted = false
+		}
+		result.Selections = append(result.Selections, *v)
+	}
+
+	return result, nil
+}
+
+// producerHealth 获取 producer 健康状态
+func (s *BetValidationService) producerHealth() (map[int]ProducerStatus, error) {
+	health := make(map[int]ProducerStatus)
+	if s.producerMonitor == nil {
+		return health, nil
+	}
+
+	statuses, err := s.producerMonitor.GetProducerStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get producer status: %w", err)
+	}
+	for _, status := range statuses {
+		health[status.ProducerID] = status
+	}
+	return health, nil
+}
+
+func (v *SelectionValidation) reject(reason, message string) {
+	v.Accepted = false
+	v.Reasons = append(v.Reasons, reason)
+	v.Messages = append(v.Messages, message)
+}
+
+// validateSelection 校验单个选项
+func (s *BetValidationService) validateSelection(index int, sel BetSelection, health map[int]ProducerStatus, acceptHigherOdds bool) (*SelectionValidation, error) {
+	v := &SelectionValidation{
+		Index:         index,
+		EventID:       sel.EventID,
+		SRMarketID:    sel.SRMarketID,
+		Specifiers:    sel.Specifiers,
+		OutcomeID:     sel.OutcomeID,
+		RequestedOdds: sel.RequestedOdds,
+		ProducerID:    sel.ProducerID,
+		Accepted:      true,
+		Reasons:       make([]string, 0),
+	}
+
+	if sel.EventID == "" || sel.SRMarketID == "" || sel.OutcomeID == "" || sel.RequestedOdds <= 0 {
+		v.reject(RejectInvalidSelection, "event_id, sr_market_id, outcome_id and odds are required")
+		return v, nil
+	}
+
+	// 1. 盘口状态
+	var marketPK, producerID int
+	var statusText, marketGroups string
+	err := s.db.QueryRow(`
+		SELECT id, COALESCE(status, '0'), COALESCE(producer_id, 0), COALESCE(groups, '')
+		FROM markets
+		WHERE event_id = $1 AND sr_market_id = $2 AND COALESCE(specifiers, '') = $3
+	`, sel.EventID, sel.SRMarketID, sel.Specifiers).Scan(&marketPK, &statusText, &producerID, &marketGroups)
+	if err == sql.ErrNoRows {
+		v.reject(RejectMarketNotFound, "market not found")
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query market: %w", err)
+	}
+
+	marketStatus, _ := strconv.Atoi(statusText)
+	v.MarketStatus = &marketStatus
+	if marketStatus != 1 {
+		name := marketStatusNames[marketStatus]
+		if name == "" {
+			name = statusText
+		}
+		v.reject(RejectMarketNotActive, fmt.Sprintf("market status is %s", name))
+	}
+
+	// 2. Producer: 来源一致且健康
+	if sel.ProducerID != 0 && producerID != 0 && sel.ProducerID != producerID {
+		v.reject(RejectProducerMismatch, fmt.Sprintf("market is currently priced by producer %d", producerID))
+	}
+	checkProducer := sel.ProducerID
+	if checkProducer == 0 {
+		checkProducer = producerID
+	}
+	// producer 0 表示来源未知 (请求和盘口都没有 producer), 不做健康检查
+	if checkProducer != 0 {
+		if status, ok := health[checkProducer]; !ok {
+			v.reject(RejectProducerDown, fmt.Sprintf("no alive received from producer %d", checkProducer))
+		} else if !status.IsHealthy {
+			v.reject(RejectProducerDown, fmt.Sprintf("producer %d is down (%d seconds since last alive)", checkProducer, status.SecondsSinceLastAlive))
+		}
+	}
+
+	// 3. 结果和赔率
+	var odds float64
+	var active bool
+	var oddsTimestamp int64
+	err = s.db.QueryRow(`
+		SELECT COALESCE(odds_value, 0), COALESCE(active, false), COALESCE(timestamp, 0)
+		FROM odds
+		WHERE market_id = $1 AND outcome_id = $2
+	`, marketPK, sel.OutcomeID).Scan(&odds, &active, &oddsTimestamp)
+	if err == sql.ErrNoRows {
+		v.reject(RejectOutcomeNotFound, "outcome not found")
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query odds: %w", err)
+	}
+
+	v.CurrentOdds = &odds
+	if !active {
+		v.reject(RejectOutcomeInactive, "outcome is not active")
+	}
+
+	if !s.oddsWithinTolerance(sel.RequestedOdds, odds, acceptHigherOdds) {
+		v.reject(RejectOddsChanged, fmt.Sprintf("odds changed from %.2f to %.2f", sel.RequestedOdds, odds))
+	}
+
+	// 4. bet_stop: 最近一次影响该盘口组的 bet_stop 晚于该盘口的最新赔率, 说明还未重新开盘
+	// bet_stop 为 "all" 或盘口没有 groups 信息时按影响全部盘口处理
+	var lastBetStop sql.NullInt64
+	if err := s.db.QueryRow(`
+		SELECT MAX(timestamp) FROM bet_stops
+		WHERE event_id = $1
+			AND (COALESCE(groups, '') = '' OR 'all' = ANY(string_to_array(groups, '|'))
+				OR $2 = '' OR string_to_array(groups, '|') && string_to_array($2, '|'))
+	`, sel.EventID, marketGroups).Scan(&lastBetStop); err != nil {
+		return nil, fmt.Errorf("failed to query bet_stops: %w", err)
+	}
+	if lastBetStop.Valid && lastBetStop.Int64 > oddsTimestamp {
+		v.reject(RejectBetStop, "bet_stop received after the last odds update")
+	}
+
+	return v, nil
+}
+
+// oddsWithinTolerance 判断当前赔率相对请求赔率的变化是否在容差内
+func (s *BetValidationService) oddsWithinTolerance(requested, current float64, acceptHigherOdds bool) bool {
+	if current <= 0 {
+		return false
+	}
+	if current >= requested && acceptHigherOdds {
+		return true
+	}
+
+	change := (current - requested) / requested
+	if change < 0 {
+		change = -change
+	}
+	// 容差比较留一点浮点余量
+	return change <= s.tolerance+1e-9
+}
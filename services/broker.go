@@ -2,6 +2,8 @@ package services
 
 import (
 	"fmt"
+
+	"uof-service/uof"
 )
 
 // BrokerMessage 定义了在 Broker 中传输的消息结构
@@ -9,6 +11,9 @@ type BrokerMessage struct {
 	Topic string
 	Key   string // 可以是 EventID 或其他唯一标识
 	Value []byte // 原始 XML 消息体
+	// Message 接收时解码的类型化消息, 处理器直接使用而不必重新解析 Value
+	// 为 nil 时 (例如来自其它生产者) 由消费方自行解码
+	Message *uof.Message
 }
 
 // MessageBroker 定义了消息队列的抽象接口
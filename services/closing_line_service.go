@@ -0,0 +1,164 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 收盘赔率快照的触发原因
+const (
+	ClosingTriggerProducerSwitch = "producer_switch" // 赛事首次收到 live producer (1) 的 odds_change
+	ClosingTriggerEventLive      = "event_live"      // sport_event_status 变为 live
+	ClosingTriggerHandedOver     = "handed_over"     // 盘口状态 -2 (由 prematch 移交给 live)
+)
+
+// ClosingLine 收盘赔率 (开赛时最后的 prematch 赔率)
+type ClosingLine struct {
+	EventID       string    `json:"event_id"`
+	SRMarketID    string    `json:"sr_market_id"`
+	Specifiers    string    `json:"specifiers"`
+	OutcomeID     string    `json:"outcome_id"`
+	OutcomeName   string    `json:"outcome_name"`
+	OddsValue     float64   `json:"odds_value"`
+	RawOddsValue  float64   `json:"raw_odds_value,omitempty"` // 原始赔率只返回给 trader / admin
+	Active        bool      `json:"active"`
+	ProducerID    int       `json:"producer_id"`
+	OddsTimestamp int64     `json:"odds_timestamp"` // 该赔率对应的 odds_change 时间戳
+	Trigger       string    `json:"trigger"`
+	CapturedAt    time.Time `json:"captured_at"`
+}
+
+// ClosingLineService 收盘赔率快照服务
+// 每个结果只保存一次 (第一次触发时的 prematch 赔率)
+type ClosingLineService struct {
+	db       *sql.DB
+	captured map[string]time.Time // 已做过整场快照的赛事
+	mu       sync.Mutex
+}
+
+// NewClosingLineService 创建收盘赔率服务
+func NewClosingLineService(db *sql.DB) *ClosingLineService {
+	return &ClosingLineService{
+		db:       db,
+		captured: make(map[string]time.Time),
+	}
+}
+
+// closingLineInsert 从当前 prematch (producer 3) 赔率复制收盘赔率, 已存在的结果不覆盖
+const closingLineInsert = `
+	INSERT INTO closing_lines (event_id, market_id, sr_market_id, specifiers, outcome_id, outcome_name,
+	                           odds_value, raw_odds_value, active, producer_id, odds_timestamp, trigger)
+	SELECT m.event_id, m.id, m.sr_market_id, COALESCE(m.specifiers, ''), o.outcome_id, o.outcome_name,
+	       o.odds_value, COALESCE(o.raw_odds_value, o.odds_value), o.active, m.producer_id, o.timestamp, $2
+	FROM markets m
+	JOIN odds o ON o.market_id = m.id
+	WHERE m.event_id = $1 AND m.producer_id = 3`
+
+// CaptureFromOddsChange 在 odds_change 入库前检查触发条件并保存收盘赔率
+// 必须在更新 markets / odds 之前调用, 此时表中仍是最后的 prematch 赔率
+// 执行了整场快照时返回 true, 调用方在事务提交成功后调用 MarkCaptured (回滚时下一条消息会重新快照)
+func (s *ClosingLineService) CaptureFromOddsChange(tx *sql.Tx, oddsChange *uof.OddsChange, productID int) (bool, error) {
+	// 1. 整场快照: live producer 接管或赛事进入 live
+	trigger := ""
+	if productID == 1 {
+		trigger = ClosingTriggerProducerSwitch
+	} else if oddsChange.SportEventStatus != nil && oddsChange.SportEventStatus.Status == "1" {
+		trigger = ClosingTriggerEventLive
+	}
+	captured := false
+	if trigger != "" && !s.isCaptured(oddsChange.EventID) {
+		if _, err := tx.Exec(closingLineInsert+`
+			ON CONFLICT (event_id, sr_market_id, specifiers, outcome_id) DO NOTHING
+		`, oddsChange.EventID, trigger); err != nil {
+			return false, fmt.Errorf("failed to capture closing lines: %w", err)
+		}
+		captured = true
+	}
+
+	// 2. 单个盘口快照: 盘口被移交 (-2)
+	for _, market := range oddsChange.Odds.Markets {
+		if market.Status != uof.MarketStatusHandedOver {
+			continue
+		}
+		if _, err := tx.Exec(closingLineInsert+`
+			  AND m.sr_market_id = $3 AND COALESCE(m.specifiers, '') = $4
+			ON CONFLICT (event_id, sr_market_id, specifiers, outcome_id) DO NOTHING
+		`, oddsChange.EventID, ClosingTriggerHandedOver, strconv.Itoa(market.ID), market.Specifiers); err != nil {
+			return false, fmt.Errorf("failed to capture closing line for market %d: %w", market.ID, err)
+		}
+	}
+	return captured, nil
+}
+
+// isCaptured 赛事的整场快照是否已提交
+func (s *ClosingLineService) isCaptured(eventID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.captured[eventID]
+	return ok
+}
+
+// MarkCaptured 标记赛事的整场快照已提交, 之后的 live 消息不再执行快照
+// 只用于避免每条 live 消息都执行一次快照 SQL, 重启后重复执行也不会覆盖已有数据
+func (s *ClosingLineService) MarkCaptured(eventID string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.captured[eventID] = now
+
+	// 清理一天前的标记
+	if len(s.captured) > 10000 {
+		for id, at := range s.captured {
+			if now.Sub(at) > 24*time.Hour {
+				delete(s.captured, id)
+			}
+		}
+	}
+}
+
+// GetClosingLines 获取赛事的收盘赔率, marketID / specifiers 为空时不过滤
+func (s *ClosingLineService) GetClosingLines(eventID, marketID, specifiers string) ([]ClosingLine, error) {
+	query := `
+		SELECT event_id, sr_market_id, specifiers, outcome_id, COALESCE(outcome_name, ''),
+		       COALESCE(odds_value, 0), COALESCE(raw_odds_value, odds_value, 0), COALESCE(active, false),
+		       COALESCE(producer_id, 0), COALESCE(odds_timestamp, 0), trigger, captured_at
+		FROM closing_lines
+		WHERE event_id = $1
+	`
+	args := []interface{}{eventID}
+	if marketID != "" {
+		args = append(args, NormalizeMarketID(marketID))
+		query += fmt.Sprintf(" AND sr_market_id = $%d", len(args))
+	}
+	if specifiers != "" {
+		args = append(args, specifiers)
+		query += fmt.Sprintf(" AND specifiers = $%d", len(args))
+	}
+	query += " ORDER BY sr_market_id, specifiers, outcome_id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closing lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make([]ClosingLine, 0)
+	for rows.Next() {
+		var line ClosingLine
+		if err := rows.Scan(&line.EventID, &line.SRMarketID, &line.Specifiers, &line.OutcomeID, &line.OutcomeName,
+			&line.OddsValue, &line.RawOddsValue, &line.Active, &line.ProducerID, &line.OddsTimestamp,
+			&line.Trigger, &line.CapturedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan closing line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
@@ -9,8 +9,9 @@ import (
 	"net/http"
 	"strings"
 	"time"
-	
+
 	"uof-service/config"
+	"uof-service/uof"
 )
 
 // ColdStart 冷启动服务
@@ -35,6 +36,7 @@ type ColdStartEvent struct {
 	StartTime   string       `xml:"start_time,attr"`
 	LiveOdds    string       `xml:"liveodds,attr"`
 	Sport       SportData    `xml:"sport"`
+	Tournament  uof.Tournament `xml:"tournament"`
 	Competitors []ColdStartCompetitor `xml:"competitors>competitor"`
 }
 
@@ -55,11 +57,14 @@ type ColdStartCompetitor struct {
 type MatchInfo struct {
 	EventID       string
 	SportID       string
+	TournamentID  string
+	TournamentName string
 	ScheduleTime  *time.Time
 	HomeTeamID    string
 	HomeTeamName  string
 	AwayTeamID    string
 	AwayTeamName  string
+	Competitors   []uof.Competitor // 完整参赛方列表 (非对阵赛事没有主客队)
 }
 
 // ValidationReport 验证报告
@@ -189,11 +194,13 @@ func (c *ColdStart) parseEvent(event ColdStartEvent) MatchInfo {
 	match := MatchInfo{
 		EventID: event.ID,
 		SportID: event.Sport.ID,
+		TournamentID:   event.Tournament.ID,
+		TournamentName: event.Tournament.Name,
 	}
 	
 	// 如果 sport_id 为空，从 event_id 推断
 	if match.SportID == "" {
-		match.SportID = c.inferSportID(event.ID)
+		match.SportID = c.inferSportID(event)
 	}
 	
 	// 解析时间
@@ -207,8 +214,9 @@ func (c *ColdStart) parseEvent(event ColdStartEvent) MatchInfo {
 		}
 	}
 	
-	// 解析球队
+	// 解析球队 (非对阵赛事只有参赛方列表)
 	for _, comp := range event.Competitors {
+		match.Competitors = append(match.Competitors, uof.Competitor{ID: comp.ID, Name: comp.Name, Qualifier: comp.Qualifier})
 		if comp.Qualifier == "home" {
 			match.HomeTeamID = comp.ID
 			match.HomeTeamName = comp.Name
@@ -239,7 +247,7 @@ func (c *ColdStart) deduplicate(matches []MatchInfo) []MatchInfo {
 // storeMatches 存储比赛
 func (c *ColdStart) storeMatches(matches []MatchInfo) int {
 	failed := 0
-query := `INSERT INTO tracked_events (event_id, sport_id, schedule_time, home_team_id, home_team_name, away_team_id, away_team_name, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, 'scheduled', $8, $9) ON CONFLICT (event_id) DO UPDATE SET sport_id = CASE WHEN EXCLUDED.sport_id = '' THEN tracked_events.sport_id ELSE EXCLUDED.sport_id END, schedule_time = EXCLUDED.schedule_time, home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, updated_at = EXCLUDED.updated_at`
+query := `INSERT INTO tracked_events (event_id, sport_id, schedule_time, home_team_id, home_team_name, away_team_id, away_team_name, status, created_at, updated_at, tournament_id, tournament_name) VALUES ($1, $2, $3, $4, $5, $6, $7, 'scheduled', $8, $9, $10, $11) ON CONFLICT (event_id) DO UPDATE SET sport_id = CASE WHEN EXCLUDED.sport_id = '' THEN tracked_events.sport_id ELSE EXCLUDED.sport_id END, tournament_id = COALESCE(NULLIF(EXCLUDED.tournament_id, ''), tracked_events.tournament_id), tournament_name = COALESCE(NULLIF(EXCLUDED.tournament_name, ''), tracked_events.tournament_name), schedule_time = EXCLUDED.schedule_time, home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, updated_at = EXCLUDED.updated_at`
 	
 	stored := 0
 	for _, match := range matches {
@@ -254,6 +262,8 @@ query := `INSERT INTO tracked_events (event_id, sport_id, schedule_time, home_te
 				match.AwayTeamName,
 				time.Now(),
 				time.Now(),
+				match.TournamentID,
+				match.TournamentName,
 			)
 		
 		if err != nil {
@@ -261,6 +271,9 @@ query := `INSERT INTO tracked_events (event_id, sport_id, schedule_time, home_te
 			failed++
 			continue
 		}
+		if err := SaveEventCompetitors(c.db, match.EventID, match.Competitors); err != nil {
+			c.logger.Printf("⚠️  Failed to store competitors for %s: %v", match.EventID, err)
+		}
 		stored++
 	}
 	
@@ -301,16 +314,19 @@ func (c *ColdStart) validateData(matches []MatchInfo) ValidationReport {
 			complete = false
 		}
 		
-		if match.HomeTeamID == "" || match.HomeTeamName == "" {
-			report.MissingHomeTeam++
-			missing = append(missing, "home_team")
-			complete = false
-		}
-		
-		if match.AwayTeamID == "" || match.AwayTeamName == "" {
-			report.MissingAwayTeam++
-			missing = append(missing, "away_team")
-			complete = false
+		// 只有对阵比赛需要主客队, 非对阵赛事 (stage / season 等) 是参赛方列表
+		if uof.EventType(match.EventID) == uof.EventTypeMatch {
+			if match.HomeTeamID == "" || match.HomeTeamName == "" {
+				report.MissingHomeTeam++
+				missing = append(missing, "home_team")
+				complete = false
+			}
+			
+			if match.AwayTeamID == "" || match.AwayTeamName == "" {
+				report.MissingAwayTeam++
+				missing = append(missing, "away_team")
+				complete = false
+			}
 		}
 		
 		if complete {
@@ -327,27 +343,18 @@ func (c *ColdStart) validateData(matches []MatchInfo) ValidationReport {
 	return report
 }
 
-// inferSportID 从 event_id推断sport_id
-func (c *ColdStart) inferSportID(eventID string) string {
-	// 根据 event_id 的格式推断 sport_id
-	// sr:match:xxx -> 足球 (sr:sport:1)
-	// sr:stage:xxx -> 足球 (sr:sport:1)
-	// sr:simple_tournament:xxx -> 网球等其他运动
-	
-	if strings.HasPrefix(eventID, "sr:match:") {
-		// 大多数 match 是足球
-		return "sr:sport:1"
-	} else if strings.HasPrefix(eventID, "sr:stage:") {
-		// stage 通常也是足球
-		return "sr:sport:1"
-	} else if strings.HasPrefix(eventID, "sr:simple_tournament:") {
-		// 网球等其他运动
-		// 默认也设为足球，需要时可以调整
+// inferSportID 推断 sport_id
+// schedule 中的赛事没有 sport 时, 使用 tournament 所属的运动;
+// 仍然未知时, 对阵比赛沿用足球 (sr:sport:1), 非对阵赛事 (stage / season / simple_tournament) 留空,
+// 等 fixture 消息补全, 避免把赛车 / 高尔夫等 outright 错标为足球
+func (c *ColdStart) inferSportID(event ColdStartEvent) string {
+	if event.Tournament.Sport.ID != "" {
+		return event.Tournament.Sport.ID
+	}
+	if uof.EventType(event.ID) == uof.EventTypeMatch {
 		return "sr:sport:1"
 	}
-	
-	// 默认返回足球
-	return "sr:sport:1"
+	return ""
 }
 
 // printReport 打印报告
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"uof-service/logger"
+	"uof-service/uof"
+)
+
+// 参赛方资料后台刷新: 每轮最多刷新的过期资料数和检查间隔
+const (
+	competitorRefreshBatch    = 200
+	competitorRefreshInterval = time.Hour
+	competitorRetryDelay      = 30 * time.Minute
+)
+
+// CompetitorVenue 参赛方主场
+type CompetitorVenue struct {
+	ID             string `xml:"id,attr" json:"id"`
+	Name           string `xml:"name,attr" json:"name"`
+	Capacity       int    `xml:"capacity,attr" json:"capacity,omitempty"`
+	CityName       string `xml:"city_name,attr" json:"city_name,omitempty"`
+	CountryName    string `xml:"country_name,attr" json:"country_name,omitempty"`
+	CountryCode    string `xml:"country_code,attr" json:"country_code,omitempty"`
+	MapCoordinates string `xml:"map_coordinates,attr" json:"map_coordinates,omitempty"`
+}
+
+// CompetitorJersey 参赛方球衣 (home / away / third / goalkeeper)
+type CompetitorJersey struct {
+	Type              string `xml:"type,attr" json:"type"`
+	Base              string `xml:"base,attr" json:"base,omitempty"`
+	Sleeve            string `xml:"sleeve,attr" json:"sleeve,omitempty"`
+	Number            string `xml:"number,attr" json:"number,omitempty"`
+	Stripes           bool   `xml:"stripes,attr" json:"stripes,omitempty"`
+	HorizontalStripes bool   `xml:"horizontal_stripes,attr" json:"horizontal_stripes,omitempty"`
+	Squares           bool   `xml:"squares,attr" json:"squares,omitempty"`
+	Split             bool   `xml:"split,attr" json:"split,omitempty"`
+}
+
+// CompetitorManager 参赛方主教练
+type CompetitorManager struct {
+	ID          string `xml:"id,attr" json:"id"`
+	Name        string `xml:"name,attr" json:"name"`
+	Nationality string `xml:"nationality,attr" json:"nationality,omitempty"`
+	CountryCode string `xml:"country_code,attr" json:"country_code,omitempty"`
+}
+
+// CompetitorPlayer 参赛方阵容中的球员 (基本信息保存在 players 表)
+type CompetitorPlayer struct {
+	ID           string `xml:"id,attr" json:"id"`
+	Name         string `xml:"name,attr" json:"name"`
+	Type         string `xml:"type,attr" json:"type,omitempty"`
+	Nationality  string `xml:"nationality,attr" json:"nationality,omitempty"`
+	DateOfBirth  string `xml:"date_of_birth,attr" json:"date_of_birth,omitempty"`
+	JerseyNumber int    `xml:"jersey_number,attr" json:"jersey_number,omitempty"`
+}
+
+// CompetitorProfile 参赛方资料 (Sports API competitors/{id}/profile.xml)
+type CompetitorProfile struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Abbreviation string             `json:"abbreviation,omitempty"`
+	Country      string             `json:"country,omitempty"`
+	CountryCode  string             `json:"country_code,omitempty"`
+	Gender       string             `json:"gender,omitempty"`
+	AgeGroup     string             `json:"age_group,omitempty"`
+	SportID      string             `json:"sport_id,omitempty"`
+	Venue        *CompetitorVenue   `json:"venue,omitempty"`
+	Jerseys      []CompetitorJersey `json:"jerseys,omitempty"`
+	Manager      *CompetitorManager `json:"manager,omitempty"`
+	Players      []CompetitorPlayer `json:"players"`
+	FetchedAt    time.Time          `json:"fetched_at"`
+}
+
+// CompetitorSummary 赛事列表使用的参赛方简要信息
+type CompetitorSummary struct {
+	Abbreviation string
+	CountryCode  string
+}
+
+// competitorProfileResponse API 响应
+type competitorProfileResponse struct {
+	XMLName    xml.Name `xml:"competitor_profile"`
+	Competitor struct {
+		ID           string `xml:"id,attr"`
+		Name         string `xml:"name,attr"`
+		Abbreviation string `xml:"abbreviation,attr"`
+		Country      string `xml:"country,attr"`
+		CountryCode  string `xml:"country_code,attr"`
+		Gender       string `xml:"gender,attr"`
+		AgeGroup     string `xml:"age_group,attr"`
+		Sport        struct {
+			ID string `xml:"id,attr"`
+		} `xml:"sport"`
+	} `xml:"competitor"`
+	Venue   *CompetitorVenue   `xml:"venue"`
+	Jerseys []CompetitorJersey `xml:"jerseys>jersey"`
+	Manager *CompetitorManager `xml:"manager"`
+	Players []CompetitorPlayer `xml:"players>player"`
+}
+
+// CompetitorService 参赛方资料服务
+// 资料缓存在 competitors 表, 超过 TTL 后重新请求; 阵容写入 players 并通过 competitor_players 关联
+type CompetitorService struct {
+	db             *sql.DB
+	apiBaseURL     string
+	token          string
+	ttl            time.Duration
+	client         *http.Client
+	playersService *PlayersService              // 阵容球员写入后更新球员缓存 (可选)
+	summaries      map[string]CompetitorSummary // competitor_id -> 简称 / 国家代码
+	pending        map[string]time.Time         // 后台请求中的参赛方 (失败后 competitorRetryDelay 内不再请求)
+	queue          chan string
+	mu             sync.RWMutex
+}
+
+// NewCompetitorService 创建参赛方资料服务
+func NewCompetitorService(token string, apiBaseURL string, db *sql.DB, ttl time.Duration) *CompetitorService {
+	return &CompetitorService{
+		db:         db,
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/v1"),
+		token:      token,
+		ttl:        ttl,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		summaries:  make(map[string]CompetitorSummary),
+		pending:    make(map[string]time.Time),
+		queue:      make(chan string, 1000),
+	}
+}
+
+// SetPlayersService 设置球员服务 (可选)
+func (s *CompetitorService) SetPlayersService(playersService *PlayersService) {
+	s.playersService = playersService
+}
+
+// Start 启动服务: 加载简要信息缓存, 启动后台请求和 TTL 刷新
+func (s *CompetitorService) Start() error {
+	logger.Println("[CompetitorService] Starting competitor service...")
+
+	if err := s.loadSummaries(); err != nil {
+		logger.Printf("[CompetitorService] ⚠️  Failed to load from database: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		go s.worker()
+	}
+	go s.refreshLoop()
+
+	s.mu.RLock()
+	count := len(s.summaries)
+	s.mu.RUnlock()
+	logger.Printf("[CompetitorService] ✅ Competitor service started (%d competitors cached, ttl: %s)", count, s.ttl)
+	return nil
+}
+
+// loadSummaries 从数据库加载所有参赛方的简称和国家代码
+func (s *CompetitorService) loadSummaries() error {
+	rows, err := s.db.Query(`
+		SELECT competitor_id, COALESCE(abbreviation, ''), COALESCE(country_code, '')
+		FROM competitors
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query competitors: %w", err)
+	}
+	defer rows.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for rows.Next() {
+		var id string
+		var summary CompetitorSummary
+		if err := rows.Scan(&id, &summary.Abbreviation, &summary.CountryCode); err != nil {
+			continue
+		}
+		s.summaries[id] = summary
+	}
+	return rows.Err()
+}
+
+// Summary 返回参赛方的简称和国家代码, 未缓存时在后台请求资料并返回 false
+func (s *CompetitorService) Summary(competitorID string) (CompetitorSummary, bool) {
+	if s == nil || competitorID == "" {
+		return CompetitorSummary{}, false
+	}
+
+	s.mu.RLock()
+	summary, ok := s.summaries[competitorID]
+	s.mu.RUnlock()
+	if !ok {
+		s.enqueue(competitorID)
+	}
+	return summary, ok
+}
+
+// ParseCompetitorID 校验参赛方 ID (sr:competitor:123) 并返回规范格式
+func ParseCompetitorID(competitorID string) (string, error) {
+	urn, err := uof.ParseURN(competitorID)
+	if err != nil {
+		return "", err
+	}
+	if urn.Type != "competitor" {
+		return "", fmt.Errorf("not a competitor URN: %s", competitorID)
+	}
+	return urn.String(), nil
+}
+
+// CachedProfile 只从数据库返回参赛方资料 (可能已过期), 不同步请求 API
+// 资料缺失、过期或 refresh=true 时加入后台请求队列 (经过 enqueue 限流), queued 为 true
+func (s *CompetitorService) CachedProfile(competitorID string, refresh bool) (profile *CompetitorProfile, queued bool, err error) {
+	profile, err = s.loadProfile(competitorID)
+	if err != nil {
+		return nil, false, err
+	}
+	if profile == nil || refresh || time.Since(profile.FetchedAt) >= s.ttl {
+		s.enqueue(competitorID)
+		queued = true
+	}
+	return profile, queued, nil
+}
+
+// GetProfile 返回参赛方资料: 缓存未过期时直接返回, 否则从 API 刷新 (refresh=true 时强制刷新)
+// API 请求失败但有缓存时返回旧资料; 参赛方不存在时返回 nil
+func (s *CompetitorService) GetProfile(competitorID string, refresh bool) (*CompetitorProfile, error) {
+	cached, err := s.loadProfile(competitorID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && !refresh && time.Since(cached.FetchedAt) < s.ttl {
+		return cached, nil
+	}
+
+	profile, err := s.fetchProfile(competitorID)
+	if err != nil {
+		if cached != nil {
+			logger.Printf("[CompetitorService] ⚠️  Failed to refresh %s, using cached profile: %v", competitorID, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if profile == nil {
+		return cached, nil
+	}
+	return profile, nil
+}
+
+// loadProfile 从数据库读取参赛方资料和阵容, 不存在时返回 nil
+func (s *CompetitorService) loadProfile(competitorID string) (*CompetitorProfile, error) {
+	profile := &CompetitorProfile{ID: competitorID, Players: make([]CompetitorPlayer, 0)}
+	var abbreviation, country, countryCode, gender, ageGroup, sportID sql.NullString
+	var venue, jerseys, manager sql.NullString
+
+	err := s.db.QueryRow(`
+		SELECT name, abbreviation, country, country_code, gender, age_group, sport_id,
+			venue, jerseys, manager, fetched_at
+		FROM competitors
+		WHERE competitor_id = $1
+	`, competitorID).Scan(&profile.Name, &abbreviation, &country, &countryCode, &gender, &ageGroup, &sportID,
+		&venue, &jerseys, &manager, &profile.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competitor: %w", err)
+	}
+
+	profile.Abbreviation = abbreviation.String
+	profile.Country = country.String
+	profile.CountryCode = countryCode.String
+	profile.Gender = gender.String
+	profile.AgeGroup = ageGroup.String
+	profile.SportID = sportID.String
+	if venue.Valid {
+		json.Unmarshal([]byte(venue.String), &profile.Venue)
+	}
+	if jerseys.Valid {
+		json.Unmarshal([]byte(jerseys.String), &profile.Jerseys)
+	}
+	if manager.Valid {
+		json.Unmarshal([]byte(manager.String), &profile.Manager)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT cp.player_id, COALESCE(p.player_name, ''), COALESCE(cp.player_type, ''),
+			COALESCE(p.nationality, ''), COALESCE(TO_CHAR(p.date_of_birth, 'YYYY-MM-DD'), ''),
+			COALESCE(cp.jersey_number, 0)
+		FROM competitor_players cp
+		LEFT JOIN players p ON p.player_id = cp.player_id
+		WHERE cp.competitor_id = $1
+		ORDER BY cp.jersey_number NULLS LAST, p.player_name
+	`, competitorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competitor players: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var player CompetitorPlayer
+		if err := rows.Scan(&player.ID, &player.Name, &player.Type, &player.Nationality, &player.DateOfBirth, &player.JerseyNumber); err != nil {
+			continue
+		}
+		profile.Players = append(profile.Players, player)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating competitor players: %w", err)
+	}
+
+	return profile, nil
+}
+
+// fetchProfile 从 API 请求参赛方资料并保存, 参赛方不存在 (404) 时返回 nil
+func (s *CompetitorService) fetchProfile(competitorID string) (*CompetitorProfile, error) {
+	// 只请求合法的参赛方 URN, 不把原始参数拼进 URL
+	id, err := ParseCompetitorID(competitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构造 URL: /v1/sports/en/competitors/{competitor_id}/profile.xml
+	profileURL := fmt.Sprintf("%s/v1/sports/en/competitors/%s/profile.xml", s.apiBaseURL, url.PathEscape(id))
+
+	req, err := http.NewRequest("GET", profileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-access-token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch competitor profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response competitorProfileResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+	if response.Competitor.ID == "" {
+		return nil, fmt.Errorf("no competitor in response")
+	}
+
+	profile := &CompetitorProfile{
+		ID:           response.Competitor.ID,
+		Name:         response.Competitor.Name,
+		Abbreviation: response.Competitor.Abbreviation,
+		Country:      response.Competitor.Country,
+		CountryCode:  response.Competitor.CountryCode,
+		Gender:       response.Competitor.Gender,
+		AgeGroup:     response.Competitor.AgeGroup,
+		SportID:      response.Competitor.Sport.ID,
+		Venue:        response.Venue,
+		Jerseys:      response.Jerseys,
+		Manager:      response.Manager,
+		Players:      response.Players,
+		FetchedAt:    time.Now(),
+	}
+	if profile.Players == nil {
+		profile.Players = make([]CompetitorPlayer, 0)
+	}
+
+	if err := s.saveProfile(profile); err != nil {
+		return nil, err
+	}
+
+	logger.Printf("[CompetitorService] ✅ Loaded competitor %s: %s (%d players)", profile.ID, profile.Name, len(profile.Players))
+	return profile, nil
+}
+
+// saveProfile 保存参赛方资料, 替换阵容并把阵容球员写入 players
+func (s *CompetitorService) saveProfile(profile *CompetitorProfile) error {
+	venue, err := nullableJSON(profile.Venue)
+	if err != nil {
+		return err
+	}
+	jerseys, err := nullableJSON(profile.Jerseys)
+	if err != nil {
+		return err
+	}
+	manager, err := nullableJSON(profile.Manager)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO competitors (competitor_id, name, abbreviation, country, country_code, gender, age_group, sport_id,
+			venue, jerseys, manager, fetched_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+			$9, $10, $11, $12, NOW())
+		ON CONFLICT (competitor_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			abbreviation = EXCLUDED.abbreviation,
+			country = EXCLUDED.country,
+			country_code = EXCLUDED.country_code,
+			gender = EXCLUDED.gender,
+			age_group = EXCLUDED.age_group,
+			sport_id = EXCLUDED.sport_id,
+			venue = EXCLUDED.venue,
+			jerseys = EXCLUDED.jerseys,
+			manager = EXCLUDED.manager,
+			fetched_at = EXCLUDED.fetched_at,
+			updated_at = NOW()
+	`, profile.ID, profile.Name, profile.Abbreviation, profile.Country, profile.CountryCode, profile.Gender,
+		profile.AgeGroup, profile.SportID, venue, jerseys, manager, profile.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert competitor: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM competitor_players WHERE competitor_id = $1", profile.ID); err != nil {
+		return fmt.Errorf("failed to clear competitor players: %w", err)
+	}
+
+	names := make(map[string]string, len(profile.Players))
+	for _, player := range profile.Players {
+		var dateOfBirth *time.Time
+		if t, err := time.Parse("2006-01-02", player.DateOfBirth); err == nil {
+			dateOfBirth = &t
+		}
+		_, err := tx.Exec(`
+			INSERT INTO players (player_id, player_name, nationality, date_of_birth, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (player_id) DO UPDATE
+			SET player_name = EXCLUDED.player_name,
+			    nationality = COALESCE(NULLIF(EXCLUDED.nationality, ''), players.nationality),
+			    date_of_birth = COALESCE(EXCLUDED.date_of_birth, players.date_of_birth),
+			    updated_at = NOW()
+		`, player.ID, player.Name, player.Nationality, dateOfBirth)
+		if err != nil {
+			return fmt.Errorf("failed to insert player %s: %w", player.ID, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO competitor_players (competitor_id, player_id, player_type, jersey_number, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NOW())
+			ON CONFLICT (competitor_id, player_id) DO NOTHING
+		`, profile.ID, player.ID, player.Type, player.JerseyNumber)
+		if err != nil {
+			return fmt.Errorf("failed to link player %s: %w", player.ID, err)
+		}
+		names[player.ID] = player.Name
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit competitor: %w", err)
+	}
+
+	s.mu.Lock()
+	s.summaries[profile.ID] = CompetitorSummary{Abbreviation: profile.Abbreviation, CountryCode: profile.CountryCode}
+	delete(s.pending, profile.ID)
+	s.mu.Unlock()
+
+	s.playersService.cachePlayerNames(names)
+	return nil
+}
+
+// nullableJSON 序列化可选的 JSONB 字段, nil / 空列表保存为 NULL
+func nullableJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %T: %w", v, err)
+	}
+	if string(data) == "null" || string(data) == "[]" {
+		return nil, nil
+	}
+	return string(data), nil
+}
+
+// enqueue 后台请求参赛方资料 (同一参赛方在 competitorRetryDelay 内只请求一次)
+func (s *CompetitorService) enqueue(competitorID string) {
+	if !strings.Contains(competitorID, ":competitor:") {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	if last, ok := s.pending[competitorID]; ok && now.Sub(last) < competitorRetryDelay {
+		s.mu.Unlock()
+		return
+	}
+	s.pending[competitorID] = now
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- competitorID:
+	default:
+		// 队列已满, 下次访问时重试
+		s.mu.Lock()
+		delete(s.pending, competitorID)
+		s.mu.Unlock()
+	}
+}
+
+// worker 处理后台请求
+func (s *CompetitorService) worker() {
+	for competitorID := range s.queue {
+		if _, err := s.fetchProfile(competitorID); err != nil {
+			logger.Printf("[CompetitorService] ⚠️  Failed to load competitor %s: %v", competitorID, err)
+		}
+	}
+}
+
+// refreshLoop 定期刷新超过 TTL 的参赛方资料
+func (s *CompetitorService) refreshLoop() {
+	ticker := time.NewTicker(competitorRefreshInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.refreshStale(); err != nil {
+			logger.Printf("[CompetitorService] ⚠️  Failed to refresh competitors: %v", err)
+		}
+	}
+}
+
+// refreshStale 把最早过期的一批参赛方加入后台请求队列
+func (s *CompetitorService) refreshStale() error {
+	rows, err := s.db.Query(`
+		SELECT competitor_id
+		FROM competitors
+		WHERE fetched_at < $1
+		ORDER BY fetched_at
+		LIMIT $2
+	`, time.Now().Add(-s.ttl), competitorRefreshBatch)
+	if err != nil {
+		return fmt.Errorf("failed to query stale competitors: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var competitorID string
+		if err := rows.Scan(&competitorID); err != nil {
+			continue
+		}
+		s.enqueue(competitorID)
+		count++
+	}
+	if count > 0 {
+		logger.Printf("[CompetitorService] Refreshing %d stale competitor profiles", count)
+	}
+	return rows.Err()
+}
+
+// GetStatus 获取服务状态
+func (s *CompetitorService) GetStatus() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return map[string]interface{}{
+		"competitor_count": len(s.summaries),
+		"pending_count":    len(s.pending),
+		"ttl_hours":        s.ttl.Hours(),
+	}
+}
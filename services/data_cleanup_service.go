@@ -19,6 +19,9 @@ type CleanupConfig struct {
 	RetainDaysBets      int // bet_stops, bet_settlements 保留天数
 	RetainDaysLiveData  int // ld_events, ld_lineups 保留天数
 	RetainDaysEvents    int // tracked_events, ld_matches 保留天数
+	RetainDaysAudit     int // audit_log 保留天数
+	RetainDaysCandles   int // odds_candles 保留天数
+	RetainDaysClosingLines int // closing_lines 保留天数
 }
 
 // CleanupResult 清理结果
@@ -44,11 +47,17 @@ func NewDataCleanupService(db *sql.DB, config CleanupConfig) *DataCleanupService
 // - bet_stops: 保留 7 天
 // - bet_settlements: 保留 7 天
 // - odds_history: 保留 7 天
+// - market_margin_history: 保留 7 天（盘口返还率历史）
+// - odds_alerts: 保留 7 天（赔率异动告警）
+// - odds_candles: 保留 30 天（赔率 K 线, 比原始赔率历史保留更久）
 // - tracked_events: 保留 30 天（赛事信息，需要更长时间）
 // - markets: 保留 7 天（盘口数据）
 // - odds: 保留 7 天（赔率详情）
 // - ld_events: 保留 3 天（Live Data 事件）
 // - ld_matches: 保留 30 天（比赛信息）
+// - closing_lines: 保留 365 天（收盘赔率, CLV 分析需要长期数据）
+// - match_timeline: 保留 30 天（比赛时间线）
+// - event_status: 保留 30 天（完整赛事状态）
 // - ld_lineups: 保留 7 天（阵容信息）
 func (s *DataCleanupService) ExecuteCleanup() ([]CleanupResult, error) {
 	results := []CleanupResult{}
@@ -60,12 +69,19 @@ func (s *DataCleanupService) ExecuteCleanup() ([]CleanupResult, error) {
 		"bet_stops":       s.config.RetainDaysBets,      // 投注停止
 		"bet_settlements": s.config.RetainDaysBets,      // 投注结算
 		"odds_history":    s.config.RetainDaysOdds,      // 赔率历史
+		"market_margin_history": s.config.RetainDaysOdds, // 盘口返还率历史
+		"odds_alerts":     s.config.RetainDaysOdds,      // 赔率异动告警
+		"odds_candles":    s.config.RetainDaysCandles,   // 赔率 K 线（比原始赔率历史保留更久）
 		"markets":         s.config.RetainDaysOdds,      // 盘口数据
 		"odds":            s.config.RetainDaysOdds,      // 赔率详情
 		"ld_events":       s.config.RetainDaysLiveData,  // Live Data 事件（更新频繁）
 		"ld_lineups":      s.config.RetainDaysLiveData,  // 阵容信息
 		"tracked_events":  s.config.RetainDaysEvents,    // 赛事信息（保留更长时间）
 		"ld_matches":      s.config.RetainDaysEvents,    // 比赛信息（保留更长时间）
+		"closing_lines":   s.config.RetainDaysClosingLines, // 收盘赔率（CLV 分析, 长期保留）
+		"match_timeline":  s.config.RetainDaysEvents,    // 比赛时间线
+		"event_status":    s.config.RetainDaysEvents,    // 完整赛事状态
+		"audit_log":       s.config.RetainDaysAudit,     // 审计日志
 	}
 
 	// 按表清理数据
@@ -124,12 +140,19 @@ func (s *DataCleanupService) getTimeField(tableName string) string {
 		"bet_stops":       "created_at",
 		"bet_settlements": "created_at",
 		"odds_history":    "created_at",
+		"market_margin_history": "created_at",
+		"odds_alerts":     "created_at",
+		"odds_candles":    "created_at",
 		"markets":         "updated_at",
 		"odds":            "updated_at",
 		"ld_events":       "created_at",
 		"ld_lineups":      "created_at",
 		"tracked_events":  "created_at",
 		"ld_matches":      "created_at",
+		"closing_lines":   "captured_at",
+		"match_timeline":  "created_at",
+		"event_status":    "updated_at",
+		"audit_log":       "created_at",
 	}
 
 	return timeFields[tableName]
@@ -169,8 +192,8 @@ func (s *DataCleanupService) GetTableSizes() (map[string]int64, error) {
 func (s *DataCleanupService) GetTableRowCounts() (map[string]int64, error) {
 	tables := []string{
 		"uof_messages", "odds_changes", "bet_stops", "bet_settlements",
-		"odds_history", "markets", "odds", "ld_events", "ld_lineups",
-		"tracked_events", "ld_matches",
+		"odds_history", "market_margin_history", "odds_alerts", "odds_candles", "markets", "odds", "ld_events", "ld_lineups",
+		"tracked_events", "ld_matches", "closing_lines", "match_timeline", "event_status", "audit_log",
 	}
 
 	counts := make(map[string]int64)
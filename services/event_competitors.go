@@ -0,0 +1,136 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 赛事列表的 event_type 过滤值
+// match 只返回两队对阵的比赛, outright 返回冠军 / 赛季 / 阶段等非对阵赛事
+const (
+	EventFilterMatch    = "match"
+	EventFilterOutright = "outright"
+)
+
+// EventCompetitor 赛事参赛方 (tracked_events.competitors 中的一项)
+// 对阵比赛有 home / away 两项, 非对阵赛事为完整的参赛方列表且没有 qualifier
+type EventCompetitor struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Qualifier string `json:"qualifier,omitempty"`
+
+	// 来自参赛方资料 (CompetitorService), 只在响应时填充
+	Abbreviation string `json:"abbreviation,omitempty"`
+	CountryCode  string `json:"country_code,omitempty"`
+}
+
+// EventTypeCondition 返回 event_type 过滤对应的 SQL 条件 (column 为 tracked_events.event_type 列)
+// 旧数据没有 event_type, 按 match 处理; 无效的过滤值返回 error
+func EventTypeCondition(column, filter string) (string, error) {
+	switch filter {
+	case "":
+		return "", nil
+	case EventFilterMatch:
+		return fmt.Sprintf("COALESCE(%s, '%s') = '%s'", column, uof.EventTypeMatch, uof.EventTypeMatch), nil
+	case EventFilterOutright:
+		return fmt.Sprintf("COALESCE(%s, '%s') <> '%s'", column, uof.EventTypeMatch, uof.EventTypeMatch), nil
+	default:
+		return "", fmt.Errorf("invalid event_type: %s (expected %s or %s)", filter, EventFilterMatch, EventFilterOutright)
+	}
+}
+
+// SaveEventCompetitors 保存赛事类型和参赛方列表到 tracked_events
+// 参赛方为空时保留已有列表 (odds_change 通常不带参赛方)
+func SaveEventCompetitors(db *sql.DB, eventID string, competitors []uof.Competitor) error {
+	eventType := uof.EventType(eventID)
+
+	var competitorsJSON interface{}
+	if len(competitors) > 0 {
+		data, err := json.Marshal(EventCompetitorsFrom(competitors))
+		if err != nil {
+			return fmt.Errorf("failed to marshal competitors: %w", err)
+		}
+		competitorsJSON = string(data)
+	}
+
+	if eventType == "" && competitorsJSON == nil {
+		return nil
+	}
+
+	now := time.Now()
+	_, err := db.Exec(`
+		INSERT INTO tracked_events (event_id, event_type, competitors, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $4)
+		ON CONFLICT (event_id) DO UPDATE SET
+			event_type = COALESCE(EXCLUDED.event_type, tracked_events.event_type),
+			competitors = COALESCE(EXCLUDED.competitors, tracked_events.competitors)
+	`, eventID, eventType, competitorsJSON, now)
+	if err != nil {
+		return fmt.Errorf("failed to store event competitors: %w", err)
+	}
+	return nil
+}
+
+// LoadEventCompetitors 读取赛事的参赛方列表
+func LoadEventCompetitors(db *sql.DB, eventID string) ([]EventCompetitor, error) {
+	var raw sql.NullString
+	err := db.QueryRow(`SELECT competitors FROM tracked_events WHERE event_id = $1`, eventID).Scan(&raw)
+	if err == sql.ErrNoRows || (err == nil && !raw.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event competitors: %w", err)
+	}
+
+	var competitors []EventCompetitor
+	if err := json.Unmarshal([]byte(raw.String), &competitors); err != nil {
+		return nil, fmt.Errorf("failed to parse event competitors: %w", err)
+	}
+	return competitors, nil
+}
+
+// CompetitorNames 把参赛方列表转换为 competitor URN -> 名称, 用于非对阵盘口的结果命名
+func CompetitorNames(competitors []EventCompetitor) map[string]string {
+	if len(competitors) == 0 {
+		return nil
+	}
+	names := make(map[string]string, len(competitors))
+	for _, comp := range competitors {
+		if comp.Name != "" {
+			names[comp.ID] = comp.Name
+		}
+	}
+	return names
+}
+
+// EventCompetitorsFrom 把消息中的参赛方转换为 EventCompetitor 列表
+func EventCompetitorsFrom(competitors []uof.Competitor) []EventCompetitor {
+	list := make([]EventCompetitor, 0, len(competitors))
+	for _, comp := range competitors {
+		list = append(list, EventCompetitor{ID: comp.ID, Name: comp.Name, Qualifier: comp.Qualifier})
+	}
+	return list
+}
+
+// NewReplacementContext 由参赛方列表构造名称模板上下文
+// home / away 对应 $competitor1 / $competitor2, 完整列表按顺序用于 $competitorN 和参赛方结果
+func NewReplacementContext(competitors []EventCompetitor, specifiers string) *ReplacementContext {
+	ctx := &ReplacementContext{
+		Specifiers:  specifiers,
+		Competitors: CompetitorNames(competitors),
+	}
+	for _, comp := range competitors {
+		switch comp.Qualifier {
+		case "home":
+			ctx.HomeTeamName = comp.Name
+		case "away":
+			ctx.AwayTeamName = comp.Name
+		}
+		ctx.CompetitorNames = append(ctx.CompetitorNames, comp.Name)
+	}
+	return ctx
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// EventMeta 赛事元数据 (用于 WebSocket 按运动/联赛过滤, 以及推送时的名称渲染)
+type EventMeta struct {
+	SportID      string
+	TournamentID string
+	Competitors  []EventCompetitor
+}
+
+type eventMetaEntry struct {
+	meta      EventMeta
+	expiresAt time.Time
+}
+
+// EventMetaCache 赛事元数据缓存
+// odds_change 等消息本身不带 sport / tournament, 需要从 tracked_events 查询
+type EventMetaCache struct {
+	db      *sql.DB
+	ttl     time.Duration
+	entries map[string]eventMetaEntry
+	lastGC  time.Time
+	mu      sync.RWMutex
+}
+
+// NewEventMetaCache 创建赛事元数据缓存
+func NewEventMetaCache(db *sql.DB, ttl time.Duration) *EventMetaCache {
+	return &EventMetaCache{
+		db:      db,
+		ttl:     ttl,
+		entries: make(map[string]eventMetaEntry),
+	}
+}
+
+// Get 获取赛事元数据, 缓存未命中或过期时查询数据库
+func (c *EventMetaCache) Get(eventID string) EventMeta {
+	if eventID == "" {
+		return EventMeta{}
+	}
+
+	now := time.Now()
+
+	c.mu.RLock()
+	entry, ok := c.entries[eventID]
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.meta
+	}
+
+	var sportID, tournamentID, competitors sql.NullString
+	err := c.db.QueryRow(
+		`SELECT sport_id, tournament_id, competitors FROM tracked_events WHERE event_id = $1`,
+		eventID,
+	).Scan(&sportID, &tournamentID, &competitors)
+	if err != nil && err != sql.ErrNoRows {
+		// 查询失败时沿用旧值
+		return entry.meta
+	}
+
+	meta := EventMeta{SportID: sportID.String, TournamentID: tournamentID.String}
+	if competitors.Valid {
+		json.Unmarshal([]byte(competitors.String), &meta.Competitors)
+	}
+
+	// 信息不完整时缩短缓存时间, 以便 fixture 到达后尽快更新
+	ttl := c.ttl
+	if meta.SportID == "" || meta.TournamentID == "" {
+		ttl = ttl / 10
+	}
+
+	c.mu.Lock()
+	c.entries[eventID] = eventMetaEntry{meta: meta, expiresAt: now.Add(ttl)}
+
+	// 定期清理过期条目
+	if now.Sub(c.lastGC) > c.ttl {
+		for id, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		c.lastGC = now
+	}
+	c.mu.Unlock()
+
+	return meta
+}
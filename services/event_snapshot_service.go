@@ -0,0 +1,236 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventSnapshotService 赛事快照服务
+// 从 tracked_events / markets / odds 读取赛事当前完整状态,
+// 用于 WebSocket 客户端订阅时先推送一份一致的快照, 之后再推送增量消息
+type EventSnapshotService struct {
+	db                *sql.DB
+	marketDescService *MarketDescriptionsService
+	localization      *LocalizationService
+}
+
+// NewEventSnapshotService 创建赛事快照服务
+func NewEventSnapshotService(db *sql.DB, marketDescService *MarketDescriptionsService) *EventSnapshotService {
+	return &EventSnapshotService{
+		db:                db,
+		marketDescService: marketDescService,
+	}
+}
+
+// SetLocalizationService 设置多语言名称服务 (可选)
+func (s *EventSnapshotService) SetLocalizationService(localization *LocalizationService) {
+	s.localization = localization
+}
+
+// EventSnapshot 赛事快照
+type EventSnapshot struct {
+	EventID      string           `json:"event_id"`
+	Found        bool             `json:"found"`
+	SportID      string           `json:"sport_id,omitempty"`
+	HomeTeamID   string           `json:"home_team_id,omitempty"`
+	HomeTeamName string           `json:"home_team_name,omitempty"`
+	AwayTeamID   string           `json:"away_team_id,omitempty"`
+	AwayTeamName string           `json:"away_team_name,omitempty"`
+	HomeScore    *int             `json:"home_score"`
+	AwayScore    *int             `json:"away_score"`
+	MatchStatus  string           `json:"match_status"`
+	MatchTime    string           `json:"match_time"`
+	Status       string           `json:"status"`
+	Markets      []SnapshotMarket `json:"markets"`
+	GeneratedAt  int64            `json:"generated_at"`
+}
+
+// SnapshotMarket 快照中的盘口
+type SnapshotMarket struct {
+	ID         string            `json:"id"`
+	Specifier  string            `json:"specifier"`
+	Name       string            `json:"name"`
+	Status     int               `json:"status"`
+	ProducerID int               `json:"producer_id"`
+	IsMainLine bool              `json:"is_main_line"`
+	Outcomes   []SnapshotOutcome `json:"outcomes"`
+}
+
+// SnapshotOutcome 快照中的结果
+type SnapshotOutcome struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Odds      float64 `json:"odds"`
+	Active    bool    `json:"active"`
+	Timestamp int64   `json:"timestamp"`
+
+	Formatted *FormattedOdds `json:"formatted,omitempty"` // 客户端订阅了 odds_format 时填充
+}
+
+// GetEventSnapshot 获取赛事快照
+// lang 为英文以外的语言时, 队伍 / 盘口 / 结果名称按该语言重新渲染 (缺少翻译时为英文)
+// 赛事不存在时返回 Found=false 的空快照, 而不是错误
+func (s *EventSnapshotService) GetEventSnapshot(eventID, lang string) (*EventSnapshot, error) {
+	snapshot := &EventSnapshot{
+		EventID:     eventID,
+		Markets:     make([]SnapshotMarket, 0),
+		GeneratedAt: time.Now().UnixMilli(),
+	}
+
+	// 使用只读事务, 保证赛事状态和盘口赔率来自同一时刻
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+		return nil, fmt.Errorf("failed to set transaction isolation: %w", err)
+	}
+
+	var sportID, homeTeamID, homeTeamName, awayTeamID, awayTeamName sql.NullString
+	var matchStatus, matchTime, status, competitorsJSON sql.NullString
+	var homeScore, awayScore sql.NullInt64
+
+	err = tx.QueryRow(`
+		SELECT sport_id, home_team_id, home_team_name, away_team_id, away_team_name,
+		       home_score, away_score, match_status, match_time, status, competitors
+		FROM tracked_events
+		WHERE event_id = $1
+	`, eventID).Scan(
+		&sportID, &homeTeamID, &homeTeamName, &awayTeamID, &awayTeamName,
+		&homeScore, &awayScore, &matchStatus, &matchTime, &status, &competitorsJSON,
+	)
+	if err == sql.ErrNoRows {
+		return snapshot, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tracked event: %w", err)
+	}
+
+	snapshot.Found = true
+	snapshot.SportID = sportID.String
+	snapshot.HomeTeamID = homeTeamID.String
+	snapshot.HomeTeamName = homeTeamName.String
+	snapshot.AwayTeamID = awayTeamID.String
+	snapshot.AwayTeamName = awayTeamName.String
+	snapshot.MatchStatus = matchStatus.String
+	snapshot.MatchTime = matchTime.String
+	snapshot.Status = status.String
+	if homeScore.Valid {
+		v := int(homeScore.Int64)
+		snapshot.HomeScore = &v
+	}
+	if awayScore.Valid {
+		v := int(awayScore.Int64)
+		snapshot.AwayScore = &v
+	}
+
+	rows, err := tx.Query(`
+		SELECT m.id, m.sr_market_id, COALESCE(m.specifiers, ''), COALESCE(m.market_name, ''),
+		       COALESCE(m.status, '0'), COALESCE(m.producer_id, 0), COALESCE(m.is_main_line, false),
+		       o.outcome_id, COALESCE(o.outcome_name, ''), COALESCE(o.odds_value, 0),
+		       COALESCE(o.active, false), COALESCE(o.timestamp, 0)
+		FROM markets m
+		LEFT JOIN odds o ON o.market_id = m.id
+		WHERE m.event_id = $1
+		ORDER BY m.id, o.outcome_id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query markets: %w", err)
+	}
+	defer rows.Close()
+
+	// 其它语言: 参赛方名称翻译后重新渲染盘口 / 结果名称
+	localize := lang != "" && lang != DefaultLanguage && s.marketDescService != nil
+	var competitors []EventCompetitor
+	if competitorsJSON.Valid {
+		json.Unmarshal([]byte(competitorsJSON.String), &competitors)
+	}
+	if len(competitors) == 0 {
+		if snapshot.HomeTeamID != "" {
+			competitors = append(competitors, EventCompetitor{ID: snapshot.HomeTeamID, Name: snapshot.HomeTeamName, Qualifier: "home"})
+		}
+		if snapshot.AwayTeamID != "" {
+			competitors = append(competitors, EventCompetitor{ID: snapshot.AwayTeamID, Name: snapshot.AwayTeamName, Qualifier: "away"})
+		}
+	}
+
+	ctx := &ReplacementContext{
+		HomeTeamName: snapshot.HomeTeamName,
+		AwayTeamName: snapshot.AwayTeamName,
+	}
+	if localize {
+		ctx = s.localization.ReplacementContext(competitors, "", lang)
+		snapshot.HomeTeamName = s.localization.Localize(snapshot.HomeTeamID, lang, snapshot.HomeTeamName)
+		snapshot.AwayTeamName = s.localization.Localize(snapshot.AwayTeamID, lang, snapshot.AwayTeamName)
+	}
+
+	lastMarketPK := -1
+	for rows.Next() {
+		var marketPK, producerID int
+		var isMainLine bool
+		var marketID, specifiers, marketName, marketStatus string
+		var outcomeID sql.NullString
+		var outcomeName string
+		var odds float64
+		var active bool
+		var timestamp int64
+
+		if err := rows.Scan(
+			&marketPK, &marketID, &specifiers, &marketName, &marketStatus, &producerID, &isMainLine,
+			&outcomeID, &outcomeName, &odds, &active, &timestamp,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan market: %w", err)
+		}
+
+		if marketPK != lastMarketPK {
+			lastMarketPK = marketPK
+
+			if (marketName == "" || localize) && s.marketDescService != nil {
+				ctx.Specifiers = specifiers
+				marketName = s.marketDescService.GetMarketName(marketID, specifiers, ctx)
+			}
+
+			var statusValue int
+			fmt.Sscanf(marketStatus, "%d", &statusValue)
+
+			snapshot.Markets = append(snapshot.Markets, SnapshotMarket{
+				ID:         marketID,
+				Specifier:  specifiers,
+				Name:       marketName,
+				Status:     statusValue,
+				ProducerID: producerID,
+				IsMainLine: isMainLine,
+				Outcomes:   make([]SnapshotOutcome, 0),
+			})
+		}
+
+		if !outcomeID.Valid {
+			continue
+		}
+
+		market := &snapshot.Markets[len(snapshot.Markets)-1]
+		if localize {
+			// 非对阵赛事的参赛方结果找不到描述时保留入库时的名称
+			if name := s.marketDescService.GetOutcomeName(marketID, outcomeID.String, specifiers, ctx); name != outcomeID.String || outcomeName == "" {
+				outcomeName = name
+			}
+		}
+		market.Outcomes = append(market.Outcomes, SnapshotOutcome{
+			ID:        outcomeID.String,
+			Name:      outcomeName,
+			Odds:      odds,
+			Active:    active,
+			Timestamp: timestamp,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating markets: %w", err)
+	}
+
+	return snapshot, nil
+}
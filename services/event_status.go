@@ -0,0 +1,190 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 赛事状态的来源
+const (
+	EventStatusSourceOddsChange = "odds_change"
+	EventStatusSourceFixture    = "fixture"
+	EventStatusSourceSummary    = "summary"
+)
+
+// EventStatusDetail 完整的赛事状态 (sport_event_status)
+type EventStatusDetail struct {
+	EventID      string               `json:"event_id"`
+	Status       string               `json:"status"` // 状态名称: not_started / live / ended / closed ...
+	MatchStatus  string               `json:"match_status"`
+	HomeScore    *int                 `json:"home_score"`
+	AwayScore    *int                 `json:"away_score"`
+	WinnerID     string               `json:"winner_id,omitempty"`
+	Clock        *uof.Clock           `json:"clock,omitempty"`
+	PeriodScores []uof.PeriodScore        `json:"period_scores"`
+	Statistics   *uof.Statistics          `json:"statistics,omitempty"`
+	SportDetails *uof.SportSpecificStatus `json:"sport_details,omitempty"`
+	Source       string               `json:"source"`
+	UOFTimestamp int64                `json:"uof_timestamp"`
+	UpdatedAt    *time.Time           `json:"updated_at,omitempty"`
+}
+
+// NewEventStatusDetail 从 sport_event_status 构建完整状态
+func NewEventStatusDetail(eventID string, ses *uof.SportEventStatus, source string, timestamp int64) *EventStatusDetail {
+	detail := &EventStatusDetail{
+		EventID:      eventID,
+		Status:       sportEventStatusName(ses.Status),
+		MatchStatus:  ses.MatchStatus,
+		HomeScore:    ses.HomeScore,
+		AwayScore:    ses.AwayScore,
+		WinnerID:     ses.WinnerID,
+		Clock:        ses.Clock,
+		PeriodScores: ses.PeriodScores,
+		Statistics:   ses.Statistics,
+		Source:       source,
+		UOFTimestamp: timestamp,
+	}
+	if detail.PeriodScores == nil {
+		detail.PeriodScores = []uof.PeriodScore{}
+	}
+	if ses.SportSpecificStatus != (uof.SportSpecificStatus{}) {
+		sport := ses.SportSpecificStatus
+		detail.SportDetails = &sport
+	}
+	return detail
+}
+
+// EventStatusService 保存和查询完整的赛事状态 (event_status 表, 每场一行)
+type EventStatusService struct {
+	db *sql.DB
+}
+
+// NewEventStatusService 创建赛事状态服务
+func NewEventStatusService(db *sql.DB) *EventStatusService {
+	return &EventStatusService{db: db}
+}
+
+// jsonOrNil 序列化为 JSON 字符串, 值为空时返回 nil (数据库中保留原值)
+func jsonOrNil(v interface{}, empty bool) (interface{}, error) {
+	if empty {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event status: %w", err)
+	}
+	return string(data), nil
+}
+
+// Save 保存赛事状态; 消息中没有的部分 (时钟 / 分段比分 / 统计 / 运动特有属性) 保留原值
+// timestamp 比已保存的旧时不更新; fixture 和 summary 没有消息时间戳时使用当前时间
+func (s *EventStatusService) Save(eventID string, ses *uof.SportEventStatus, source string, timestamp int64) error {
+	if ses == nil {
+		return nil
+	}
+	if timestamp == 0 {
+		timestamp = time.Now().UnixMilli()
+	}
+	detail := NewEventStatusDetail(eventID, ses, source, timestamp)
+
+	clock, err := jsonOrNil(detail.Clock, detail.Clock == nil)
+	if err != nil {
+		return err
+	}
+	periodScores, err := jsonOrNil(detail.PeriodScores, len(detail.PeriodScores) == 0)
+	if err != nil {
+		return err
+	}
+	statistics, err := jsonOrNil(detail.Statistics, detail.Statistics == nil)
+	if err != nil {
+		return err
+	}
+	sportDetails, err := jsonOrNil(detail.SportDetails, detail.SportDetails == nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO event_status (event_id, status, match_status, home_score, away_score, winner_id,
+		                          clock, period_scores, statistics, sport_details, source, uof_timestamp, updated_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, NOW())
+		ON CONFLICT (event_id) DO UPDATE SET
+		    status = COALESCE(EXCLUDED.status, event_status.status),
+		    match_status = COALESCE(EXCLUDED.match_status, event_status.match_status),
+		    home_score = COALESCE(EXCLUDED.home_score, event_status.home_score),
+		    away_score = COALESCE(EXCLUDED.away_score, event_status.away_score),
+		    winner_id = COALESCE(EXCLUDED.winner_id, event_status.winner_id),
+		    clock = COALESCE(EXCLUDED.clock, event_status.clock),
+		    period_scores = COALESCE(EXCLUDED.period_scores, event_status.period_scores),
+		    statistics = COALESCE(EXCLUDED.statistics, event_status.statistics),
+		    sport_details = COALESCE(EXCLUDED.sport_details, event_status.sport_details),
+		    source = EXCLUDED.source,
+		    uof_timestamp = EXCLUDED.uof_timestamp,
+		    updated_at = NOW()
+		WHERE EXCLUDED.uof_timestamp >= event_status.uof_timestamp
+	`, eventID, detail.Status, detail.MatchStatus, detail.HomeScore, detail.AwayScore, detail.WinnerID,
+		clock, periodScores, statistics, sportDetails, source, timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to upsert event_status: %w", err)
+	}
+	return nil
+}
+
+// Get 获取赛事状态, 不存在时返回 nil
+func (s *EventStatusService) Get(eventID string) (*EventStatusDetail, error) {
+	var detail EventStatusDetail
+	var status, matchStatus, winnerID sql.NullString
+	var homeScore, awayScore sql.NullInt64
+	var clock, periodScores, statistics, sportDetails []byte
+	var updatedAt time.Time
+
+	err := s.db.QueryRow(`
+		SELECT event_id, status, match_status, home_score, away_score, winner_id,
+		       clock, period_scores, statistics, sport_details, source, uof_timestamp, updated_at
+		FROM event_status
+		WHERE event_id = $1
+	`, eventID).Scan(&detail.EventID, &status, &matchStatus, &homeScore, &awayScore, &winnerID,
+		&clock, &periodScores, &statistics, &sportDetails, &detail.Source, &detail.UOFTimestamp, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event_status: %w", err)
+	}
+
+	detail.Status = status.String
+	detail.MatchStatus = matchStatus.String
+	detail.WinnerID = winnerID.String
+	if homeScore.Valid {
+		v := int(homeScore.Int64)
+		detail.HomeScore = &v
+	}
+	if awayScore.Valid {
+		v := int(awayScore.Int64)
+		detail.AwayScore = &v
+	}
+	detail.UpdatedAt = &updatedAt
+
+	detail.PeriodScores = []uof.PeriodScore{}
+	for _, field := range []struct {
+		data []byte
+		dest interface{}
+	}{
+		{clock, &detail.Clock},
+		{periodScores, &detail.PeriodScores},
+		{statistics, &detail.Statistics},
+		{sportDetails, &detail.SportDetails},
+	} {
+		if len(field.data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.data, field.dest); err != nil {
+			return nil, fmt.Errorf("failed to decode event_status: %w", err)
+		}
+	}
+	return &detail, nil
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"uof-service/uof"
 )
 
 // FixtureParser Fixture 消息解析器
@@ -18,40 +20,8 @@ type FixtureParser struct {
 	logger           *log.Logger
 	apiBaseURL       string
 	accessToken      string
-}
-
-// FixtureMessage Fixture 消息结构
-type FixtureMessage struct {
-	XMLName      xml.Name     `xml:"fixture"`
-	EventID      string       `xml:"event_id,attr"`
-	ProductID    int          `xml:"product,attr"`
-	Timestamp    int64        `xml:"timestamp,attr"`
-	ScheduledTime int64       `xml:"scheduled,attr"`
-	Sport        SportInfo    `xml:"sport"`
-	Tournament   TournamentInfo `xml:"tournament"`
-	Competitors  []FixtureCompetitor `xml:"competitors>competitor"`
-	Status       string       `xml:"status,attr"`
-	StartTime    int64        `xml:"start_time,attr"`
-	NextLiveTime int64        `xml:"next_live_time,attr"`
-}
-
-// SportInfo 体育信息
-type SportInfo struct {
-	ID   string `xml:"id,attr"`
-	Name string `xml:"name,attr"`
-}
-
-// TournamentInfo 锦标赛信息
-type TournamentInfo struct {
-	ID   string `xml:"id,attr"`
-	Name string `xml:"name,attr"`
-}
-
-// FixtureCompetitor 参赛队伍
-type FixtureCompetitor struct {
-	ID        string `xml:"id,attr"`
-	Name      string `xml:"name,attr"`
-	Qualifier string `xml:"qualifier,attr"` // home/away
+	eventStatus      *EventStatusService
+	localization     *LocalizationService
 }
 
 // NewFixtureParser 创建 Fixture 解析器
@@ -62,16 +32,26 @@ func NewFixtureParser(db *sql.DB, srnMappingService *SRNMappingService, apiBaseU
 		logger:           log.New(os.Stdout, "", log.LstdFlags),
 		apiBaseURL:       apiBaseURL,
 		accessToken:      accessToken,
+		eventStatus:      NewEventStatusService(db),
 	}
 }
 
-// ParseAndStore 解析并存储 Fixture 消息
+// SetLocalizationService 设置多语言名称服务 (可选, 设置后为其它语言预取赛事名称)
+func (p *FixtureParser) SetLocalizationService(localization *LocalizationService) {
+	p.localization = localization
+}
+
+// ParseAndStore 解析并存储 Fixture XML (Fixture API 的响应)
 func (p *FixtureParser) ParseAndStore(xmlContent string) error {
-	var fixture FixtureMessage
+	var fixture uof.Fixture
 	if err := xml.Unmarshal([]byte(xmlContent), &fixture); err != nil {
 		return fmt.Errorf("failed to parse fixture message: %w", err)
 	}
+	return p.Store(&fixture)
+}
 
+// Store 存储已解码的 Fixture 消息
+func (p *FixtureParser) Store(fixture *uof.Fixture) error {
 	p.logger.Printf("Parsing fixture for event: %s", fixture.EventID)
 
 	// 获取 SRN ID
@@ -109,6 +89,8 @@ func (p *FixtureParser) ParseAndStore(xmlContent string) error {
 			fixture.EventID,
 			srnID,
 			fixture.Sport.ID,
+			fixture.Tournament.ID,
+			fixture.Tournament.Name,
 			scheduleTime,
 			homeTeamID,
 			homeTeamName,
@@ -120,8 +102,26 @@ func (p *FixtureParser) ParseAndStore(xmlContent string) error {
 		return fmt.Errorf("failed to store fixture data: %w", err)
 	}
 
-	p.logger.Printf("Stored fixture data for event %s: home=%s, away=%s, scheduled=%v",
-		fixture.EventID, homeTeamName, awayTeamName, scheduleTime)
+	// 保存赛事类型和完整参赛方列表 (outright / season 等没有 home / away)
+	if err := SaveEventCompetitors(p.db, fixture.EventID, fixture.Competitors); err != nil {
+		p.logger.Printf("Warning: failed to store competitors for %s: %v", fixture.EventID, err)
+	}
+
+	// 保存赛事状态 (如果 fixture 带有 sport_event_status)
+	if err := p.eventStatus.Save(fixture.EventID, fixture.SportEventStatus, EventStatusSourceFixture, fixture.Timestamp); err != nil {
+		p.logger.Printf("Warning: failed to store sport_event_status for %s: %v", fixture.EventID, err)
+	}
+
+	// 为其它语言预取赛事 / 联赛 / 参赛方名称
+	p.localization.Prefetch(fixture.EventID)
+
+	if uof.EventType(fixture.EventID) == uof.EventTypeMatch {
+		p.logger.Printf("Stored fixture data for event %s: home=%s, away=%s, scheduled=%v",
+			fixture.EventID, homeTeamName, awayTeamName, scheduleTime)
+	} else {
+		p.logger.Printf("Stored fixture data for event %s: %d competitors, scheduled=%v",
+			fixture.EventID, len(fixture.Competitors), scheduleTime)
+	}
 
 	return nil
 }
@@ -146,12 +146,12 @@ func (p *FixtureParser) getStatusOrder(status string) int {
 
 // storeFixtureData 存储 Fixture 数据到数据库
 func (p *FixtureParser) storeFixtureData(
-	eventID, srnID, sportID string,
+	eventID, srnID, sportID, tournamentID, tournamentName string,
 	scheduleTime *time.Time,
 	homeTeamID, homeTeamName, awayTeamID, awayTeamName, status string, statusOrder int,
 ) error {
 	// 使用 UPSERT 更新或插入 tracked_events
-query := `INSERT INTO tracked_events (event_id, srn_id, sport_id, schedule_time, home_team_id, home_team_name, away_team_id, away_team_name, match_status, status_order, subscribed, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12) ON CONFLICT (event_id) DO UPDATE SET srn_id = COALESCE(NULLIF(EXCLUDED.srn_id, ''), tracked_events.srn_id), sport_id = COALESCE(NULLIF(EXCLUDED.sport_id, ''), tracked_events.sport_id), schedule_time = COALESCE(EXCLUDED.schedule_time, tracked_events.schedule_time), home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, match_status = CASE WHEN EXCLUDED.match_status = '' THEN tracked_events.match_status ELSE EXCLUDED.match_status END, status_order = CASE WHEN EXCLUDED.status_order > tracked_events.status_order THEN EXCLUDED.status_order ELSE tracked_events.status_order END, updated_at = EXCLUDED.updated_at`
+query := `INSERT INTO tracked_events (event_id, srn_id, sport_id, schedule_time, home_team_id, home_team_name, away_team_id, away_team_name, match_status, status_order, subscribed, created_at, updated_at, tournament_id, tournament_name) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12, $13, $14) ON CONFLICT (event_id) DO UPDATE SET srn_id = COALESCE(NULLIF(EXCLUDED.srn_id, ''), tracked_events.srn_id), sport_id = COALESCE(NULLIF(EXCLUDED.sport_id, ''), tracked_events.sport_id), tournament_id = COALESCE(NULLIF(EXCLUDED.tournament_id, ''), tracked_events.tournament_id), tournament_name = COALESCE(NULLIF(EXCLUDED.tournament_name, ''), tracked_events.tournament_name), schedule_time = COALESCE(EXCLUDED.schedule_time, tracked_events.schedule_time), home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, match_status = CASE WHEN EXCLUDED.match_status = '' THEN tracked_events.match_status ELSE EXCLUDED.match_status END, status_order = CASE WHEN EXCLUDED.status_order > tracked_events.status_order THEN EXCLUDED.status_order ELSE tracked_events.status_order END, updated_at = EXCLUDED.updated_at`
 
 	// p.logger.Printf("[DEBUG] SQL Query: %s, Args: event_id=%v, srn_id=%v, sport_id=%v, schedule_time=%v, home_team_id=%v, home_team_name=%v, away_team_id=%v, away_team_name=%v, status=%v", CleanSQLQuery(query), eventID, srnID, sportID, scheduleTime, homeTeamID, homeTeamName, awayTeamID, awayTeamName, status)
 		_, err := p.db.Exec(
@@ -161,6 +161,7 @@ query := `INSERT INTO tracked_events (event_id, srn_id, sport_id, schedule_time,
 			awayTeamID, awayTeamName,
 status, statusOrder,
 				time.Now(), time.Now(),
+				tournamentID, tournamentName,
 			)
 	if err != nil {
 		return fmt.Errorf("failed to upsert tracked_events: %w", err)
@@ -169,19 +170,9 @@ status, statusOrder,
 	return nil
 }
 
-// ParseFixtureChange 解析 fixture_change 消息
-func (p *FixtureParser) ParseFixtureChange(eventID string, xmlContent string) error {
-	type FixtureChange struct {
-		StartTime    int64 `xml:"start_time,attr"`
-		NextLiveTime int64 `xml:"next_live_time,attr"`
-		ChangeType   int   `xml:"change_type,attr"`
-		ProductID    int   `xml:"product,attr"`
-	}
-
-	var fixtureChange FixtureChange
-	if err := xml.Unmarshal([]byte(xmlContent), &fixtureChange); err != nil {
-		return fmt.Errorf("failed to parse fixture_change: %w", err)
-	}
+// ProcessFixtureChange 处理 fixture_change 消息
+func (p *FixtureParser) ProcessFixtureChange(fixtureChange *uof.FixtureChange) error {
+	eventID := fixtureChange.EventID
 
 	// 日志在处理完成后输出
 
@@ -48,6 +48,7 @@ type FixtureData struct {
 	XMLName xml.Name `xml:"fixtures_fixture"`
 	Fixture struct {
 		ID     string `xml:"id,attr"`
+		Name   string `xml:"name,attr"`   // stage / season 等非对阵赛事的名称
 		Status string `xml:"status,attr"` // live, not_started, ended, etc.
 		Tournament struct {
 			ID   string `xml:"id,attr"`
@@ -70,11 +71,16 @@ type FixtureData struct {
 
 // FetchFixture 获取赛事 Fixture 信息
 func (s *FixtureService) FetchFixture(eventID string) (*FixtureData, error) {
+	return s.FetchLocalizedFixture(eventID, "en")
+}
+
+// FetchLocalizedFixture 获取指定语言的赛事 Fixture 信息 (队伍 / 联赛 / 运动名称为该语言)
+func (s *FixtureService) FetchLocalizedFixture(eventID, lang string) (*FixtureData, error) {
 	// UOF Fixture API 端点：使用 .xml 格式，不使用 api_token 查询参数
-	url := fmt.Sprintf("%s/sports/en/sport_events/%s/fixture.xml",
-		s.baseURL, eventID)
+	url := fmt.Sprintf("%s/sports/%s/sport_events/%s/fixture.xml",
+		s.baseURL, lang, eventID)
 	
-	logger.Printf("[FixtureService] Fetching fixture for event: %s", eventID)
+	logger.Printf("[FixtureService] Fetching fixture for event: %s (%s)", eventID, lang)
 	
 	// 创建请求
 	req, err := http.NewRequest("GET", url, nil)
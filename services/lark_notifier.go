@@ -376,3 +376,87 @@ func (n *LarkNotifier) NotifyDatabaseReset(deletedCounts map[string]int64, total
 	return n.SendRichText("Database Reset", content)
 }
 
+
+// NotifySettlementFlips 发送提前结算在确认时结果变化的告警
+func (n *LarkNotifier) NotifySettlementFlips(flips []SettlementFlip) error {
+	if !n.enabled || len(flips) == 0 {
+		return nil
+	}
+	
+	content := [][]LarkElement{
+		{
+			{Tag: "text", Text: "⚠️ 提前结算结果在确认时发生变化\n"},
+		},
+		{
+			{Tag: "text", Text: fmt.Sprintf("比赛: %s\n", flips[0].EventID)},
+		},
+		{
+			{Tag: "text", Text: fmt.Sprintf("变化结果数: %d\n", len(flips))},
+		},
+	}
+	
+	// 最多列出 10 条, 其余通过 /api/settlements/flips 查看
+	for i, flip := range flips {
+		if i >= 10 {
+			content = append(content, []LarkElement{
+				{Tag: "text", Text: fmt.Sprintf("  ... 还有 %d 条\n", len(flips)-10)},
+			})
+			break
+		}
+		market := flip.SRMarketID
+		if flip.Specifiers != "" {
+			market += " (" + flip.Specifiers + ")"
+		}
+		content = append(content, []LarkElement{
+			{Tag: "text", Text: fmt.Sprintf("  • 市场 %s 结果 %s: %s → %s (提前结算 producer %d)\n",
+				market, flip.OutcomeID, flip.EarlyOutcome, flip.ConfirmedOutcome, flip.EarlyProducerID)},
+		})
+	}
+	
+	content = append(content, []LarkElement{
+		{Tag: "text", Text: fmt.Sprintf("\n时间: %s", time.Now().Format("2006-01-02 15:04:05"))},
+	})
+	
+	return n.SendRichText("Settlement Flip Alert", content)
+}
+
+// NotifyOddsAlerts 发送赔率异动告警, suppressed 为此前被限流跳过的告警数
+func (n *LarkNotifier) NotifyOddsAlerts(alerts []OddsAlert, suppressed int) error {
+	if !n.enabled || len(alerts) == 0 {
+		return nil
+	}
+	
+	content := [][]LarkElement{
+		{
+			{Tag: "text", Text: "📈 赔率异动告警\n"},
+		},
+		{
+			{Tag: "text", Text: fmt.Sprintf("比赛: %s\n", alerts[0].EventID)},
+		},
+	}
+	
+	// 最多列出 10 条, 其余通过 /api/odds-alerts 查看
+	for i, alert := range alerts {
+		if i >= 10 {
+			content = append(content, []LarkElement{
+				{Tag: "text", Text: fmt.Sprintf("  ... 还有 %d 条\n", len(alerts)-10)},
+			})
+			break
+		}
+		content = append(content, []LarkElement{
+			{Tag: "text", Text: fmt.Sprintf("  • [%s] %s: %s\n", alert.Severity, alert.RuleName, alert.Message)},
+		})
+	}
+	
+	if suppressed > 0 {
+		content = append(content, []LarkElement{
+			{Tag: "text", Text: fmt.Sprintf("\n限流期间未发送的告警: %d 条\n", suppressed)},
+		})
+	}
+	
+	content = append(content, []LarkElement{
+		{Tag: "text", Text: fmt.Sprintf("\n时间: %s", time.Now().Format("2006-01-02 15:04:05"))},
+	})
+	
+	return n.SendRichText("Odds Alert", content)
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"uof-service/logger"
+	"uof-service/uof"
+)
+
+// DefaultLanguage 默认语言, 其它语言缺少翻译时回退到英文
+const DefaultLanguage = "en"
+
+// localizationRetryDelay 同一名称请求失败 (或 API 没有该语言) 后再次请求的间隔
+const localizationRetryDelay = 30 * time.Minute
+
+// 比赛状态名称在 localized_names 中的 key 前缀 (match_status:<code>)
+const matchStatusNameKey = "match_status:"
+
+// LocalizationService 多语言名称服务
+// 缓存参赛方 / 球员 / 赛事 / 联赛 / 运动 / 分类 / 比赛状态的各语言名称 (localized_names 表)
+// 缓存未命中时在后台按语言请求 Sports API, 本次调用先回退到英文名称
+type LocalizationService struct {
+	db             *sql.DB
+	apiBaseURL     string
+	token          string
+	languages      []string
+	client         *http.Client
+	fixtureService *FixtureService
+	names          map[string]map[string]string // lang -> URN -> 名称
+	pending        map[string]time.Time         // lang|URN -> 下次允许请求的时间
+	queue          chan localizationRequest
+	mu             sync.RWMutex
+}
+
+type localizationRequest struct {
+	urn  string
+	lang string
+}
+
+// NewLocalizationService 创建多语言名称服务
+// languages 为配置的语言列表 (config.Languages), 总是包含 en
+func NewLocalizationService(db *sql.DB, token, apiBaseURL string, languages []string) *LocalizationService {
+	langs := []string{DefaultLanguage}
+	for _, lang := range languages {
+		if lang != DefaultLanguage {
+			langs = append(langs, lang)
+		}
+	}
+
+	return &LocalizationService{
+		db:             db,
+		apiBaseURL:     strings.TrimSuffix(apiBaseURL, "/v1"),
+		token:          token,
+		languages:      langs,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		fixtureService: NewFixtureService(token, apiBaseURL),
+		names:          make(map[string]map[string]string),
+		pending:        make(map[string]time.Time),
+		queue:          make(chan localizationRequest, 1000),
+	}
+}
+
+// Start 从数据库加载缓存并启动后台请求
+func (s *LocalizationService) Start() error {
+	logger.Printf("[Localization] Starting localization service (languages: %v)", s.languages)
+
+	if err := s.loadFromDatabase(); err != nil {
+		logger.Errorf("[Localization] ⚠️  Failed to load from database: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		go s.worker()
+	}
+
+	logger.Printf("[Localization] ✅ Localization service started (%d names cached)", s.countNames())
+	return nil
+}
+
+// loadFromDatabase 从 localized_names 加载缓存
+func (s *LocalizationService) loadFromDatabase() error {
+	rows, err := s.db.Query(`SELECT urn, lang, name FROM localized_names`)
+	if err != nil {
+		return fmt.Errorf("failed to query localized names: %w", err)
+	}
+	defer rows.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for rows.Next() {
+		var urn, lang, name string
+		if err := rows.Scan(&urn, &lang, &name); err != nil {
+			continue
+		}
+		if s.names[lang] == nil {
+			s.names[lang] = make(map[string]string)
+		}
+		s.names[lang][urn] = name
+	}
+	return rows.Err()
+}
+
+// Languages 返回配置的语言列表 (第一个为 en)
+func (s *LocalizationService) Languages() []string {
+	if s == nil {
+		return []string{DefaultLanguage}
+	}
+	return append([]string(nil), s.languages...)
+}
+
+// ExtraLanguages 返回除英文外需要翻译的语言
+func (s *LocalizationService) ExtraLanguages() []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s.languages[1:]...)
+}
+
+// NormalizeLanguage 规范化请求的语言 (zh-CN -> zh), 未配置的语言返回 en
+func (s *LocalizationService) NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if s == nil || lang == "" {
+		return DefaultLanguage
+	}
+	base := strings.FieldsFunc(lang, func(r rune) bool { return r == '-' || r == '_' })
+	for _, configured := range s.languages {
+		if lang == configured || (len(base) > 0 && base[0] == configured) {
+			return configured
+		}
+	}
+	return DefaultLanguage
+}
+
+// Name 返回 URN 在指定语言下的名称, 没有翻译时返回空字符串
+// 参赛方 / 球员 / 赛事 / 联赛的翻译缺失时在后台请求 Sports API
+func (s *LocalizationService) Name(urn, lang string) string {
+	if s == nil || urn == "" || lang == "" {
+		return ""
+	}
+
+	s.mu.RLock()
+	name, ok := s.names[lang][urn]
+	s.mu.RUnlock()
+	if ok {
+		return name
+	}
+
+	if lang != DefaultLanguage {
+		s.enqueue(urn, lang)
+	}
+	return ""
+}
+
+// Localize 返回 URN 在指定语言下的名称, 没有翻译时返回 fallback (通常为英文名称)
+func (s *LocalizationService) Localize(urn, lang, fallback string) string {
+	if lang == "" || lang == DefaultLanguage {
+		return fallback
+	}
+	if name := s.Name(urn, lang); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// LocalizeCompetitors 返回参赛方列表的副本, 名称替换为指定语言 (缺少翻译时保留原名称)
+func (s *LocalizationService) LocalizeCompetitors(competitors []EventCompetitor, lang string) []EventCompetitor {
+	if s == nil || lang == "" || lang == DefaultLanguage || len(competitors) == 0 {
+		return competitors
+	}
+	localized := make([]EventCompetitor, len(competitors))
+	for i, comp := range competitors {
+		comp.Name = s.Localize(comp.ID, lang, comp.Name)
+		localized[i] = comp
+	}
+	return localized
+}
+
+// ReplacementContext 构造指定语言的名称模板上下文 (参赛方名称已翻译)
+func (s *LocalizationService) ReplacementContext(competitors []EventCompetitor, specifiers, lang string) *ReplacementContext {
+	ctx := NewReplacementContext(s.LocalizeCompetitors(competitors, lang), specifiers)
+	ctx.Lang = lang
+	return ctx
+}
+
+// SportName 返回运动名称: 优先使用 Sports API 的翻译, 中文缺失时使用内置中文名称, 最后回退到英文
+// 都没有时返回空字符串
+func (s *LocalizationService) SportName(sportID, lang string) string {
+	if name := s.Name(sportID, lang); name != "" {
+		return name
+	}
+	if lang == "zh" {
+		if name, ok := SportChineseName[sportID]; ok {
+			return name
+		}
+	}
+	return s.Name(sportID, DefaultLanguage)
+}
+
+// MatchStatusName 返回比赛状态名称 (来自 match_status 描述), 回退规则同 SportName
+func (s *LocalizationService) MatchStatusName(code, lang string) string {
+	if name := s.Name(matchStatusNameKey+code, lang); name != "" {
+		return name
+	}
+	if lang == "zh" {
+		if name, ok := MatchStatusChineseName[code]; ok {
+			return name
+		}
+	}
+	return s.Name(matchStatusNameKey+code, DefaultLanguage)
+}
+
+// SaveNames 保存一批名称到数据库和缓存
+func (s *LocalizationService) SaveNames(lang string, names map[string]string) error {
+	if s == nil || len(names) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for urn, name := range names {
+		if urn == "" || name == "" {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO localized_names (urn, lang, name, updated_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (urn, lang) DO UPDATE SET
+				name = EXCLUDED.name,
+				updated_at = NOW()
+		`, urn, lang, name)
+		if err != nil {
+			return fmt.Errorf("failed to save localized name %s (%s): %w", urn, lang, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit localized names: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.names[lang] == nil {
+		s.names[lang] = make(map[string]string)
+	}
+	for urn, name := range names {
+		if urn == "" || name == "" {
+			continue
+		}
+		s.names[lang][urn] = name
+		delete(s.pending, lang+"|"+urn)
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Prefetch 为所有其它语言预取赛事的名称 (赛事 / 联赛 / 运动 / 参赛方)
+func (s *LocalizationService) Prefetch(eventID string) {
+	if s == nil {
+		return
+	}
+	for _, lang := range s.ExtraLanguages() {
+		s.mu.RLock()
+		_, ok := s.names[lang][eventID]
+		s.mu.RUnlock()
+		if !ok {
+			s.enqueue(eventID, lang)
+		}
+	}
+}
+
+// enqueue 把名称请求加入后台队列 (同一名称在重试间隔内只请求一次)
+func (s *LocalizationService) enqueue(urn, lang string) {
+	parsed, err := uof.ParseURN(urn)
+	if err != nil {
+		return
+	}
+	switch parsed.Type {
+	case "competitor", "player", uof.EventTypeMatch, uof.EventTypeStage,
+		uof.EventTypeSeason, uof.EventTypeTournament, uof.EventTypeSimpleTournament:
+	default:
+		// 运动 / 分类等由 StaticDataService 定期加载
+		return
+	}
+
+	key := lang + "|" + urn
+	now := time.Now()
+
+	s.mu.Lock()
+	if next, ok := s.pending[key]; ok && now.Before(next) {
+		s.mu.Unlock()
+		return
+	}
+	// 清理已过重试间隔的失败记录
+	if len(s.pending) > 10000 {
+		for k, next := range s.pending {
+			if now.After(next) {
+				delete(s.pending, k)
+			}
+		}
+	}
+	s.pending[key] = now.Add(localizationRetryDelay)
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- localizationRequest{urn: urn, lang: lang}:
+	default:
+		// 队列已满, 下次调用时再请求
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.mu.Unlock()
+	}
+}
+
+// worker 处理后台名称请求
+func (s *LocalizationService) worker() {
+	for req := range s.queue {
+		names, err := s.fetchNames(req.urn, req.lang)
+		if err != nil {
+			logger.Printf("[Localization] ⚠️  Failed to load %s name for %s: %v", req.lang, req.urn, err)
+			continue
+		}
+		if err := s.SaveNames(req.lang, names); err != nil {
+			logger.Errorf("[Localization] ⚠️  Failed to save %s names for %s: %v", req.lang, req.urn, err)
+		}
+	}
+}
+
+// fetchNames 按 URN 类型请求对应语言的 Sports API, 返回响应中出现的所有名称
+func (s *LocalizationService) fetchNames(urn, lang string) (map[string]string, error) {
+	parsed, err := uof.ParseURN(urn)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	switch parsed.Type {
+	case "competitor":
+		var profile struct {
+			Competitor uof.Competitor `xml:"competitor"`
+			Players    []struct {
+				ID   string `xml:"id,attr"`
+				Name string `xml:"name,attr"`
+			} `xml:"players>player"`
+		}
+		if err := s.fetchXML(fmt.Sprintf("/v1/sports/%s/competitors/%s/profile.xml", lang, urn), &profile); err != nil {
+			return nil, err
+		}
+		names[profile.Competitor.ID] = profile.Competitor.Name
+		for _, player := range profile.Players {
+			names[player.ID] = player.Name
+		}
+
+	case "player":
+		var profile PlayerProfileResponse
+		if err := s.fetchXML(fmt.Sprintf("/v1/sports/%s/players/%s/profile.xml", lang, urn), &profile); err != nil {
+			return nil, err
+		}
+		names[profile.Player.ID] = profile.Player.Name
+
+	case uof.EventTypeSeason, uof.EventTypeTournament, uof.EventTypeSimpleTournament:
+		var info struct {
+			Tournament uof.Tournament `xml:"tournament"`
+			Season     struct {
+				ID   string `xml:"id,attr"`
+				Name string `xml:"name,attr"`
+			} `xml:"season"`
+			Competitors []uof.Competitor `xml:"competitors>competitor"`
+		}
+		if err := s.fetchXML(fmt.Sprintf("/v1/sports/%s/tournaments/%s/info.xml", lang, urn), &info); err != nil {
+			return nil, err
+		}
+		names[info.Tournament.ID] = info.Tournament.Name
+		names[info.Tournament.Sport.ID] = info.Tournament.Sport.Name
+		names[info.Season.ID] = info.Season.Name
+		for _, comp := range info.Competitors {
+			names[comp.ID] = comp.Name
+		}
+
+	default:
+		fixture, err := s.fixtureService.FetchLocalizedFixture(urn, lang)
+		if err != nil {
+			return nil, err
+		}
+		names[fixture.Fixture.ID] = fixture.Fixture.Name
+		names[fixture.Fixture.Tournament.ID] = fixture.Fixture.Tournament.Name
+		names[fixture.Fixture.Tournament.Sport.ID] = fixture.Fixture.Tournament.Sport.Name
+		for _, comp := range fixture.Fixture.Competitors.Competitor {
+			names[comp.ID] = comp.Name
+		}
+	}
+
+	delete(names, "")
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no names in %s response", lang)
+	}
+	return names, nil
+}
+
+// fetchXML 请求 Sports API 并解析 XML
+func (s *LocalizationService) fetchXML(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", s.apiBaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-access-token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+	return nil
+}
+
+// countNames 统计缓存的名称数量
+func (s *LocalizationService) countNames() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, names := range s.names {
+		count += len(names)
+	}
+	return count
+}
+
+// GetStatus 获取服务状态
+func (s *LocalizationService) GetStatus() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int, len(s.names))
+	for lang, names := range s.names {
+		counts[lang] = len(names)
+	}
+	return map[string]interface{}{
+		"languages":   s.languages,
+		"name_counts": counts,
+		"pending":     len(s.pending),
+	}
+}
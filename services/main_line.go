@@ -0,0 +1,301 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"uof-service/uof"
+)
+
+// lineSpecifierKeys 表示盘口线的 specifier (让球 / 大小球)
+var lineSpecifierKeys = map[string]bool{
+	"hcp":   true,
+	"total": true,
+}
+
+// SplitLineSpecifier 把 specifiers 拆成盘口族 (去掉盘口线后的其余 specifiers) 和盘口线
+// 例如 "quarternr=1|total=2.5" -> ("quarternr=1", "2.5", true); 不是盘口线市场时 ok 为 false
+func SplitLineSpecifier(specifiers string) (family string, line string, ok bool) {
+	if specifiers == "" {
+		return "", "", false
+	}
+
+	rest := make([]string, 0)
+	for _, part := range strings.Split(specifiers, "|") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && lineSpecifierKeys[kv[0]] {
+			line = kv[1]
+			ok = true
+			continue
+		}
+		rest = append(rest, part)
+	}
+	sort.Strings(rest)
+	return strings.Join(rest, "|"), line, ok
+}
+
+// LineBalance 计算盘口各结果去除利润率后的概率的最大差值, 越小越接近平手盘 (even money)
+// 只有全部结果 active 且赔率 > 1 (至少 2 个) 时有效
+func LineBalance(odds []float64, active []bool) (float64, bool) {
+	overround, ok := ComputeOverround(odds, active)
+	if !ok {
+		return 0, false
+	}
+	minP, maxP := 1.0, 0.0
+	for _, o := range odds {
+		p := 1 / o / overround
+		if p < minP {
+			minP = p
+		}
+		if p > maxP {
+			maxP = p
+		}
+	}
+	return maxP - minP, true
+}
+
+// MainLine 盘口族的主盘口
+type MainLine struct {
+	MarketID  int    `json:"market_id"`
+	Family    string `json:"family"`    // 除盘口线外的 specifiers, 通常为空
+	Specifier string `json:"specifier"` // 主盘口的完整 specifiers
+}
+
+// MainLineTracker 按赛事跟踪每个盘口族各条线的平衡度, 用于在 WebSocket 广播中标记主盘口
+type MainLineTracker struct {
+	events  map[string]*eventMainLines
+	maxIdle time.Duration
+	lastGC  time.Time
+	mu      sync.Mutex
+}
+
+type eventMainLines struct {
+	families  map[string]map[string]float64 // key: market_id|family -> specifiers -> balance
+	updatedAt time.Time
+}
+
+// NewMainLineTracker 创建主盘口跟踪器, maxIdle 内没有更新的赛事会被清理
+func NewMainLineTracker(maxIdle time.Duration) *MainLineTracker {
+	return &MainLineTracker{
+		events:  make(map[string]*eventMainLines),
+		maxIdle: maxIdle,
+	}
+}
+
+func mainLineFamilyKey(marketID int, family string) string {
+	return strconv.Itoa(marketID) + "|" + family
+}
+
+// Update 用 odds_change 更新各条线的平衡度, 返回本消息涉及的盘口族当前的主盘口
+// active 且结果齐全的线参与比较, 其余的线从候选中移除
+func (t *MainLineTracker) Update(oddsChange *uof.OddsChange) []MainLine {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.gc(now)
+
+	state, ok := t.events[oddsChange.EventID]
+	if !ok {
+		state = &eventMainLines{families: make(map[string]map[string]float64)}
+		t.events[oddsChange.EventID] = state
+	}
+	state.updatedAt = now
+
+	for _, market := range oddsChange.Odds.Markets {
+		family, _, isLine := SplitLineSpecifier(market.Specifiers)
+		if !isLine {
+			continue
+		}
+		key := mainLineFamilyKey(market.ID, family)
+		lines, exists := state.families[key]
+		if !exists {
+			lines = make(map[string]float64)
+			state.families[key] = lines
+		}
+
+		odds := make([]float64, len(market.Outcomes))
+		active := make([]bool, len(market.Outcomes))
+		for i, outcome := range market.Outcomes {
+			odds[i] = outcome.Odds
+			active[i] = outcome.Active == 1
+		}
+		if balance, valid := LineBalance(odds, active); valid && market.Status == 1 {
+			lines[market.Specifiers] = balance
+		} else {
+			delete(lines, market.Specifiers)
+		}
+	}
+
+	return t.mainLines(state, oddsChange)
+}
+
+// MainLines 返回 odds_change 涉及的盘口族当前的主盘口 (不更新状态)
+func (t *MainLineTracker) MainLines(oddsChange *uof.OddsChange) []MainLine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.mainLines(t.events[oddsChange.EventID], oddsChange)
+}
+
+// mainLines 调用方需持有 t.mu; 没有候选线的盘口族 specifier 为空
+func (t *MainLineTracker) mainLines(state *eventMainLines, oddsChange *uof.OddsChange) []MainLine {
+	result := make([]MainLine, 0)
+	seen := make(map[string]bool)
+	for _, market := range oddsChange.Odds.Markets {
+		family, _, isLine := SplitLineSpecifier(market.Specifiers)
+		if !isLine {
+			continue
+		}
+		key := mainLineFamilyKey(market.ID, family)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		line := MainLine{MarketID: market.ID, Family: family}
+		if state != nil {
+			line.Specifier = bestLine(state.families[key])
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// IsMainLine 判断盘口是否为当前主盘口 (不是盘口线市场时返回 false)
+func (t *MainLineTracker) IsMainLine(eventID string, marketID int, specifiers string) bool {
+	family, _, isLine := SplitLineSpecifier(specifiers)
+	if !isLine {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.events[eventID]
+	if !ok {
+		return false
+	}
+	return bestLine(state.families[mainLineFamilyKey(marketID, family)]) == specifiers
+}
+
+// bestLine 返回平衡度最小的线, 相同时取 specifiers 字典序最小的以保证稳定
+func bestLine(lines map[string]float64) string {
+	best := ""
+	bestBalance := 0.0
+	for specifiers, balance := range lines {
+		if best == "" || balance < bestBalance || (balance == bestBalance && specifiers < best) {
+			best = specifiers
+			bestBalance = balance
+		}
+	}
+	return best
+}
+
+// gc 清理长时间未更新的赛事 (调用方需持有 t.mu)
+func (t *MainLineTracker) gc(now time.Time) {
+	if now.Sub(t.lastGC) < t.maxIdle/4 {
+		return
+	}
+	for eventID, state := range t.events {
+		if now.Sub(state.updatedAt) > t.maxIdle {
+			delete(t.events, eventID)
+		}
+	}
+	t.lastGC = now
+}
+
+// updateMainLines 根据数据库中的当前赔率重新计算盘口族的主盘口并更新 markets.is_main_line
+// 盘口族中没有 active 且结果齐全的线时保持原有标记
+func updateMainLines(tx *sql.Tx, eventID, srMarketID string) error {
+	rows, err := tx.Query(`
+		SELECT m.id, COALESCE(m.specifiers, ''), COALESCE(m.status, ''), COALESCE(m.is_main_line, false),
+		       COALESCE(o.odds_value, 0), COALESCE(o.active, false)
+		FROM markets m
+		JOIN odds o ON o.market_id = m.id
+		WHERE m.event_id = $1 AND m.sr_market_id = $2
+		ORDER BY m.id
+	`, eventID, srMarketID)
+	if err != nil {
+		return fmt.Errorf("failed to query lines: %w", err)
+	}
+
+	type lineState struct {
+		id         int
+		specifiers string
+		family     string
+		status     string
+		isMain     bool
+		odds       []float64
+		active     []bool
+	}
+
+	lines := make([]*lineState, 0)
+	var current *lineState
+	for rows.Next() {
+		var id int
+		var specifiers, status string
+		var isMain, active bool
+		var odds float64
+		if err := rows.Scan(&id, &specifiers, &status, &isMain, &odds, &active); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan line: %w", err)
+		}
+		if current == nil || current.id != id {
+			family, _, isLine := SplitLineSpecifier(specifiers)
+			if !isLine {
+				current = &lineState{id: id}
+				continue
+			}
+			current = &lineState{id: id, specifiers: specifiers, family: family, status: status, isMain: isMain}
+			lines = append(lines, current)
+		}
+		if current.specifiers == "" {
+			continue
+		}
+		current.odds = append(current.odds, odds)
+		current.active = append(current.active, active)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	// 每个盘口族选出平衡度最小的线
+	balances := make(map[string]map[string]float64)
+	for _, line := range lines {
+		if line.status != "1" {
+			continue
+		}
+		balance, valid := LineBalance(line.odds, line.active)
+		if !valid {
+			continue
+		}
+		if balances[line.family] == nil {
+			balances[line.family] = make(map[string]float64)
+		}
+		balances[line.family][line.specifiers] = balance
+	}
+
+	for _, line := range lines {
+		familyLines, ok := balances[line.family]
+		if !ok {
+			continue
+		}
+		isMain := bestLine(familyLines) == line.specifiers
+		if isMain == line.isMain {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE markets SET is_main_line = $2 WHERE id = $1`, line.id, isMain); err != nil {
+			return fmt.Errorf("failed to update main line: %w", err)
+		}
+	}
+	return nil
+}
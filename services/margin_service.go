@@ -0,0 +1,368 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"uof-service/logger"
+	"uof-service/uof"
+)
+
+// 利润率模式
+const (
+	MarginModeTarget = "target" // 把盘口总返还率调整到 1 + margin (例如 0.05 -> 105%)
+	MarginModeAdd    = "add"    // 在收到的总返还率上再加 margin (例如 0.02 -> +2%)
+)
+
+// minPublishedOdds 发布赔率下限
+const minPublishedOdds = 1.01
+
+// MarginProfile 利润率配置
+// sport_id / tournament_id / sr_market_id 为空表示不限
+type MarginProfile struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	SportID      string    `json:"sport_id"`
+	TournamentID string    `json:"tournament_id"`
+	SRMarketID   string    `json:"sr_market_id"`
+	Mode         string    `json:"mode"`
+	Margin       float64   `json:"margin"`
+	MinOdds      *float64  `json:"min_odds"`
+	MaxOdds      *float64  `json:"max_odds"`
+	Priority     int       `json:"priority"`
+	Enabled      bool      `json:"enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// specificity 匹配精确度: 盘口 > 联赛 > 运动
+func (p *MarginProfile) specificity() int {
+	score := 0
+	if p.SRMarketID != "" {
+		score += 4
+	}
+	if p.TournamentID != "" {
+		score += 2
+	}
+	if p.SportID != "" {
+		score++
+	}
+	return score
+}
+
+// matches 判断配置是否适用于指定赛事和盘口
+func (p *MarginProfile) matches(meta EventMeta, marketID string) bool {
+	if !p.Enabled {
+		return false
+	}
+	if p.SportID != "" && p.SportID != meta.SportID {
+		return false
+	}
+	if p.TournamentID != "" && p.TournamentID != meta.TournamentID {
+		return false
+	}
+	if p.SRMarketID != "" && p.SRMarketID != marketID {
+		return false
+	}
+	return true
+}
+
+// MarginService 利润率服务
+// 配置缓存在内存中, 超过 ttl 后重新从数据库加载 (多个实例之间最多延迟 ttl 生效)
+type MarginService struct {
+	db        *sql.DB
+	eventMeta *EventMetaCache
+	ttl       time.Duration
+	profiles  []MarginProfile // 按精确度、优先级降序
+	loadedAt  time.Time
+	mu        sync.RWMutex
+}
+
+// NewMarginService 创建利润率服务
+func NewMarginService(db *sql.DB, eventMeta *EventMetaCache, ttl time.Duration) *MarginService {
+	return &MarginService{
+		db:        db,
+		eventMeta: eventMeta,
+		ttl:       ttl,
+	}
+}
+
+// Invalidate 清除缓存, 下次定价时重新加载
+func (s *MarginService) Invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// activeProfiles 获取启用的配置 (必要时重新加载)
+func (s *MarginService) activeProfiles() []MarginProfile {
+	s.mu.RLock()
+	if time.Since(s.loadedAt) < s.ttl {
+		profiles := s.profiles
+		s.mu.RUnlock()
+		return profiles
+	}
+	s.mu.RUnlock()
+
+	profiles, err := s.queryProfiles(true)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		// 加载失败时沿用旧配置, 稍后重试
+		logger.Errorf("[MarginService] Failed to load margin profiles: %v", err)
+		s.loadedAt = time.Now().Add(-s.ttl + 10*time.Second)
+		return s.profiles
+	}
+
+	sort.SliceStable(profiles, func(i, j int) bool {
+		si, sj := profiles[i].specificity(), profiles[j].specificity()
+		if si != sj {
+			return si > sj
+		}
+		return profiles[i].Priority > profiles[j].Priority
+	})
+	s.profiles = profiles
+	s.loadedAt = time.Now()
+	return profiles
+}
+
+// Match 查找适用的配置, 没有匹配时返回 nil
+func (s *MarginService) Match(meta EventMeta, marketID string) *MarginProfile {
+	for _, profile := range s.activeProfiles() {
+		if profile.matches(meta, marketID) {
+			p := profile
+			return &p
+		}
+	}
+	return nil
+}
+
+// Reprice 按配置重新定价一个盘口的全部结果
+// 使用 active 且赔率 > 1 的结果计算收到的总返还率 R = Σ 1/odds,
+// 目标返还率 T 由模式决定, 所有结果按 R/T 等比例缩放 (即隐含概率乘以 T/R),
+// 然后四舍五入到 2 位小数并限制在 [min_odds, max_odds] 内
+func (p *MarginProfile) Reprice(raw []float64, active []bool) []float64 {
+	published := make([]float64, len(raw))
+	copy(published, raw)
+
+	received := 0.0
+	for i, odds := range raw {
+		if active[i] && odds > 1 {
+			received += 1 / odds
+		}
+	}
+	if received <= 0 {
+		return published
+	}
+
+	target := 1 + p.Margin
+	if p.Mode == MarginModeAdd {
+		target = received + p.Margin
+	}
+	if target <= 0 {
+		return published
+	}
+
+	minOdds := minPublishedOdds
+	if p.MinOdds != nil && *p.MinOdds > minOdds {
+		minOdds = *p.MinOdds
+	}
+
+	factor := received / target
+	for i, odds := range raw {
+		if odds <= 1 {
+			continue
+		}
+		value := roundTo(odds*factor, 2)
+		if value < minOdds {
+			value = minOdds
+		}
+		if p.MaxOdds != nil && value > *p.MaxOdds {
+			value = *p.MaxOdds
+		}
+		published[i] = value
+	}
+	return published
+}
+
+// ApplyToOddsChange 对 odds_change 消息中的每个盘口应用利润率
+// 原始赔率保存在 Outcome.RawOdds; 返回每个盘口使用的配置 (与 Odds.Markets 对应, 未匹配为 nil)
+func (s *MarginService) ApplyToOddsChange(oddsChange *uof.OddsChange) []*MarginProfile {
+	meta := s.eventMeta.Get(oddsChange.EventID)
+	profiles := make([]*MarginProfile, len(oddsChange.Odds.Markets))
+	for mi := range oddsChange.Odds.Markets {
+		market := &oddsChange.Odds.Markets[mi]
+		raw := make([]float64, len(market.Outcomes))
+		active := make([]bool, len(market.Outcomes))
+		for i, outcome := range market.Outcomes {
+			raw[i] = outcome.Odds
+			active[i] = outcome.Active == 1
+			market.Outcomes[i].RawOdds = outcome.Odds
+		}
+
+		profile := s.Match(meta, strconv.Itoa(market.ID))
+		if profile == nil {
+			continue
+		}
+		profiles[mi] = profile
+		for i, odds := range profile.Reprice(raw, active) {
+			market.Outcomes[i].Odds = odds
+		}
+	}
+	return profiles
+}
+
+// Validate 校验配置
+func (p *MarginProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch p.Mode {
+	case MarginModeTarget:
+		if p.Margin <= -1 || p.Margin > 1 {
+			return fmt.Errorf("target margin must be between -1 and 1")
+		}
+	case MarginModeAdd:
+		if math.Abs(p.Margin) > 1 {
+			return fmt.Errorf("added margin must be between -1 and 1")
+		}
+	default:
+		return fmt.Errorf("mode must be '%s' or '%s'", MarginModeTarget, MarginModeAdd)
+	}
+	if p.MinOdds != nil && *p.MinOdds < 1 {
+		return fmt.Errorf("min_odds must be >= 1")
+	}
+	if p.MaxOdds != nil && *p.MaxOdds <= minPublishedOdds {
+		return fmt.Errorf("max_odds must be > %.2f", minPublishedOdds)
+	}
+	if p.MinOdds != nil && p.MaxOdds != nil && *p.MinOdds > *p.MaxOdds {
+		return fmt.Errorf("min_odds must not exceed max_odds")
+	}
+	return nil
+}
+
+const marginProfileColumns = `id, name, COALESCE(sport_id, ''), COALESCE(tournament_id, ''), COALESCE(sr_market_id, ''),
+	mode, margin, min_odds, max_odds, priority, enabled, created_at, updated_at`
+
+// queryProfiles 查询配置
+func (s *MarginService) queryProfiles(enabledOnly bool) ([]MarginProfile, error) {
+	query := `SELECT ` + marginProfileColumns + ` FROM margin_profiles`
+	if enabledOnly {
+		query += ` WHERE enabled = true`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query margin profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := make([]MarginProfile, 0)
+	for rows.Next() {
+		p, err := scanMarginProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+type marginProfileScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMarginProfile(row marginProfileScanner) (*MarginProfile, error) {
+	var p MarginProfile
+	var minOdds, maxOdds sql.NullFloat64
+	if err := row.Scan(&p.ID, &p.Name, &p.SportID, &p.TournamentID, &p.SRMarketID,
+		&p.Mode, &p.Margin, &minOdds, &maxOdds, &p.Priority, &p.Enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.MinOdds = nullFloatPtr(minOdds)
+	p.MaxOdds = nullFloatPtr(maxOdds)
+	return &p, nil
+}
+
+// ListProfiles 列出全部配置
+func (s *MarginService) ListProfiles() ([]MarginProfile, error) {
+	return s.queryProfiles(false)
+}
+
+// GetProfile 获取单个配置, 不存在时返回 nil
+func (s *MarginService) GetProfile(id int) (*MarginProfile, error) {
+	p, err := scanMarginProfile(s.db.QueryRow(`SELECT `+marginProfileColumns+` FROM margin_profiles WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query margin profile: %w", err)
+	}
+	return p, nil
+}
+
+// CreateProfile 创建配置
+func (s *MarginService) CreateProfile(p *MarginProfile) (*MarginProfile, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := scanMarginProfile(s.db.QueryRow(`
+		INSERT INTO margin_profiles (name, sport_id, tournament_id, sr_market_id, mode, margin, min_odds, max_odds, priority, enabled)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+		RETURNING `+marginProfileColumns,
+		p.Name, p.SportID, p.TournamentID, NormalizeMarketID(p.SRMarketID), p.Mode, p.Margin,
+		p.MinOdds, p.MaxOdds, p.Priority, p.Enabled,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create margin profile: %w", err)
+	}
+
+	s.Invalidate()
+	return created, nil
+}
+
+// UpdateProfile 更新配置, 不存在时返回 nil
+func (s *MarginService) UpdateProfile(id int, p *MarginProfile) (*MarginProfile, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanMarginProfile(s.db.QueryRow(`
+		UPDATE margin_profiles
+		SET name = $2, sport_id = NULLIF($3, ''), tournament_id = NULLIF($4, ''), sr_market_id = NULLIF($5, ''),
+		    mode = $6, margin = $7, min_odds = $8, max_odds = $9, priority = $10, enabled = $11, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+marginProfileColumns,
+		id, p.Name, p.SportID, p.TournamentID, NormalizeMarketID(p.SRMarketID), p.Mode, p.Margin,
+		p.MinOdds, p.MaxOdds, p.Priority, p.Enabled,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update margin profile: %w", err)
+	}
+
+	s.Invalidate()
+	return updated, nil
+}
+
+// DeleteProfile 删除配置, 返回是否存在
+func (s *MarginService) DeleteProfile(id int) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM margin_profiles WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete margin profile: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+
+	s.Invalidate()
+	return affected > 0, nil
+}
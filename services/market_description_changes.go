@@ -0,0 +1,492 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"uof-service/logger"
+)
+
+// 描述刷新后重新渲染名称: 每批更新的行数, 以及收到变化通知后等待合并的时间
+const (
+	descriptionRerenderBatch  = 500
+	descriptionChangeDebounce = time.Minute
+)
+
+// 盘口描述变化类型 (market_description_changes.change_type)
+const (
+	DescriptionChangeAdded      = "added"
+	DescriptionChangeRemoved    = "removed"
+	DescriptionChangeName       = "market_name"
+	DescriptionChangeGroups     = "groups"
+	DescriptionChangeSpecifiers = "specifiers"
+	DescriptionChangeOutcome    = "outcome"
+	DescriptionChangeMapping    = "mapping"
+)
+
+// MarketDescriptionChange 盘口描述的一项变化
+// outcome / mapping 变化的 OutcomeID 为对应的结果, 值为空表示新增或删除
+type MarketDescriptionChange struct {
+	ID         int64     `json:"id"`
+	MarketID   string    `json:"market_id"`
+	ChangeType string    `json:"change_type"`
+	OutcomeID  string    `json:"outcome_id,omitempty"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// descriptionIndex 市场描述索引, 刷新时整体替换
+type descriptionIndex struct {
+	markets  map[string]*MarketDescription
+	outcomes map[string]map[string]*OutcomeDescription // marketID -> outcomeID -> outcome
+	mappings map[string]map[string]string              // marketID -> outcomeID (URN) -> product_outcome_name
+}
+
+// renderScope 一个盘口需要重新渲染的名称
+type renderScope struct {
+	marketName  bool
+	allOutcomes bool
+	outcomeIDs  map[string]bool
+	groups      bool // markets.groups 需要同步
+}
+
+// newDescriptionIndex 由 API 返回的描述构建索引
+func newDescriptionIndex(markets []MarketDescription) descriptionIndex {
+	index := descriptionIndex{
+		markets:  make(map[string]*MarketDescription, len(markets)),
+		outcomes: make(map[string]map[string]*OutcomeDescription, len(markets)),
+		mappings: make(map[string]map[string]string, len(markets)),
+	}
+	for i := range markets {
+		market := &markets[i]
+		index.markets[market.ID] = market
+
+		index.outcomes[market.ID] = make(map[string]*OutcomeDescription, len(market.Outcomes))
+		for j := range market.Outcomes {
+			outcome := &market.Outcomes[j]
+			index.outcomes[market.ID][outcome.ID] = outcome
+		}
+
+		index.mappings[market.ID] = make(map[string]string)
+		for _, mapping := range market.Mappings {
+			for _, mappingOutcome := range mapping.Outcomes {
+				index.mappings[market.ID][mappingOutcome.OutcomeID] = mappingOutcome.ProductOutcomeName
+			}
+		}
+	}
+	return index
+}
+
+// diffDescriptions 比较新旧描述, 返回变化列表和每个盘口需要重新渲染的名称
+// 只在旧描述中出现的 mapping (按需加载的 variant 描述) 不视为删除
+func diffDescriptions(previous, current descriptionIndex) ([]MarketDescriptionChange, map[string]*renderScope) {
+	var changes []MarketDescriptionChange
+	scopes := make(map[string]*renderScope)
+	scope := func(marketID string) *renderScope {
+		if scopes[marketID] == nil {
+			scopes[marketID] = &renderScope{outcomeIDs: make(map[string]bool)}
+		}
+		return scopes[marketID]
+	}
+	record := func(marketID, changeType, outcomeID, oldValue, newValue string) {
+		changes = append(changes, MarketDescriptionChange{
+			MarketID:   marketID,
+			ChangeType: changeType,
+			OutcomeID:  outcomeID,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+		})
+	}
+
+	for marketID, market := range current.markets {
+		old, ok := previous.markets[marketID]
+		if !ok {
+			// 新盘口: 之前入库的行使用的是 "Market X" 之类的兜底名称
+			record(marketID, DescriptionChangeAdded, "", "", market.Name)
+			scopes[marketID] = &renderScope{marketName: true, allOutcomes: true, groups: market.Groups != ""}
+			continue
+		}
+
+		if old.Name != market.Name {
+			record(marketID, DescriptionChangeName, "", old.Name, market.Name)
+			scope(marketID).marketName = true
+		}
+		if old.Groups != market.Groups {
+			record(marketID, DescriptionChangeGroups, "", old.Groups, market.Groups)
+			scope(marketID).groups = true
+		}
+		if oldSpecifiers, newSpecifiers := specifierSignature(old.Specifiers), specifierSignature(market.Specifiers); oldSpecifiers != newSpecifiers {
+			record(marketID, DescriptionChangeSpecifiers, "", oldSpecifiers, newSpecifiers)
+		}
+
+		for outcomeID, outcome := range current.outcomes[marketID] {
+			oldName := ""
+			if oldOutcome, ok := previous.outcomes[marketID][outcomeID]; ok {
+				oldName = oldOutcome.Name
+			}
+			if oldName != outcome.Name {
+				record(marketID, DescriptionChangeOutcome, outcomeID, oldName, outcome.Name)
+				scope(marketID).outcomeIDs[outcomeID] = true
+			}
+		}
+		for outcomeID, oldOutcome := range previous.outcomes[marketID] {
+			if _, ok := current.outcomes[marketID][outcomeID]; !ok {
+				record(marketID, DescriptionChangeOutcome, outcomeID, oldOutcome.Name, "")
+			}
+		}
+
+		for outcomeID, name := range current.mappings[marketID] {
+			if oldName := previous.mappings[marketID][outcomeID]; oldName != name {
+				record(marketID, DescriptionChangeMapping, outcomeID, oldName, name)
+				scope(marketID).outcomeIDs[outcomeID] = true
+			}
+		}
+	}
+
+	for marketID, old := range previous.markets {
+		if _, ok := current.markets[marketID]; !ok {
+			record(marketID, DescriptionChangeRemoved, "", old.Name, "")
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].MarketID < changes[j].MarketID })
+	return changes, scopes
+}
+
+// specifierSignature 把 specifier 列表转换为可比较的字符串 (name:type|...)
+func specifierSignature(specifiers []SpecifierDescription) string {
+	parts := make([]string, 0, len(specifiers))
+	for _, specifier := range specifiers {
+		parts = append(parts, specifier.Name+":"+specifier.Type)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+// keepVariantMappings 把旧描述中按需加载的 variant 结果 (新描述中没有) 保留到当前索引
+// 调用方需持有 s.mu
+func (s *MarketDescriptionsService) keepVariantMappings(previous map[string]map[string]string) {
+	for marketID, outcomes := range previous {
+		for outcomeID, name := range outcomes {
+			if _, ok := s.mappings[marketID][outcomeID]; ok || !strings.HasPrefix(outcomeID, "sr:") {
+				continue
+			}
+			if s.mappings[marketID] == nil {
+				s.mappings[marketID] = make(map[string]string)
+			}
+			s.mappings[marketID][outcomeID] = name
+		}
+	}
+}
+
+// applyDescriptionChanges 记录描述变化并在后台重新渲染受影响的盘口 / 结果名称
+func (s *MarketDescriptionsService) applyDescriptionChanges(changes []MarketDescriptionChange, scopes map[string]*renderScope) {
+	if len(changes) == 0 {
+		logger.Println("[MarketDescService] No market description changes")
+		return
+	}
+	logger.Printf("[MarketDescService] Market descriptions changed: %d changes in %d markets to re-render", len(changes), len(scopes))
+
+	if s.db == nil {
+		return
+	}
+	if err := s.saveDescriptionChanges(changes); err != nil {
+		logger.Printf("[MarketDescService] ⚠️  Failed to save description changes: %v", err)
+	}
+	if len(scopes) > 0 {
+		go s.rerenderNames(scopes)
+	}
+}
+
+// saveDescriptionChanges 保存描述变化到 market_description_changes
+func (s *MarketDescriptionsService) saveDescriptionChanges(changes []MarketDescriptionChange) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO market_description_changes (market_id, change_type, outcome_id, old_value, new_value)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare change statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, change := range changes {
+		if _, err := stmt.Exec(change.MarketID, change.ChangeType, change.OutcomeID, change.OldValue, change.NewValue); err != nil {
+			return fmt.Errorf("failed to insert change for market %s: %w", change.MarketID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDescriptionChanges 查询描述变化记录 (marketID 为空时返回所有盘口), 按时间倒序
+func (s *MarketDescriptionsService) GetDescriptionChanges(marketID string, limit int) ([]MarketDescriptionChange, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, market_id, change_type, COALESCE(outcome_id, ''), COALESCE(old_value, ''), COALESCE(new_value, ''), created_at
+		FROM market_description_changes
+		WHERE $1 = '' OR market_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, marketID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query description changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]MarketDescriptionChange, 0)
+	for rows.Next() {
+		var change MarketDescriptionChange
+		if err := rows.Scan(&change.ID, &change.MarketID, &change.ChangeType, &change.OutcomeID, &change.OldValue, &change.NewValue, &change.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan description change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// HandleDescriptionsChanged 处理 feed 的描述变化通知: 合并一段时间内的通知后重新加载并比较描述
+func (s *MarketDescriptionsService) HandleDescriptionsChanged(marketIDs []string) {
+	logger.Printf("[MarketDescService] Market descriptions changed notification (markets: %v)", marketIDs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.refreshPending {
+		return
+	}
+	s.refreshPending = true
+
+	time.AfterFunc(descriptionChangeDebounce, func() {
+		s.mu.Lock()
+		s.refreshPending = false
+		s.mu.Unlock()
+
+		if err := s.loadMarketDescriptions(); err != nil {
+			logger.Printf("[MarketDescService] ⚠️  Failed to refresh after change notification: %v", err)
+		}
+		s.loadTranslations(s.localization.ExtraLanguages())
+	})
+}
+
+// rerenderNames 重新渲染受影响盘口的 markets.market_name 和 odds.outcome_name (分批更新)
+func (s *MarketDescriptionsService) rerenderNames(scopes map[string]*renderScope) {
+	s.rerenderMu.Lock()
+	defer s.rerenderMu.Unlock()
+
+	marketIDs := make([]string, 0, len(scopes))
+	for marketID := range scopes {
+		marketIDs = append(marketIDs, marketID)
+	}
+	sort.Strings(marketIDs)
+
+	totalMarkets, totalOutcomes := 0, 0
+	for _, marketID := range marketIDs {
+		scope := scopes[marketID]
+		if scope.groups {
+			if err := s.syncMarketGroups(marketID); err != nil {
+				logger.Printf("[MarketDescService] ⚠️  Failed to sync groups for %s: %v", marketID, err)
+			}
+		}
+		if scope.marketName {
+			count, err := s.rerenderMarketNames(marketID)
+			if err != nil {
+				logger.Printf("[MarketDescService] ⚠️  Failed to re-render market names for %s: %v", marketID, err)
+			}
+			totalMarkets += count
+		}
+		if scope.allOutcomes || len(scope.outcomeIDs) > 0 {
+			var outcomeIDs []string
+			if !scope.allOutcomes {
+				for outcomeID := range scope.outcomeIDs {
+					outcomeIDs = append(outcomeIDs, outcomeID)
+				}
+			}
+			count, err := s.rerenderOutcomeNames(marketID, outcomeIDs)
+			if err != nil {
+				logger.Printf("[MarketDescService] ⚠️  Failed to re-render outcome names for %s: %v", marketID, err)
+			}
+			totalOutcomes += count
+		}
+	}
+
+	logger.Printf("[MarketDescService] ✅ Re-rendered %d market names and %d outcome names for %d changed markets", totalMarkets, totalOutcomes, len(marketIDs))
+}
+
+// syncMarketGroups 分批把盘口的 markets.groups 更新为当前描述中的组
+func (s *MarketDescriptionsService) syncMarketGroups(marketID string) error {
+	groups := s.GetMarketGroups(marketID)
+	for {
+		result, err := s.db.Exec(`
+			UPDATE markets SET groups = NULLIF($1, '')
+			WHERE id IN (
+				SELECT id FROM markets
+				WHERE sr_market_id = $2 AND COALESCE(groups, '') != $1
+				LIMIT $3
+			)
+		`, groups, marketID, descriptionRerenderBatch)
+		if err != nil {
+			return fmt.Errorf("failed to update market groups: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n < int64(descriptionRerenderBatch) {
+			return nil
+		}
+	}
+}
+
+// eventRenderContext 由 tracked_events 的参赛方 / 主客队构造名称模板上下文
+func eventRenderContext(competitorsJSON, homeTeamName, awayTeamName sql.NullString, specifiers string) *ReplacementContext {
+	var competitors []EventCompetitor
+	if competitorsJSON.Valid {
+		json.Unmarshal([]byte(competitorsJSON.String), &competitors)
+	}
+	ctx := NewReplacementContext(competitors, specifiers)
+	if ctx.HomeTeamName == "" {
+		ctx.HomeTeamName = homeTeamName.String
+	}
+	if ctx.AwayTeamName == "" {
+		ctx.AwayTeamName = awayTeamName.String
+	}
+	return ctx
+}
+
+// rerenderMarketNames 分批重新渲染一个盘口的所有 markets.market_name
+func (s *MarketDescriptionsService) rerenderMarketNames(marketID string) (int, error) {
+	updated, lastID := 0, 0
+	for {
+		rows, err := s.db.Query(`
+			SELECT m.id, COALESCE(m.specifiers, ''), COALESCE(m.market_name, ''),
+				te.competitors, COALESCE(te.home_team_name, m.home_team_name), COALESCE(te.away_team_name, m.away_team_name)
+			FROM markets m
+			LEFT JOIN tracked_events te ON te.event_id = m.event_id
+			WHERE m.sr_market_id = $1 AND m.id > $2
+			ORDER BY m.id
+			LIMIT $3
+		`, marketID, lastID, descriptionRerenderBatch)
+		if err != nil {
+			return updated, fmt.Errorf("failed to query markets: %w", err)
+		}
+
+		type row struct {
+			id   int
+			name string
+		}
+		var batch []row
+		count := 0
+		for rows.Next() {
+			var id int
+			var specifiers, currentName string
+			var competitors, homeTeamName, awayTeamName sql.NullString
+			if err := rows.Scan(&id, &specifiers, &currentName, &competitors, &homeTeamName, &awayTeamName); err != nil {
+				continue
+			}
+			count++
+			lastID = id
+
+			name := s.GetMarketName(marketID, specifiers, eventRenderContext(competitors, homeTeamName, awayTeamName, specifiers))
+			if name != currentName {
+				batch = append(batch, row{id: id, name: name})
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return updated, fmt.Errorf("error iterating markets: %w", err)
+		}
+
+		for _, r := range batch {
+			if _, err := s.db.Exec("UPDATE markets SET market_name = $1 WHERE id = $2", r.name, r.id); err != nil {
+				return updated, fmt.Errorf("failed to update market %d: %w", r.id, err)
+			}
+			updated++
+		}
+
+		if count < descriptionRerenderBatch {
+			return updated, nil
+		}
+	}
+}
+
+// rerenderOutcomeNames 分批重新渲染一个盘口的 odds.outcome_name (outcomeIDs 为空时渲染所有结果)
+// 找不到描述的参赛方结果 (outright) 保留入库时的名称
+func (s *MarketDescriptionsService) rerenderOutcomeNames(marketID string, outcomeIDs []string) (int, error) {
+	filter := ""
+	args := []interface{}{marketID, 0, descriptionRerenderBatch}
+	if len(outcomeIDs) > 0 {
+		placeholders := make([]string, len(outcomeIDs))
+		for i, outcomeID := range outcomeIDs {
+			args = append(args, outcomeID)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		filter = " AND o.outcome_id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	updated := 0
+	for {
+		rows, err := s.db.Query(`
+			SELECT o.id, o.outcome_id, COALESCE(o.outcome_name, ''), COALESCE(m.specifiers, ''),
+				te.competitors, COALESCE(te.home_team_name, m.home_team_name), COALESCE(te.away_team_name, m.away_team_name)
+			FROM odds o
+			JOIN markets m ON o.market_id = m.id
+			LEFT JOIN tracked_events te ON te.event_id = m.event_id
+			WHERE m.sr_market_id = $1 AND o.id > $2`+filter+`
+			ORDER BY o.id
+			LIMIT $3
+		`, args...)
+		if err != nil {
+			return updated, fmt.Errorf("failed to query odds: %w", err)
+		}
+
+		type row struct {
+			id   int
+			name string
+		}
+		var batch []row
+		count := 0
+		for rows.Next() {
+			var id int
+			var outcomeID, currentName, specifiers string
+			var competitors, homeTeamName, awayTeamName sql.NullString
+			if err := rows.Scan(&id, &outcomeID, &currentName, &specifiers, &competitors, &homeTeamName, &awayTeamName); err != nil {
+				continue
+			}
+			count++
+			args[1] = id
+
+			name := s.GetOutcomeName(marketID, outcomeID, specifiers, eventRenderContext(competitors, homeTeamName, awayTeamName, specifiers))
+			if name == outcomeID && currentName != "" {
+				continue
+			}
+			if name != currentName {
+				batch = append(batch, row{id: id, name: name})
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return updated, fmt.Errorf("error iterating odds: %w", err)
+		}
+
+		for _, r := range batch {
+			if _, err := s.db.Exec("UPDATE odds SET outcome_name = $1 WHERE id = $2", r.name, r.id); err != nil {
+				return updated, fmt.Errorf("failed to update outcome %d: %w", r.id, err)
+			}
+			updated++
+		}
+
+		if count < descriptionRerenderBatch {
+			return updated, nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import "testing"
+
+func TestDiffDescriptions(t *testing.T) {
+	previous := newDescriptionIndex([]MarketDescription{
+		{ID: "1", Name: "1x2", Outcomes: []OutcomeDescription{{ID: "1", Name: "{$competitor1}"}, {ID: "2", Name: "draw"}}},
+		{ID: "18", Name: "Total", Groups: "all|score"},
+		{ID: "99", Name: "Removed market"},
+	})
+	previous.mappings["1"]["sr:variant:1"] = "variant outcome"
+
+	current := newDescriptionIndex([]MarketDescription{
+		{ID: "1", Name: "1x2", Outcomes: []OutcomeDescription{{ID: "1", Name: "{$competitor1}"}, {ID: "2", Name: "Draw"}}},
+		{ID: "18", Name: "Total {total}", Groups: "all|score|regular_play",
+			Specifiers: []SpecifierDescription{{Name: "total", Type: "decimal"}}},
+		{ID: "534", Name: "Championship free text market"},
+	})
+
+	changes, scopes := diffDescriptions(previous, current)
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.MarketID+"/"+change.ChangeType]++
+	}
+	want := map[string]int{
+		"1/" + DescriptionChangeOutcome:     1,
+		"18/" + DescriptionChangeName:       1,
+		"18/" + DescriptionChangeGroups:     1,
+		"18/" + DescriptionChangeSpecifiers: 1,
+		"534/" + DescriptionChangeAdded:     1,
+		"99/" + DescriptionChangeRemoved:    1,
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("changes[%s] = %d, want %d", key, counts[key], n)
+		}
+	}
+
+	if len(scopes) != 3 {
+		t.Fatalf("scopes = %v, want markets 1, 18 and 534", scopes)
+	}
+	if s := scopes["1"]; s.marketName || s.allOutcomes || !s.outcomeIDs["2"] || len(s.outcomeIDs) != 1 {
+		t.Errorf("scope 1 = %+v, want outcome 2 only", s)
+	}
+	if s := scopes["18"]; !s.marketName || !s.groups || len(s.outcomeIDs) != 0 {
+		t.Errorf("scope 18 = %+v, want market name and groups only", s)
+	}
+	if s := scopes["534"]; !s.marketName || !s.allOutcomes {
+		t.Errorf("scope 534 = %+v, want everything", s)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"uof-service/logger"
+)
+
+// 其它语言的市场描述 (market_description_translations 表)
+// 只保存名称模板, specifiers / mappings 结构仍以英文描述为准
+
+// translationKey 翻译表的 key: 盘口名称为 marketID, 结果名称为 marketID/outcomeID
+func translationKey(marketID, outcomeID string) string {
+	if outcomeID == "" {
+		return marketID
+	}
+	return marketID + "/" + outcomeID
+}
+
+// contextLang 返回名称模板上下文的语言
+func contextLang(ctx *ReplacementContext) string {
+	if ctx == nil || ctx.Lang == "" {
+		return DefaultLanguage
+	}
+	return ctx.Lang
+}
+
+// translation 返回盘口 / 结果名称模板的翻译, 没有时返回空字符串
+func (s *MarketDescriptionsService) translation(lang, marketID, outcomeID string) string {
+	if lang == DefaultLanguage {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.translationLocked(lang, marketID, outcomeID)
+}
+
+// translationLocked 同 translation (调用方需持有 s.mu)
+func (s *MarketDescriptionsService) translationLocked(lang, marketID, outcomeID string) string {
+	if lang == DefaultLanguage {
+		return ""
+	}
+	return s.translations[lang][translationKey(marketID, outcomeID)]
+}
+
+// startTranslations 加载其它语言的市场描述: 优先使用数据库缓存, 缺少的语言在后台从 API 加载
+func (s *MarketDescriptionsService) startTranslations() {
+	languages := s.localization.ExtraLanguages()
+	if len(languages) == 0 {
+		return
+	}
+
+	var missing []string
+	for _, lang := range languages {
+		if s.db != nil {
+			if err := s.loadTranslationsFromDatabase(lang); err == nil {
+				continue
+			}
+		}
+		missing = append(missing, lang)
+	}
+
+	if len(missing) > 0 {
+		go s.loadTranslations(missing)
+	}
+}
+
+// loadTranslationsFromDatabase 从数据库加载一种语言的市场描述
+func (s *MarketDescriptionsService) loadTranslationsFromDatabase(lang string) error {
+	rows, err := s.db.Query(`
+		SELECT market_id, outcome_id, name
+		FROM market_description_translations
+		WHERE lang = $1
+	`, lang)
+	if err != nil {
+		return fmt.Errorf("failed to query translations: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var marketID, outcomeID, name string
+		if err := rows.Scan(&marketID, &outcomeID, &name); err != nil {
+			continue
+		}
+		names[translationKey(marketID, outcomeID)] = name
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating translations: %w", err)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no %s translations in database", lang)
+	}
+
+	s.mu.Lock()
+	s.translations[lang] = names
+	s.mu.Unlock()
+
+	logger.Printf("[MarketDescService] ✅ Loaded %d %s market translations from database", len(names), lang)
+	return nil
+}
+
+// loadTranslations 从 API 加载多种语言的市场描述
+func (s *MarketDescriptionsService) loadTranslations(languages []string) {
+	for _, lang := range languages {
+		if err := s.loadTranslation(lang); err != nil {
+			logger.Printf("[MarketDescService] ⚠️  Failed to load %s market descriptions: %v", lang, err)
+		}
+	}
+}
+
+// loadTranslation 从 API 加载一种语言的市场描述并保存到数据库
+func (s *MarketDescriptionsService) loadTranslation(lang string) error {
+	apiBase := strings.TrimSuffix(s.apiBaseURL, "/v1")
+	url := fmt.Sprintf("%s/v1/descriptions/%s/markets.xml?include_mappings=true", apiBase, lang)
+
+	logger.Printf("[MarketDescService] Fetching %s market descriptions from: %s", lang, url)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-access-token", s.token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response MarketDescriptionsResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	names := make(map[string]string)
+	for _, market := range response.Markets {
+		names[translationKey(market.ID, "")] = market.Name
+		for _, outcome := range market.Outcomes {
+			names[translationKey(market.ID, outcome.ID)] = outcome.Name
+		}
+		for _, mapping := range market.Mappings {
+			for _, mappingOutcome := range mapping.Outcomes {
+				if mappingOutcome.ProductOutcomeName != "" {
+					names[translationKey(market.ID, mappingOutcome.OutcomeID)] = mappingOutcome.ProductOutcomeName
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.translations[lang] = names
+	s.mu.Unlock()
+
+	logger.Printf("[MarketDescService] ✅ Loaded %d %s market translations from API", len(names), lang)
+
+	if err := s.saveTranslations(lang, names); err != nil {
+		logger.Printf("[MarketDescService] ⚠️  Failed to save %s translations: %v", lang, err)
+	}
+	return nil
+}
+
+// saveTranslations 保存一种语言的市场描述到数据库 (替换该语言的旧数据)
+func (s *MarketDescriptionsService) saveTranslations(lang string, names map[string]string) error {
+	if s.db == nil {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM market_description_translations WHERE lang = $1", lang); err != nil {
+		return fmt.Errorf("failed to clear translations: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO market_description_translations (market_id, outcome_id, lang, name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (market_id, outcome_id, lang) DO UPDATE SET name = EXCLUDED.name
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare translation statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, name := range names {
+		marketID, outcomeID := key, ""
+		if i := strings.Index(key, "/"); i >= 0 {
+			marketID, outcomeID = key[:i], key[i+1:]
+		}
+		if _, err := stmt.Exec(marketID, outcomeID, lang, name); err != nil {
+			return fmt.Errorf("failed to insert translation %s: %w", key, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -16,9 +16,13 @@ import (
 
 // ReplacementContext 变量替换所需的上下文信息
 type ReplacementContext struct {
-	HomeTeamName string
-	AwayTeamName string
-	Specifiers   string // 原始 specifiers 字符串
+	HomeTeamName    string
+	AwayTeamName    string
+	EventName       string            // {$event}, 为空时使用 "主队 vs 客队"
+	Specifiers      string            // 原始 specifiers 字符串
+	Competitors     map[string]string // 非对阵赛事的参赛方 (competitor URN -> 名称)
+	CompetitorNames []string          // 按顺序的参赛方名称, 用于 {$competitorN} (N > 2 或没有主客队时)
+	Lang            string            // 名称语言, 为空或 en 时使用英文描述
 }
 
 // MarketDescriptionsService 市场描述服务
@@ -27,11 +31,15 @@ type MarketDescriptionsService struct {
 	apiBaseURL     string
 	db             *sql.DB // 可选的数据库连接
 	playersService *PlayersService // 球员信息服务
+	localization   *LocalizationService // 多语言名称服务
 	markets        map[string]*MarketDescription
 	outcomes       map[string]map[string]*OutcomeDescription // marketID -> outcomeID -> outcome
 	mappings       map[string]map[string]string              // marketID -> outcomeID (URN) -> product_outcome_name
+	translations   map[string]map[string]string              // lang -> translationKey(marketID, outcomeID) -> 名称模板
 	mu             sync.RWMutex
 	lastUpdated    time.Time
+	refreshPending bool       // 已收到描述变化通知, 等待重新加载
+	rerenderMu     sync.Mutex // 同一时间只运行一次名称重新渲染
 }
 
 // MarketDescription 市场描述
@@ -87,6 +95,7 @@ func NewMarketDescriptionsService(token string, apiBaseURL string) *MarketDescri
 		markets:    make(map[string]*MarketDescription),
 		outcomes:   make(map[string]map[string]*OutcomeDescription),
 		mappings:   make(map[string]map[string]string),
+		translations: make(map[string]map[string]string),
 	}
 }
 
@@ -100,10 +109,18 @@ func (s *MarketDescriptionsService) SetPlayersService(playersService *PlayersSer
 	s.playersService = playersService
 }
 
+// SetLocalizationService 设置多语言名称服务 (可选, 设置后加载其它语言的市场描述)
+func (s *MarketDescriptionsService) SetLocalizationService(localization *LocalizationService) {
+	s.localization = localization
+}
+
 // Start 启动服务并加载市场描述
 func (s *MarketDescriptionsService) Start() error {
 	logger.Println("[MarketDescService] Starting Market Descriptions Service...")
 	
+	// 其它语言的市场描述
+	s.startTranslations()
+	
 	// 如果有数据库,优先从数据库加载
 	if s.db != nil {
 		err := s.loadFromDatabase()
@@ -376,35 +393,23 @@ func (s *MarketDescriptionsService) loadMarketDescriptions() error {
 		return fmt.Errorf("failed to parse XML: %w", err)
 	}
 	
-	s.mu.Lock()
-	s.markets = make(map[string]*MarketDescription)
-	s.outcomes = make(map[string]map[string]*OutcomeDescription)
-	s.mappings = make(map[string]map[string]string)
-	
-	for i := range response.Markets {
-		market := &response.Markets[i]
-		s.markets[market.ID] = market
-		
-		// 索引 outcomes
-		s.outcomes[market.ID] = make(map[string]*OutcomeDescription)
-		for j := range market.Outcomes {
-			outcome := &market.Outcomes[j]
-			s.outcomes[market.ID][outcome.ID] = outcome
-		}
-		
-		// 索引 mappings
-		s.mappings[market.ID] = make(map[string]string)
-		for _, mapping := range market.Mappings {
-			for _, mappingOutcome := range mapping.Outcomes {
-				// 使用 URN 作为 key
-				s.mappings[market.ID][mappingOutcome.OutcomeID] = mappingOutcome.ProductOutcomeName
-			}
-		}
-	}
+	// 与当前描述比较, 只重新渲染变化的盘口
+	index := newDescriptionIndex(response.Markets)
 	
+	s.mu.Lock()
+	previous := descriptionIndex{markets: s.markets, outcomes: s.outcomes, mappings: s.mappings}
+	s.markets = index.markets
+	s.outcomes = index.outcomes
+	s.mappings = index.mappings
+	s.keepVariantMappings(previous.mappings)
 	s.lastUpdated = time.Now()
 	s.mu.Unlock()
 	
+	// 首次加载 (没有旧描述) 不比较
+	if len(previous.markets) > 0 {
+		s.applyDescriptionChanges(diffDescriptions(previous, index))
+	}
+	
 	// 统计 mappings 数量
 	totalMappings := 0
 	for _, outcomes := range s.mappings {
@@ -432,48 +437,40 @@ func (s *MarketDescriptionsService) refreshLoop() {
 		if err := s.loadMarketDescriptions(); err != nil {
 			logger.Printf("[MarketDescService] ⚠️  Failed to refresh: %v", err)
 		}
+		s.loadTranslations(s.localization.ExtraLanguages())
 	}
 }
 
 // GetMarketName 获取市场名称
 func (s *MarketDescriptionsService) GetMarketName(marketID string, specifiers string, ctx *ReplacementContext) string {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
 	market, ok := s.markets[marketID]
+	s.mu.RUnlock()
+	
 	if !ok {
 		// 记录警告: market 不存在于 API 数据中
 		logger.Printf("[⚠️  MarketDescService] Market not found in API data: marketID=%s", marketID)
 		return fmt.Sprintf("Market %s", marketID)
 	}
 	
-	name := market.Name
-	
-	// 替换变量
-	if ctx != nil {
-		name = strings.ReplaceAll(name, "$competitor1", ctx.HomeTeamName)
-		name = strings.ReplaceAll(name, "$competitor2", ctx.AwayTeamName)
-		name = strings.ReplaceAll(name, "{$competitor1}", ctx.HomeTeamName)
-		name = strings.ReplaceAll(name, "{$competitor2}", ctx.AwayTeamName)
+	// 其它语言使用对应的名称模板, 没有翻译时使用英文
+	template := market.Name
+	if translated := s.translation(contextLang(ctx), marketID, ""); translated != "" {
+		template = translated
 	}
 	
-	// 替换 specifiers
-	if specifiers != "" {
-		pairs := strings.Split(specifiers, "|")
-		for _, pair := range pairs {
-			parts := strings.Split(pair, "=")
-			if len(parts) == 2 {
-				key := parts[0]
-				value := parts[1]
-				name = strings.ReplaceAll(name, "{"+key+"}", value)
-				name = strings.ReplaceAll(name, "{+"+key+"}", "+"+value)
-				name = strings.ReplaceAll(name, "{-"+key+"}", "-"+value)
-					name = strings.ReplaceAll(name, "{!"+key+"}", value)
-			}
+	// 渲染名称模板 (specifiers / 序数 / 符号 / 参赛方 / 球员)
+	return RenderNameTemplate(template, ParseSpecifiers(specifiers), ctx, s.profileResolver(contextLang(ctx)))
+}
+
+// profileResolver 返回把名称模板 {%X} 中的 URN 转换为指定语言名称的函数 (球员 / 参赛方)
+func (s *MarketDescriptionsService) profileResolver(lang string) func(urn string) string {
+	return func(urn string) string {
+		if strings.HasPrefix(urn, "sr:player:") && s.playersService != nil {
+			return s.playersService.GetLocalizedPlayerName(urn, lang)
 		}
+		return s.localization.Localize(urn, lang, "")
 	}
-	
-	return name
 }
 
 // GetOutcomeName 获取结果名称
@@ -481,13 +478,25 @@ func (s *MarketDescriptionsService) GetOutcomeName(marketID string, outcomeID st
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
+	lang := contextLang(ctx)
+	
 	// 优先从 mappings 中查询 (处理 URN 格式的 outcome_id)
 	if mappings, ok := s.mappings[marketID]; ok {
 		if productOutcomeName, ok := mappings[outcomeID]; ok {
+			if translated := s.translationLocked(lang, marketID, outcomeID); translated != "" {
+				return translated
+			}
 			return productOutcomeName
 		}
 	}
 	
+	// 非对阵赛事 (outright) 的结果是参赛方 URN, 直接使用赛事的参赛方名称
+	if ctx != nil {
+		if name, ok := ctx.Competitors[outcomeID]; ok {
+			return name
+		}
+	}
+	
 	// 如果 mappings 中没有,且 outcomeID 是 URN 格式,尝试动态加载 variant
 	if strings.HasPrefix(outcomeID, "sr:") && specifiers != "" {
 		// 提取 variant 从 specifiers
@@ -514,14 +523,15 @@ func (s *MarketDescriptionsService) GetOutcomeName(marketID string, outcomeID st
 	// 降级: 尝试从 outcomes 中查找
 	if outcomes, ok := s.outcomes[marketID]; ok {
 		if outcome, ok := outcomes[outcomeID]; ok {
-			name := outcome.Name
-			// 替换变量
-			if ctx != nil {
-				name = strings.ReplaceAll(name, "$competitor1", ctx.HomeTeamName)
-				name = strings.ReplaceAll(name, "$competitor2", ctx.AwayTeamName)
-				name = strings.ReplaceAll(name, "{$competitor1}", ctx.HomeTeamName)
-				name = strings.ReplaceAll(name, "{$competitor2}", ctx.AwayTeamName)
+			template := outcome.Name
+			if translated := s.translationLocked(lang, marketID, outcomeID); translated != "" {
+				template = translated
 			}
+			
+			// 渲染名称模板 (球员查询可能请求 API, 先解锁)
+			s.mu.RUnlock()
+			name := RenderNameTemplate(template, ParseSpecifiers(specifiers), ctx, s.profileResolver(lang))
+			s.mu.RLock()
 			return name
 		}
 	}
@@ -532,7 +542,7 @@ func (s *MarketDescriptionsService) GetOutcomeName(marketID string, outcomeID st
 		if s.playersService != nil {
 			// 解锁以调用 playersService
 			s.mu.RUnlock()
-			playerName := s.playersService.GetPlayerName(outcomeID)
+			playerName := s.playersService.GetLocalizedPlayerName(outcomeID, lang)
 			s.mu.RLock()
 			
 			// GetPlayerName 总是返回一个值,如果找不到会返回 "Player {id}"
@@ -649,6 +659,17 @@ func (s *MarketDescriptionsService) GetMarketSpecifiers(marketID string) []Speci
 	return nil
 }
 
+// GetMarketGroups 获取市场所属的盘口组 (例如 "all|score|regular_play"), 没有描述时返回空
+func (s *MarketDescriptionsService) GetMarketGroups(marketID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	if market, ok := s.markets[marketID]; ok {
+		return market.Groups
+	}
+	return ""
+}
+
 // UpdateAllMarketAndOutcomeNames 批量更新所有 market 和 outcome 的名称
 func (s *MarketDescriptionsService) UpdateAllMarketAndOutcomeNames() error {
 	if s.db == nil {
@@ -714,10 +735,16 @@ func (s *MarketDescriptionsService) GetStatus() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
+	translationCounts := make(map[string]int, len(s.translations))
+	for lang, names := range s.translations {
+		translationCounts[lang] = len(names)
+	}
+	
 	return map[string]interface{}{
 		"market_count":  len(s.markets),
 		"outcome_count": s.countOutcomes(),
 		"mapping_count": s.countMappings(),
+		"translation_counts": translationCounts,
 		"last_updated":  s.lastUpdated.Format(time.RFC3339),
 		"status":        "running",
 	}
@@ -758,6 +785,9 @@ func (s *MarketDescriptionsService) ForceRefresh() error {
 		}
 	}
 	
+	// 其它语言的市场描述
+	s.loadTranslations(s.localization.ExtraLanguages())
+	
 	logger.Println("[MarketDescriptions] ✅ Force refresh completed")
 	return nil
 }
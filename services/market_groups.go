@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MarketGroupAll 所有盘口都属于的组 (bet_stop 中 groups="all" 表示全部盘口)
+const MarketGroupAll = "all"
+
+// MarketGroupTab 按盘口组划分的标签页
+type MarketGroupTab struct {
+	Group       string `json:"group"`
+	MarketCount int    `json:"market_count"`
+}
+
+// SplitMarketGroups 解析 "all|score|regular_play" 格式的盘口组
+func SplitMarketGroups(groups string) []string {
+	var result []string
+	for _, group := range strings.Split(groups, "|") {
+		if group = strings.TrimSpace(group); group != "" {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// HasMarketGroup 判断盘口组列表是否包含指定组 (没有组信息的盘口只属于 "all")
+func HasMarketGroup(groups []string, group string) bool {
+	if group == "" || group == MarketGroupAll {
+		return true
+	}
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// MarketGroupCondition 返回按盘口组筛选的 SQL 条件, column 为 "|" 分隔的 groups 列
+func MarketGroupCondition(column string, argIndex int) string {
+	return fmt.Sprintf("$%d = ANY(string_to_array(%s, '|'))", argIndex, column)
+}
+
+// BuildMarketGroupTabs 统计每个组的盘口数量, "all" 在最前, 其余按盘口数量降序
+func BuildMarketGroupTabs(markets []MarketInfo) []MarketGroupTab {
+	counts := map[string]int{MarketGroupAll: len(markets)}
+	for _, market := range markets {
+		for _, group := range market.Groups {
+			if group != MarketGroupAll {
+				counts[group]++
+			}
+		}
+	}
+
+	tabs := make([]MarketGroupTab, 0, len(counts))
+	for group, count := range counts {
+		tabs = append(tabs, MarketGroupTab{Group: group, MarketCount: count})
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		if (tabs[i].Group == MarketGroupAll) != (tabs[j].Group == MarketGroupAll) {
+			return tabs[i].Group == MarketGroupAll
+		}
+		if tabs[i].MarketCount != tabs[j].MarketCount {
+			return tabs[i].MarketCount > tabs[j].MarketCount
+		}
+		return tabs[i].Group < tabs[j].Group
+	})
+	return tabs
+}
@@ -0,0 +1,47 @@
+package services
+
+import "testing"
+
+func TestBuildMarketGroupTabs(t *testing.T) {
+	markets := []MarketInfo{
+		{MarketID: "1", Groups: SplitMarketGroups("all|score|regular_play")},
+		{MarketID: "18", Groups: SplitMarketGroups("all|score")},
+		{MarketID: "60", Groups: SplitMarketGroups("all|half|score")},
+		{MarketID: "534"},
+	}
+
+	tabs := BuildMarketGroupTabs(markets)
+	want := []MarketGroupTab{
+		{Group: "all", MarketCount: 4},
+		{Group: "score", MarketCount: 3},
+		{Group: "half", MarketCount: 1},
+		{Group: "regular_play", MarketCount: 1},
+	}
+	if len(tabs) != len(want) {
+		t.Fatalf("tabs = %+v, want %+v", tabs, want)
+	}
+	for i := range want {
+		if tabs[i] != want[i] {
+			t.Errorf("tabs[%d] = %+v, want %+v", i, tabs[i], want[i])
+		}
+	}
+
+	if !HasMarketGroup(markets[3].Groups, MarketGroupAll) || HasMarketGroup(markets[3].Groups, "score") {
+		t.Errorf("market without groups should only belong to %q", MarketGroupAll)
+	}
+}
+
+func TestIsAllMarketGroups(t *testing.T) {
+	cases := map[string]bool{
+		"":           true,
+		"all":        true,
+		"score|all":  true,
+		"score":      false,
+		"score|half": false,
+	}
+	for groups, want := range cases {
+		if got := isAllMarketGroups(groups); got != want {
+			t.Errorf("isAllMarketGroups(%q) = %v, want %v", groups, got, want)
+		}
+	}
+}
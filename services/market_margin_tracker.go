@@ -0,0 +1,405 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 返还率异常类型
+const (
+	MarginAnomalyUnderRound = "under_round" // 总返还率低于 100%, 存在套利空间
+	MarginAnomalyOverRound  = "over_round"  // 总返还率远高于该盘口类型的预期
+)
+
+// 基线 (按盘口类型的原始返还率滑动平均) 参数
+const (
+	marginBaselineAlpha      = 0.05 // 指数滑动平均系数
+	marginBaselineMinSamples = 20   // 样本数不足时不判断 over_round
+)
+
+// ComputeOverround 计算盘口总返还率 Σ 1/odds
+// 只有全部结果 active 且赔率 > 1 (至少 2 个) 时才有意义, 否则返回 false
+func ComputeOverround(odds []float64, active []bool) (float64, bool) {
+	if len(odds) < 2 {
+		return 0, false
+	}
+	total := 0.0
+	for i, o := range odds {
+		if !active[i] || o <= 1 {
+			return 0, false
+		}
+		total += 1 / o
+	}
+	return total, true
+}
+
+type marginBaseline struct {
+	value   float64
+	samples int
+}
+
+// MarketMarginTracker 盘口返还率统计
+// 入库时计算每个盘口的发布 / 原始返还率, 更新 markets 并在变化时写入 market_margin_history
+type MarketMarginTracker struct {
+	tolerance float64
+	baselines map[string]*marginBaseline // key: sr_market_id
+	mu        sync.Mutex
+}
+
+// NewMarketMarginTracker 创建返还率统计
+// tolerance: 发布返还率高于预期多少时标记为 over_round (0.10 = 10 个百分点)
+func NewMarketMarginTracker(tolerance float64) *MarketMarginTracker {
+	return &MarketMarginTracker{
+		tolerance: tolerance,
+		baselines: make(map[string]*marginBaseline),
+	}
+}
+
+// expected 计算预期返还率
+// 有利润率配置时按配置计算, 否则使用该盘口类型原始返还率的滑动平均
+func (t *MarketMarginTracker) expected(srMarketID string, raw float64, profile *MarginProfile) (float64, bool) {
+	if profile != nil {
+		if profile.Mode == MarginModeAdd {
+			return raw + profile.Margin, true
+		}
+		return 1 + profile.Margin, true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.baselines[srMarketID]
+	if b == nil || b.samples < marginBaselineMinSamples {
+		return 0, false
+	}
+	return b.value, true
+}
+
+// observe 更新盘口类型的原始返还率基线 (异常值不计入)
+func (t *MarketMarginTracker) observe(srMarketID string, raw float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.baselines[srMarketID]
+	if b == nil {
+		t.baselines[srMarketID] = &marginBaseline{value: raw, samples: 1}
+		return
+	}
+	if b.samples >= marginBaselineMinSamples && raw > b.value+t.tolerance {
+		return
+	}
+	b.value += marginBaselineAlpha * (raw - b.value)
+	b.samples++
+}
+
+// Record 记录盘口返还率, 只统计 active (status=1) 且结果齐全的盘口
+// profile 为应用的利润率配置 (可为 nil)
+func (t *MarketMarginTracker) Record(tx *sql.Tx, marketPK int, eventID string, market uof.Market, profile *MarginProfile, timestamp int64) error {
+	if market.Status != uof.MarketStatusActive {
+		return nil
+	}
+
+	published := make([]float64, len(market.Outcomes))
+	raw := make([]float64, len(market.Outcomes))
+	active := make([]bool, len(market.Outcomes))
+	for i, outcome := range market.Outcomes {
+		published[i] = outcome.Odds
+		raw[i] = outcome.RawOdds
+		if raw[i] == 0 {
+			raw[i] = outcome.Odds
+		}
+		active[i] = outcome.Active == 1
+	}
+
+	overround, ok := ComputeOverround(published, active)
+	if !ok {
+		return nil
+	}
+	rawOverround, _ := ComputeOverround(raw, active)
+	srMarketID := strconv.Itoa(market.ID)
+
+	anomaly := ""
+	expected, hasExpected := t.expected(srMarketID, rawOverround, profile)
+	if overround < 1 {
+		anomaly = MarginAnomalyUnderRound
+	} else if hasExpected && overround > expected+t.tolerance {
+		anomaly = MarginAnomalyOverRound
+	}
+	if rawOverround >= 1 {
+		t.observe(srMarketID, rawOverround)
+	}
+
+	overround = roundTo(overround, 4)
+	rawOverround = roundTo(rawOverround, 4)
+
+	var previous sql.NullFloat64
+	if err := tx.QueryRow(`SELECT overround FROM markets WHERE id = $1`, marketPK).Scan(&previous); err != nil {
+		return fmt.Errorf("failed to query previous overround: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE markets
+		SET overround = $2, raw_overround = $3, margin_anomaly = NULLIF($4, ''), margin_timestamp = $5
+		WHERE id = $1
+	`, marketPK, overround, rawOverround, anomaly, timestamp); err != nil {
+		return fmt.Errorf("failed to update market overround: %w", err)
+	}
+
+	if previous.Valid && math.Abs(previous.Float64-overround) < 0.00005 {
+		return nil
+	}
+
+	var expectedValue *float64
+	if hasExpected {
+		v := roundTo(expected, 4)
+		expectedValue = &v
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO market_margin_history (market_id, event_id, sr_market_id, specifiers, overround, raw_overround, expected_overround, anomaly, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+	`, marketPK, eventID, srMarketID, market.Specifiers, overround, rawOverround, expectedValue, anomaly, timestamp); err != nil {
+		return fmt.Errorf("failed to insert margin history: %w", err)
+	}
+	return nil
+}
+
+// MarketMarginOutcome 结果的隐含概率
+type MarketMarginOutcome struct {
+	OutcomeID             string  `json:"outcome_id"`
+	OutcomeName           string  `json:"outcome_name"`
+	Odds                  float64 `json:"odds"`
+	RawOdds               float64 `json:"raw_odds"`
+	Active                bool    `json:"active"`
+	ImpliedProbability    float64 `json:"implied_probability"`
+	NormalizedProbability float64 `json:"normalized_probability"` // 去除利润率后的概率 (1/odds ÷ 返还率)
+}
+
+// MarketMargin 盘口当前返还率
+type MarketMargin struct {
+	MarketID     string                `json:"market_id"`
+	Specifiers   string                `json:"specifiers"`
+	MarketName   string                `json:"market_name"`
+	Status       string                `json:"status"`
+	Overround    *float64              `json:"overround"`     // 发布赔率的总返还率, 1.05 表示 105%
+	RawOverround *float64              `json:"raw_overround"` // 原始赔率的总返还率
+	Margin       *float64              `json:"margin"`        // overround - 1
+	Anomaly      string                `json:"anomaly,omitempty"`
+	Timestamp    int64                 `json:"timestamp"`
+	Outcomes     []MarketMarginOutcome `json:"outcomes"`
+}
+
+// EventMargin 赛事所有盘口的返还率
+type EventMargin struct {
+	EventID        string         `json:"event_id"`
+	MarketCount    int            `json:"market_count"`
+	AverageMargin  *float64       `json:"average_margin"` // 有返还率数据的盘口的平均利润率
+	AnomalousCount int            `json:"anomalous_count"`
+	Markets        []MarketMargin `json:"markets"`
+}
+
+// MarginHistoryPoint 返还率历史
+type MarginHistoryPoint struct {
+	MarketID          string    `json:"market_id"`
+	Specifiers        string    `json:"specifiers"`
+	Overround         float64   `json:"overround"`
+	RawOverround      float64   `json:"raw_overround"`
+	ExpectedOverround *float64  `json:"expected_overround"`
+	Margin            float64   `json:"margin"`
+	Anomaly           string    `json:"anomaly,omitempty"`
+	Timestamp         int64     `json:"timestamp"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// MarginAnomaly 当前存在异常的盘口
+type MarginAnomaly struct {
+	EventID      string   `json:"event_id"`
+	MarketID     string   `json:"market_id"`
+	Specifiers   string   `json:"specifiers"`
+	MarketName   string   `json:"market_name"`
+	Overround    float64  `json:"overround"`
+	RawOverround *float64 `json:"raw_overround"`
+	Anomaly      string   `json:"anomaly"`
+	Timestamp    int64    `json:"timestamp"`
+}
+
+// MarketMarginService 返还率查询服务
+type MarketMarginService struct {
+	db *sql.DB
+}
+
+// NewMarketMarginService 创建返还率查询服务
+func NewMarketMarginService(db *sql.DB) *MarketMarginService {
+	return &MarketMarginService{db: db}
+}
+
+// GetEventMargins 获取赛事每个盘口的当前返还率和结果概率
+func (s *MarketMarginService) GetEventMargins(eventID string) (*EventMargin, error) {
+	rows, err := s.db.Query(`
+		SELECT m.id, m.sr_market_id, COALESCE(m.specifiers, ''), COALESCE(m.market_name, ''), COALESCE(m.status, ''),
+		       m.overround, m.raw_overround, COALESCE(m.margin_anomaly, ''), COALESCE(m.margin_timestamp, 0),
+		       o.outcome_id, COALESCE(o.outcome_name, ''), COALESCE(o.odds_value, 0),
+		       COALESCE(o.raw_odds_value, o.odds_value, 0), COALESCE(o.active, false)
+		FROM markets m
+		LEFT JOIN odds o ON o.market_id = m.id
+		WHERE m.event_id = $1
+		ORDER BY m.id, o.outcome_id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query market margins: %w", err)
+	}
+	defer rows.Close()
+
+	result := &EventMargin{EventID: eventID, Markets: make([]MarketMargin, 0)}
+	lastMarketPK := -1
+	for rows.Next() {
+		var marketPK int
+		var market MarketMargin
+		var overround, rawOverround sql.NullFloat64
+		var outcomeID sql.NullString
+		var outcome MarketMarginOutcome
+		if err := rows.Scan(
+			&marketPK, &market.MarketID, &market.Specifiers, &market.MarketName, &market.Status,
+			&overround, &rawOverround, &market.Anomaly, &market.Timestamp,
+			&outcomeID, &outcome.OutcomeName, &outcome.Odds, &outcome.RawOdds, &outcome.Active,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan market margin: %w", err)
+		}
+
+		if marketPK != lastMarketPK {
+			lastMarketPK = marketPK
+			market.Overround = nullFloatPtr(overround)
+			market.RawOverround = nullFloatPtr(rawOverround)
+			if overround.Valid {
+				margin := roundTo(overround.Float64-1, 4)
+				market.Margin = &margin
+			}
+			market.Outcomes = make([]MarketMarginOutcome, 0)
+			result.Markets = append(result.Markets, market)
+		}
+
+		if !outcomeID.Valid {
+			continue
+		}
+		outcome.OutcomeID = outcomeID.String
+		current := &result.Markets[len(result.Markets)-1]
+		current.Outcomes = append(current.Outcomes, outcome)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 隐含概率和去除利润率后的概率按当前赔率计算
+	marginSum, marginCount := 0.0, 0
+	for i := range result.Markets {
+		market := &result.Markets[i]
+		total := 0.0
+		for j := range market.Outcomes {
+			if market.Outcomes[j].Odds > 1 {
+				market.Outcomes[j].ImpliedProbability = roundTo(1/market.Outcomes[j].Odds, 4)
+				if market.Outcomes[j].Active {
+					total += 1 / market.Outcomes[j].Odds
+				}
+			}
+		}
+		if total > 0 {
+			for j := range market.Outcomes {
+				if market.Outcomes[j].Active && market.Outcomes[j].Odds > 1 {
+					market.Outcomes[j].NormalizedProbability = roundTo(1/market.Outcomes[j].Odds/total, 4)
+				}
+			}
+		}
+
+		if market.Margin != nil {
+			marginSum += *market.Margin
+			marginCount++
+		}
+		if market.Anomaly != "" {
+			result.AnomalousCount++
+		}
+	}
+
+	result.MarketCount = len(result.Markets)
+	if marginCount > 0 {
+		avg := roundTo(marginSum/float64(marginCount), 4)
+		result.AverageMargin = &avg
+	}
+	return result, nil
+}
+
+// GetMarginHistory 获取赛事盘口的返还率历史 (按时间倒序)
+// marketID / specifiers 为空时不过滤
+func (s *MarketMarginService) GetMarginHistory(eventID, marketID, specifiers string, limit int) ([]MarginHistoryPoint, error) {
+	query := `
+		SELECT sr_market_id, COALESCE(specifiers, ''), overround, COALESCE(raw_overround, overround),
+		       expected_overround, COALESCE(anomaly, ''), COALESCE(timestamp, 0), created_at
+		FROM market_margin_history
+		WHERE event_id = $1
+	`
+	args := []interface{}{eventID}
+	if marketID != "" {
+		args = append(args, NormalizeMarketID(marketID))
+		query += fmt.Sprintf(" AND sr_market_id = $%d", len(args))
+	}
+	if specifiers != "" {
+		args = append(args, specifiers)
+		query += fmt.Sprintf(" AND specifiers = $%d", len(args))
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY timestamp DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query margin history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]MarginHistoryPoint, 0)
+	for rows.Next() {
+		var point MarginHistoryPoint
+		var expected sql.NullFloat64
+		if err := rows.Scan(&point.MarketID, &point.Specifiers, &point.Overround, &point.RawOverround,
+			&expected, &point.Anomaly, &point.Timestamp, &point.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan margin history: %w", err)
+		}
+		point.ExpectedOverround = nullFloatPtr(expected)
+		point.Margin = roundTo(point.Overround-1, 4)
+		history = append(history, point)
+	}
+	return history, rows.Err()
+}
+
+// GetAnomalies 获取当前存在返还率异常的 active 盘口 (按更新时间倒序)
+func (s *MarketMarginService) GetAnomalies(anomaly string, limit int) ([]MarginAnomaly, error) {
+	rows, err := s.db.Query(`
+		SELECT event_id, sr_market_id, COALESCE(specifiers, ''), COALESCE(market_name, ''),
+		       overround, raw_overround, margin_anomaly, COALESCE(margin_timestamp, 0)
+		FROM markets
+		WHERE margin_anomaly IS NOT NULL AND status = '1'
+		  AND ($1 = '' OR margin_anomaly = $1)
+		ORDER BY margin_timestamp DESC
+		LIMIT $2
+	`, anomaly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query margin anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := make([]MarginAnomaly, 0)
+	for rows.Next() {
+		var a MarginAnomaly
+		var rawOverround sql.NullFloat64
+		if err := rows.Scan(&a.EventID, &a.MarketID, &a.Specifiers, &a.MarketName,
+			&a.Overround, &rawOverround, &a.Anomaly, &a.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan margin anomaly: %w", err)
+		}
+		a.RawOverround = nullFloatPtr(rawOverround)
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
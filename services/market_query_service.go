@@ -6,11 +6,18 @@ import (
 )
 
 type MarketQueryService struct {
-	db *sql.DB
+	db                *sql.DB
+	marketDescService *MarketDescriptionsService
+	localization      *LocalizationService
 }
 
-func NewMarketQueryService(db *sql.DB) *MarketQueryService {
-	return &MarketQueryService{db: db}
+func NewMarketQueryService(db *sql.DB, marketDescService *MarketDescriptionsService) *MarketQueryService {
+	return &MarketQueryService{db: db, marketDescService: marketDescService}
+}
+
+// SetLocalizationService 设置多语言名称服务 (可选)
+func (s *MarketQueryService) SetLocalizationService(localization *LocalizationService) {
+	s.localization = localization
 }
 
 type MarketInfo struct {
@@ -18,6 +25,7 @@ type MarketInfo struct {
 	Specifiers  string        `json:"specifiers"`
 	Status      int           `json:"status"`
 	MarketName  string        `json:"market_name"`
+	Groups      []string      `json:"groups"` // 盘口组, 例如 ["all", "score", "regular_play"]
 	Outcomes    []OutcomeInfo `json:"outcomes"`
 }
 
@@ -28,14 +36,28 @@ type OutcomeInfo struct {
 	Active      bool    `json:"active"`
 }
 
-func (s *MarketQueryService) GetEventMarkets(eventID string) ([]MarketInfo, error) {
+// GetEventMarkets 获取赛事的盘口和结果
+// lang 为英文以外的语言时, 盘口 / 结果名称按该语言重新渲染 (缺少翻译时为英文)
+func (s *MarketQueryService) GetEventMarkets(eventID, lang string) ([]MarketInfo, error) {
+	var ctx *ReplacementContext
+	if lang != "" {
+		lang = s.localization.NormalizeLanguage(lang)
+	}
+	if lang != "" && lang != DefaultLanguage && s.marketDescService != nil {
+		var err error
+		if ctx, err = s.replacementContext(eventID, lang); err != nil {
+			return nil, err
+		}
+	}
+
 	// 查询所有市场
 	marketsQuery := `
 		SELECT 
 			m.sr_market_id,
 			COALESCE(m.specifiers, '') as specifiers,
 			m.status,
-			COALESCE(m.market_name, '') as market_name
+			COALESCE(m.market_name, '') as market_name,
+			COALESCE(m.groups, '') as groups
 		FROM markets m
 		WHERE m.event_id = $1
 		ORDER BY m.sr_market_id, m.specifiers
@@ -50,9 +72,19 @@ func (s *MarketQueryService) GetEventMarkets(eventID string) ([]MarketInfo, erro
 	var markets []MarketInfo
 	for rows.Next() {
 		var market MarketInfo
-		if err := rows.Scan(&market.MarketID, &market.Specifiers, &market.Status, &market.MarketName); err != nil {
+		var groups string
+		if err := rows.Scan(&market.MarketID, &market.Specifiers, &market.Status, &market.MarketName, &groups); err != nil {
 			return nil, fmt.Errorf("failed to scan market: %w", err)
 		}
+		// 描述加载前入库的盘口没有 groups, 使用当前描述
+		if groups == "" && s.marketDescService != nil {
+			groups = s.marketDescService.GetMarketGroups(market.MarketID)
+		}
+		market.Groups = SplitMarketGroups(groups)
+		if ctx != nil {
+			ctx.Specifiers = market.Specifiers
+			market.MarketName = s.marketDescService.GetMarketName(market.MarketID, market.Specifiers, ctx)
+		}
 
 		// 查询该市场的所有 outcomes
 		outcomesQuery := `
@@ -81,6 +113,12 @@ func (s *MarketQueryService) GetEventMarkets(eventID string) ([]MarketInfo, erro
 				outcomeRows.Close()
 				return nil, fmt.Errorf("failed to scan outcome: %w", err)
 			}
+			if ctx != nil {
+				// 非对阵赛事的参赛方结果找不到描述时保留入库时的名称
+				if name := s.marketDescService.GetOutcomeName(market.MarketID, outcome.OutcomeID, market.Specifiers, ctx); name != outcome.OutcomeID || outcome.OutcomeName == "" {
+					outcome.OutcomeName = name
+				}
+			}
 			outcomes = append(outcomes, outcome)
 		}
 		outcomeRows.Close()
@@ -96,3 +134,29 @@ func (s *MarketQueryService) GetEventMarkets(eventID string) ([]MarketInfo, erro
 	return markets, nil
 }
 
+// replacementContext 构造赛事指定语言的名称模板上下文 (参赛方名称已翻译)
+func (s *MarketQueryService) replacementContext(eventID, lang string) (*ReplacementContext, error) {
+	var homeTeamID, homeTeamName, awayTeamID, awayTeamName sql.NullString
+	err := s.db.QueryRow(`
+		SELECT home_team_id, home_team_name, away_team_id, away_team_name
+		FROM tracked_events
+		WHERE event_id = $1
+	`, eventID).Scan(&homeTeamID, &homeTeamName, &awayTeamID, &awayTeamName)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query event: %w", err)
+	}
+
+	competitors, err := LoadEventCompetitors(s.db, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if len(competitors) == 0 {
+		if homeTeamID.String != "" {
+			competitors = append(competitors, EventCompetitor{ID: homeTeamID.String, Name: homeTeamName.String, Qualifier: "home"})
+		}
+		if awayTeamID.String != "" {
+			competitors = append(competitors, EventCompetitor{ID: awayTeamID.String, Name: awayTeamName.String, Qualifier: "away"})
+		}
+	}
+	return s.localization.ReplacementContext(competitors, "", lang), nil
+}
@@ -202,7 +202,7 @@ func (m *MatchMonitor) AnalyzeBookedMatches(schedule *ScheduleResponse) {
 		logger.Println("   Pre-match odds updates are less frequent.")
 	}
 	
-	logger.Println("═══════════════════════════════════════════════════════════\n")
+	logger.Printf("═══════════════════════════════════════════════════════════\n\n")
 }
 
 // CheckAndReport 检查并报告
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 时间线条目类型
+const (
+	TimelineStatusChange   = "status_change"   // 赛事状态变化 (not_started -> live -> ended -> closed)
+	TimelinePeriodChange   = "period_change"   // 比赛阶段变化 (match_status, 如上半场 -> 中场)
+	TimelineScoreChange    = "score_change"    // 比分增加
+	TimelineScoreCorrected = "score_corrected" // 比分减少 (取消进球等)
+	TimelineYellowCard     = "yellow_card"
+	TimelineRedCard        = "red_card"
+	TimelineYellowRedCard  = "yellow_red_card"
+	TimelineCorner         = "corner"
+	TimelineClockStopped   = "clock_stopped"
+	TimelineClockResumed   = "clock_resumed"
+	TimelineStoppageTime   = "stoppage_time" // 宣布补时
+)
+
+// TimelineEntry 比赛时间线条目
+type TimelineEntry struct {
+	ID              int64     `json:"id"`
+	EventID         string    `json:"event_id"`
+	EntryType       string    `json:"type"`
+	Team            string    `json:"team,omitempty"`      // home / away
+	TeamName        string    `json:"team_name,omitempty"` // 查询时按 lang 填充
+	Value           int       `json:"value"`               // 变化量 (进球数 / 牌数 / 角球数), 补时为分钟数
+	HomeScore       int       `json:"home_score"`
+	AwayScore       int       `json:"away_score"`
+	Status          string    `json:"status"`                      // sport_event_status.status
+	MatchStatus     string    `json:"match_status"`                // sport_event_status.match_status
+	MatchStatusName string    `json:"match_status_name,omitempty"` // 查询时按 lang 填充
+	MatchTime       string    `json:"match_time,omitempty"`
+	Description     string    `json:"description"`
+	ProducerID      int       `json:"producer_id"`
+	UOFTimestamp    int64     `json:"uof_timestamp"` // odds_change 时间戳
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// timelineState 上一次的赛事状态
+type timelineState struct {
+	timestamp   int64
+	status      string
+	matchStatus string
+	homeScore   int
+	awayScore   int
+	stats       map[string][2]int // 统计类型 -> [home, away]
+	hasStats    bool
+	stopped     bool
+	stoppage    string
+	updatedAt   time.Time
+}
+
+// MatchTimelineService 把 sport_event_status 的变化转换为比赛时间线
+type MatchTimelineService struct {
+	db     *sql.DB
+	states map[string]*timelineState
+	lastGC time.Time
+	mu     sync.Mutex
+}
+
+// NewMatchTimelineService 创建比赛时间线服务
+func NewMatchTimelineService(db *sql.DB) *MatchTimelineService {
+	return &MatchTimelineService{
+		db:     db,
+		states: make(map[string]*timelineState),
+	}
+}
+
+// newTimelineState 从 sport_event_status 构建状态, 消息中缺少的字段沿用上一次的状态
+func newTimelineState(ses *uof.SportEventStatus, timestamp int64, prev *timelineState) *timelineState {
+	state := &timelineState{
+		timestamp: timestamp,
+		stats:     make(map[string][2]int),
+		updatedAt: time.Now(),
+	}
+	if prev != nil {
+		state.status = prev.status
+		state.matchStatus = prev.matchStatus
+		state.homeScore = prev.homeScore
+		state.awayScore = prev.awayScore
+		state.stats = prev.stats
+		state.hasStats = prev.hasStats
+		state.stopped = prev.stopped
+		state.stoppage = prev.stoppage
+	}
+
+	if ses.Status != "" {
+		state.status = ses.Status
+	}
+	if ses.MatchStatus != "" {
+		state.matchStatus = ses.MatchStatus
+	}
+	if ses.HomeScore != nil && ses.AwayScore != nil {
+		state.homeScore = *ses.HomeScore
+		state.awayScore = *ses.AwayScore
+	}
+	if ses.Statistics != nil {
+		state.hasStats = true
+		state.stats = make(map[string][2]int)
+		for entryType, stats := range map[string]*uof.TeamStats{
+			TimelineYellowCard:    ses.Statistics.YellowCards,
+			TimelineRedCard:       ses.Statistics.RedCards,
+			TimelineYellowRedCard: ses.Statistics.YellowRedCards,
+			TimelineCorner:        ses.Statistics.Corners,
+		} {
+			if stats != nil {
+				state.stats[entryType] = [2]int{stats.Home, stats.Away}
+			}
+		}
+	}
+	if ses.Clock != nil {
+		state.stopped = ses.Clock.Stopped
+		state.stoppage = ses.Clock.StoppageTimeAnnounced
+	}
+	return state
+}
+
+// Record 比较 odds_change 中的 sport_event_status 与上一次状态, 保存变化产生的时间线条目
+// 赛事第一次出现时 (如服务重启后) 以 tracked_events 中的比分和状态为基准, 统计数据从这一条开始比较
+func (s *MatchTimelineService) Record(oddsChange *uof.OddsChange) ([]TimelineEntry, error) {
+	ses := oddsChange.SportEventStatus
+	if ses == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	s.gc()
+	prev, ok := s.states[oddsChange.EventID]
+	if ok && oddsChange.Timestamp < prev.timestamp {
+		s.mu.Unlock()
+		return nil, nil // 乱序消息
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		prev = s.loadState(oddsChange.EventID)
+	}
+	current := newTimelineState(ses, oddsChange.Timestamp, prev)
+
+	s.mu.Lock()
+	s.states[oddsChange.EventID] = current
+	s.mu.Unlock()
+
+	if prev == nil {
+		return nil, nil
+	}
+
+	entries := diffTimelineStates(prev, current, ses)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	matchTime := ""
+	if ses.Clock != nil {
+		matchTime = ses.Clock.MatchTime
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.EventID = oddsChange.EventID
+		e.HomeScore = current.homeScore
+		e.AwayScore = current.awayScore
+		e.Status = current.status
+		e.MatchStatus = current.matchStatus
+		e.MatchTime = matchTime
+		e.ProducerID = oddsChange.ProductID
+		e.UOFTimestamp = oddsChange.Timestamp
+
+		if err := s.db.QueryRow(`
+			INSERT INTO match_timeline (event_id, entry_type, team, value, home_score, away_score, status, match_status,
+			                            match_time, description, producer_id, uof_timestamp)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING id, created_at
+		`, e.EventID, e.EntryType, e.Team, e.Value, e.HomeScore, e.AwayScore, e.Status, e.MatchStatus,
+			e.MatchTime, e.Description, e.ProducerID, e.UOFTimestamp,
+		).Scan(&e.ID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to insert timeline entry: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+// diffTimelineStates 生成两个状态之间的时间线条目
+func diffTimelineStates(prev, current *timelineState, ses *uof.SportEventStatus) []TimelineEntry {
+	entries := make([]TimelineEntry, 0)
+
+	if current.status != prev.status {
+		entries = append(entries, TimelineEntry{
+			EntryType:   TimelineStatusChange,
+			Description: fmt.Sprintf("status %s -> %s", sportEventStatusName(prev.status), sportEventStatusName(current.status)),
+		})
+	}
+	if current.matchStatus != prev.matchStatus {
+		entries = append(entries, TimelineEntry{
+			EntryType:   TimelinePeriodChange,
+			Description: fmt.Sprintf("match_status %s -> %s", prev.matchStatus, current.matchStatus),
+		})
+	}
+
+	// 比分 (消息中没有比分时沿用上一次, 不会产生条目)
+	for _, side := range []struct {
+		team     string
+		from, to int
+	}{
+		{"home", prev.homeScore, current.homeScore},
+		{"away", prev.awayScore, current.awayScore},
+	} {
+		if side.to == side.from {
+			continue
+		}
+		entryType := TimelineScoreChange
+		if side.to < side.from {
+			entryType = TimelineScoreCorrected
+		}
+		entries = append(entries, TimelineEntry{
+			EntryType:   entryType,
+			Team:        side.team,
+			Value:       side.to - side.from,
+			Description: fmt.Sprintf("%s score %d -> %d (%d-%d)", side.team, side.from, side.to, current.homeScore, current.awayScore),
+		})
+	}
+
+	// 统计 (牌 / 角球) 只在前后两条消息都带有统计时比较, 只记录增加
+	if prev.hasStats && current.hasStats {
+		for _, entryType := range []string{TimelineYellowCard, TimelineRedCard, TimelineYellowRedCard, TimelineCorner} {
+			before, after := prev.stats[entryType], current.stats[entryType]
+			for i, team := range []string{"home", "away"} {
+				if after[i] > before[i] {
+					entries = append(entries, TimelineEntry{
+						EntryType:   entryType,
+						Team:        team,
+						Value:       after[i] - before[i],
+						Description: fmt.Sprintf("%s %s %d -> %d", team, strings.ReplaceAll(entryType, "_", " "), before[i], after[i]),
+					})
+				}
+			}
+		}
+	}
+
+	if ses.Clock != nil {
+		if current.stopped != prev.stopped {
+			entryType := TimelineClockResumed
+			if current.stopped {
+				entryType = TimelineClockStopped
+			}
+			entries = append(entries, TimelineEntry{
+				EntryType:   entryType,
+				Description: strings.ReplaceAll(entryType, "_", " "),
+			})
+		}
+		if current.stoppage != prev.stoppage && current.stoppage != "" {
+			var minutes int
+			fmt.Sscanf(current.stoppage, "%d", &minutes)
+			entries = append(entries, TimelineEntry{
+				EntryType:   TimelineStoppageTime,
+				Value:       minutes,
+				Description: fmt.Sprintf("stoppage time announced: %s", current.stoppage),
+			})
+		}
+	}
+	return entries
+}
+
+// sportEventStatusName sport_event_status.status 的名称
+func sportEventStatusName(status string) string {
+	names := map[string]string{
+		"0": "not_started",
+		"1": "live",
+		"2": "suspended",
+		"3": "ended",
+		"4": "closed",
+		"5": "cancelled",
+		"6": "delayed",
+		"7": "interrupted",
+		"8": "postponed",
+		"9": "abandoned",
+	}
+	if name, ok := names[status]; ok {
+		return name
+	}
+	return status
+}
+
+// loadState 从 tracked_events 加载比分和状态作为基准, 赛事不存在时返回 nil
+func (s *MatchTimelineService) loadState(eventID string) *timelineState {
+	state := &timelineState{stats: make(map[string][2]int)}
+	var statusName string
+	err := s.db.QueryRow(`
+		SELECT COALESCE(status, ''), COALESCE(match_status, ''), COALESCE(home_score, 0), COALESCE(away_score, 0)
+		FROM tracked_events WHERE event_id = $1
+	`, eventID).Scan(&statusName, &state.matchStatus, &state.homeScore, &state.awayScore)
+	if err != nil {
+		return nil
+	}
+	// tracked_events.status 保存的是状态名称
+	for code := 0; code <= 9; code++ {
+		if c := fmt.Sprint(code); sportEventStatusName(c) == statusName {
+			state.status = c
+		}
+	}
+	return state
+}
+
+// gc 清理一天未更新的赛事 (调用方需持有 s.mu)
+func (s *MatchTimelineService) gc() {
+	now := time.Now()
+	if now.Sub(s.lastGC) < 10*time.Minute {
+		return
+	}
+	for eventID, state := range s.states {
+		if now.Sub(state.updatedAt) > 24*time.Hour {
+			delete(s.states, eventID)
+		}
+	}
+	s.lastGC = now
+}
+
+// GetTimeline 获取赛事时间线 (按时间正序), types 为空时返回全部类型
+func (s *MatchTimelineService) GetTimeline(eventID string, types []string) ([]TimelineEntry, error) {
+	query := `
+		SELECT id, event_id, entry_type, COALESCE(team, ''), value, home_score, away_score,
+		       COALESCE(status, ''), COALESCE(match_status, ''), COALESCE(match_time, ''), description,
+		       COALESCE(producer_id, 0), uof_timestamp, created_at
+		FROM match_timeline
+		WHERE event_id = $1
+	`
+	args := []interface{}{eventID}
+	if len(types) > 0 {
+		placeholders := make([]string, len(types))
+		for i, t := range types {
+			args = append(args, t)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		query += " AND entry_type IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY uof_timestamp, id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query timeline: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]TimelineEntry, 0)
+	for rows.Next() {
+		var e TimelineEntry
+		if err := rows.Scan(&e.ID, &e.EventID, &e.EntryType, &e.Team, &e.Value, &e.HomeScore, &e.AwayScore,
+			&e.Status, &e.MatchStatus, &e.MatchTime, &e.Description,
+			&e.ProducerID, &e.UOFTimestamp, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan timeline entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"time"
+
+	"uof-service/uof"
 )
 
 // MessageHistoryService 消息历史服务
@@ -173,20 +175,7 @@ func (s *MessageHistoryService) generateDescription(msgType, eventID, xmlContent
 
 // generateOddsChangeDescription 生成 odds_change 的自然语言描述
 func (s *MessageHistoryService) generateOddsChangeDescription(eventID, xmlContent string) string {
-	type OddsChange struct {
-		Odds struct {
-			Markets []struct {
-				Outcomes []struct{} `xml:"outcome"`
-			} `xml:"market"`
-		} `xml:"odds"`
-		SportEventStatus *struct {
-			HomeScore *int `xml:"home_score,attr"`
-			AwayScore *int `xml:"away_score,attr"`
-			MatchStatus string `xml:"match_status,attr"`
-		} `xml:"sport_event_status"`
-	}
-
-	var oddsChange OddsChange
+	var oddsChange uof.OddsChange
 	if err := xml.Unmarshal([]byte(xmlContent), &oddsChange); err != nil {
 		return fmt.Sprintf("比赛 %s 的赔率已更新", eventID)
 	}
@@ -215,11 +204,7 @@ func (s *MessageHistoryService) generateOddsChangeDescription(eventID, xmlConten
 
 // generateBetStopDescription 生成 bet_stop 的自然语言描述
 func (s *MessageHistoryService) generateBetStopDescription(eventID, xmlContent string) string {
-	type BetStop struct {
-		Groups string `xml:"groups,attr"`
-	}
-
-	var betStop BetStop
+	var betStop uof.BetStop
 	if err := xml.Unmarshal([]byte(xmlContent), &betStop); err != nil {
 		return fmt.Sprintf("比赛 %s 的市场已暂停", eventID)
 	}
@@ -232,16 +217,7 @@ func (s *MessageHistoryService) generateBetStopDescription(eventID, xmlContent s
 
 // generateBetSettlementDescription 生成 bet_settlement 的自然语言描述
 func (s *MessageHistoryService) generateBetSettlementDescription(eventID, xmlContent string) string {
-	type BetSettlement struct {
-		Certainty int `xml:"certainty,attr"`
-		Outcomes struct {
-			Markets []struct {
-				Outcomes []struct{} `xml:"outcome"`
-			} `xml:"market"`
-		} `xml:"outcomes"`
-	}
-
-	var settlement BetSettlement
+	var settlement uof.BetSettlement
 	if err := xml.Unmarshal([]byte(xmlContent), &settlement); err != nil {
 		return fmt.Sprintf("比赛 %s 的市场已结算", eventID)
 	}
@@ -270,12 +246,7 @@ func (s *MessageHistoryService) generateBetSettlementDescription(eventID, xmlCon
 
 // generateFixtureChangeDescription 生成 fixture_change 的自然语言描述
 func (s *MessageHistoryService) generateFixtureChangeDescription(eventID, xmlContent string) string {
-	type FixtureChange struct {
-		StartTime  int64 `xml:"start_time,attr"`
-		ChangeType int   `xml:"change_type,attr"`
-	}
-
-	var fixtureChange FixtureChange
+	var fixtureChange uof.FixtureChange
 	if err := xml.Unmarshal([]byte(xmlContent), &fixtureChange); err != nil {
 		return fmt.Sprintf("比赛 %s 的赛事信息已更新", eventID)
 	}
@@ -295,13 +266,7 @@ func (s *MessageHistoryService) generateFixtureChangeDescription(eventID, xmlCon
 
 // generateBetCancelDescription 生成 bet_cancel 的自然语言描述
 func (s *MessageHistoryService) generateBetCancelDescription(eventID, xmlContent string) string {
-	type BetCancel struct {
-		Markets []struct {
-			ID string `xml:"id,attr"`
-		} `xml:"market"`
-	}
-
-	var betCancel BetCancel
+	var betCancel uof.BetCancel
 	if err := xml.Unmarshal([]byte(xmlContent), &betCancel); err != nil {
 		return fmt.Sprintf("比赛 %s 的投注已取消", eventID)
 	}
@@ -1,12 +1,12 @@
 package services
 
 import (
-	"encoding/xml"
-
 	"uof-service/config"
 	"fmt" // 修复 fmt 未导入的错误
 	"strconv" // 修复 strconv 未导入的错误
+	"time"
 	"uof-service/logger"
+	"uof-service/uof"
 )
 
 // MessageProcessor 负责从 Broker 消费特定 Topic 的消息，并执行业务逻辑
@@ -28,23 +28,36 @@ type MessageProcessor struct {
 	srnMappingService         *SRNMappingService
 	fixtureService            *FixtureService
 	marketDescService         *MarketDescriptionsService
+	eventMetaCache            *EventMetaCache
+	oddsStateCache            *OddsStateCache
+	marginService             *MarginService
+	mainLineTracker           *MainLineTracker
+	oddsAlertService          *OddsAlertService
+	larkNotifier              *LarkNotifier
+	localization              *LocalizationService
 	
 	done                      chan bool
 }
 
 // NewMessageProcessor 创建 MessageProcessor 实例
-func NewMessageProcessor(cfg *config.Config, store *MessageStore, broker MessageBroker, broadcaster MessageBroadcaster, marketDescService *MarketDescriptionsService) *MessageProcessor {
+func NewMessageProcessor(cfg *config.Config, store *MessageStore, broker MessageBroker, broadcaster MessageBroadcaster, marketDescService *MarketDescriptionsService, larkNotifier *LarkNotifier) *MessageProcessor {
 	// 初始化解析器 (与原 AMQPConsumer 的初始化逻辑一致)
 	srnMappingService := NewSRNMappingService(cfg.UOFAPIToken, cfg.APIBaseURL, store.db)
 	fixtureParser := NewFixtureParser(store.db, srnMappingService, cfg.APIBaseURL, cfg.AccessToken)
 	oddsChangeParser := NewOddsChangeParser(store.db)
+	oddsChangeParser.SetTimelineService(NewMatchTimelineService(store.db))
 	oddsParser := NewOddsParser(store.db, marketDescService)
-	betSettlementParser := NewBetSettlementParser(store.db)
+	betSettlementParser := NewBetSettlementParser(store.db, larkNotifier)
 	betStopProcessor := NewBetStopProcessor(store.db)
 	betCancelProcessor := NewBetCancelProcessor(store.db)
 	rollbackBetSettlementProc := NewRollbackBetSettlementProcessor(store.db)
 	rollbackBetCancelProc := NewRollbackBetCancelProcessor(store.db)
 	fixtureService := NewFixtureService(cfg.UOFAPIToken, cfg.APIBaseURL)
+	eventMetaCache := NewEventMetaCache(store.db, 10*time.Minute)
+	marginService := NewMarginService(store.db, eventMetaCache, time.Duration(cfg.MarginProfileRefreshSeconds)*time.Second)
+	oddsParser.SetMarginService(marginService)
+	oddsParser.SetMarginTracker(NewMarketMarginTracker(cfg.MarginAnomalyTolerance))
+	oddsParser.SetClosingLineService(NewClosingLineService(store.db))
 
 	// 从数据库加载 SRN mapping 缓存
 	if err := srnMappingService.LoadCacheFromDB(); err != nil {
@@ -67,10 +80,23 @@ func NewMessageProcessor(cfg *config.Config, store *MessageStore, broker Message
 		srnMappingService:         srnMappingService,
 		fixtureService:            fixtureService,
 		marketDescService:         marketDescService,
+		eventMetaCache:            eventMetaCache,
+		oddsStateCache:            NewOddsStateCache(6 * time.Hour),
+		marginService:             marginService,
+		mainLineTracker:           NewMainLineTracker(6 * time.Hour),
+		oddsAlertService:          NewOddsAlertService(store.db, time.Duration(cfg.OddsAlertRuleRefreshSeconds)*time.Second, cfg.OddsAlertNotifyPerMinute),
+		larkNotifier:              larkNotifier,
 		done:                      make(chan bool),
 	}
 }
 
+// SetLocalizationService 设置多语言名称服务 (可选)
+// 设置后广播消息附带其它语言的名称, fixture 到达时预取赛事名称
+func (p *MessageProcessor) SetLocalizationService(localization *LocalizationService) {
+	p.localization = localization
+	p.fixtureParser.SetLocalizationService(localization)
+}
+
 // StartConsumer 启动一个消费者，订阅指定的 Topic 并开始处理消息
 func (p *MessageProcessor) StartConsumer(messageType string) error {
 	topic := GetTopicName(messageType)
@@ -95,104 +121,135 @@ func (p *MessageProcessor) handleMessages(msgs <-chan BrokerMessage) {
 
 // processMessage 处理单条 Broker 消息
 func (p *MessageProcessor) processMessage(msg BrokerMessage) {
-	xmlContent := string(msg.Value)
-	
+	// 使用接收时解码的消息, 没有时 (例如来自其它生产者) 在这里解码一次
+	message := msg.Message
+	if message == nil {
+		decoded, err := uof.Decode(msg.Value)
+		if err != nil {
+			logger.Errorf("[MessageProcessor] Failed to decode message from topic %s: %v", msg.Topic, err)
+			return
+		}
+		message = decoded
+	}
+
 	// 提取消息类型 (从 Topic 名称中获取)
 	messageType := msg.Topic[len("uof-message-"):]
 
-	// 解析消息基本信息
-	type BaseMessage struct {
-		EventID   string `xml:"event_id,attr"`
-		ProductID int    `xml:"product,attr"`
-		Timestamp int64  `xml:"timestamp,attr"`
-	}
+	eventID := message.EventID
+	productID := &message.ProductID
+	timestamp := message.Timestamp
 
-	var base BaseMessage
-	xml.Unmarshal(msg.Value, &base)
-	
-	eventID := base.EventID
-	productID := &base.ProductID
-	timestamp := base.Timestamp
+	// 广播使用应用利润率后的发布赔率 (复制一份, 入库和告警仍使用原始消息)
+	var published *uof.OddsChange
+	if message.OddsChange != nil {
+		published = p.publishOddsChange(message.OddsChange)
+	}
 
 	// 广播到WebSocket客户端 (从 AMQPConsumer 迁移过来)
 	if p.broadcaster != nil {
-		data := p.extractMessageData(messageType, xmlContent)
-		p.broadcaster.Broadcast(map[string]interface{}{
-			"type":         "message",
-			"message_type": messageType,
-			"event_id":     eventID,
-			"product_id":   productID,
-			"timestamp":    timestamp,
-			"data":         data,
-		})
+		data := p.extractMessageData(message, published)
+		meta := p.eventMetaCache.Get(eventID)
+		broadcast := map[string]interface{}{
+			"type":          "message",
+			"message_type":  messageType,
+			"event_id":      eventID,
+			"product_id":    productID,
+			"sport_id":      meta.SportID,
+			"tournament_id": meta.TournamentID,
+			"timestamp":     timestamp,
+			"data":          data,
+		}
+		// 增量格式 (客户端订阅时指定 "format":"delta")
+		if published != nil {
+			if delta := p.extractOddsChangeDelta(published); delta != nil {
+				broadcast["delta"] = delta
+				if localized := p.localizeMessageData(message, published, delta); len(localized) > 0 {
+					broadcast["localized_delta"] = localized
+				}
+			}
+		}
+		// 其它语言的名称 (客户端订阅时指定 "lang")
+		if localized := p.localizeMessageData(message, published, data); len(localized) > 0 {
+			broadcast["localized"] = localized
+		}
+		p.broadcaster.Broadcast(broadcast)
 	}
 
 	// 处理特定消息类型 (从 AMQPConsumer 迁移过来)
-	switch messageType {
-	case "odds_change":
-		p.handleOddsChange(eventID, productID, xmlContent, timestamp)
-	case "bet_stop":
-		p.handleBetStop(eventID, productID, xmlContent, timestamp)
-	case "bet_settlement":
-		p.handleBetSettlement(eventID, productID, xmlContent, timestamp)
-	case "bet_cancel":
-		p.handleBetCancel(eventID, productID, xmlContent, timestamp)
-	case "fixture":
-		p.handleFixture(eventID, productID, xmlContent, timestamp)
-	case "fixture_change":
-		p.handleFixtureChange(eventID, productID, xmlContent, timestamp)
-	case "rollback_bet_settlement":
-		p.handleRollbackBetSettlement(eventID, productID, xmlContent, timestamp)
-	case "rollback_bet_cancel":
-		p.handleRollbackBetCancel(eventID, productID, xmlContent, timestamp)
+	switch message.Type {
+	case uof.TypeOddsChange:
+		p.handleOddsChange(message.OddsChange)
+	case uof.TypeBetStop:
+		p.handleBetStop(message.BetStop)
+	case uof.TypeBetSettlement:
+		p.handleBetSettlement(message.BetSettlement)
+	case uof.TypeBetCancel:
+		p.handleBetCancel(message.BetCancel)
+	case uof.TypeFixture:
+		p.handleFixture(message.Fixture)
+	case uof.TypeFixtureChange:
+		p.handleFixtureChange(message.FixtureChange)
+	case uof.TypeRollbackBetSettlement:
+		p.handleRollbackBetSettlement(message.RollbackBetSettlement)
+	case uof.TypeRollbackBetCancel:
+		p.handleRollbackBetCancel(message.RollbackBetCancel)
+	case uof.TypeMarketDescriptionsChanged:
+		if p.marketDescService != nil {
+			p.marketDescService.HandleDescriptionsChanged(message.MarketDescriptionsChanged.MarketIDs())
+		}
 	default:
 		logger.Printf("[MessageProcessor] Unhandled message type: %s", messageType)
 	}
 }
 
 // extractMessageData 提取用于广播的附加数据 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) extractMessageData(messageType, xmlContent string) interface{} {
+// published 为应用利润率后的 odds_change, 其它类型为 nil
+func (p *MessageProcessor) extractMessageData(message *uof.Message, published *uof.OddsChange) interface{} {
 	// 深度解析和数据增强
-	switch messageType {
-	case "odds_change":
-		return p.extractOddsChangeData(xmlContent)
-	case "bet_stop":
-		return p.extractBetStopData(xmlContent)
-	case "fixture_change":
-		return p.extractFixtureChangeData(xmlContent)
-	case "bet_settlement":
-		return p.extractBetSettlementData(xmlContent)
+	switch message.Type {
+	case uof.TypeOddsChange:
+		// 检查 marketDescService 是否存在
+		if p.marketDescService == nil {
+			logger.Printf("marketDescService is nil in MessageProcessor") // 修复 logger.Error 调用错误
+			return map[string]interface{}{"xml_content": string(message.Raw)}
+		}
+		return p.extractOddsChangeData(published)
+	case uof.TypeBetStop:
+		return p.extractBetStopData(message.BetStop)
+	case uof.TypeFixtureChange:
+		return p.extractFixtureChangeData(message.FixtureChange)
+	case uof.TypeBetSettlement:
+		return p.extractBetSettlementData(message.BetSettlement)
 	default:
 		// 对于其他消息，只返回原始 XML 内容
 		return map[string]interface{}{
-			"xml_content": xmlContent,
+			"xml_content": string(message.Raw),
 		}
 	}
 }
 
-// extractOddsChangeData 提取并增强 odds_change 消息数据
-func (p *MessageProcessor) extractOddsChangeData(xmlContent string) interface{} {
-	var oddsChange OddsChangeMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &oddsChange); err != nil {
-		logger.Errorf("Failed to parse odds_change for broadcast: %v", err)
-		return map[string]interface{}{"xml_content": xmlContent}
+// publishOddsChange 复制 odds_change 并应用利润率 (广播发布赔率, 同时保留原始赔率)
+func (p *MessageProcessor) publishOddsChange(oddsChange *uof.OddsChange) *uof.OddsChange {
+	published := oddsChange.Clone()
+	if p.marginService != nil {
+		p.marginService.ApplyToOddsChange(published)
 	}
+	return published
+}
 
-		// 检查 marketDescService 是否存在
-		if p.marketDescService == nil {
-			logger.Printf("marketDescService is nil in MessageProcessor") // 修复 logger.Error 调用错误
-			return map[string]interface{}{"xml_content": xmlContent}
-		}
-
+// extractOddsChangeData 提取并增强 odds_change 消息数据
+func (p *MessageProcessor) extractOddsChangeData(oddsChange *uof.OddsChange) interface{} {
 	// 提取比分和状态信息
 	var homeScore, awayScore *int
 	var matchStatus, status string
+	var eventStatus *EventStatusDetail
 	if oddsChange.SportEventStatus != nil {
 		ses := oddsChange.SportEventStatus
 		homeScore = ses.HomeScore
 		awayScore = ses.AwayScore
 		matchStatus = ses.MatchStatus
 		status = ses.Status
+		eventStatus = NewEventStatusDetail(oddsChange.EventID, ses, EventStatusSourceOddsChange, oddsChange.Timestamp)
 	}
 
 	// 提取队伍名称
@@ -205,36 +262,48 @@ func (p *MessageProcessor) extractOddsChangeData(xmlContent string) interface{}
 		}
 	}
 
+	// 更新让球 / 大小球的主盘口
+	mainLines := p.mainLineTracker.Update(oddsChange)
+	mainLineSpecifiers := make(map[string]string, len(mainLines))
+	for _, line := range mainLines {
+		mainLineSpecifiers[mainLineFamilyKey(line.MarketID, line.Family)] = line.Specifier
+	}
+
+	// 名称模板使用的参赛方
+	competitors := p.eventCompetitors(oddsChange.EventID, oddsChange.SportEvent.Competitors)
+
 	// 提取市场和赔率信息 (简化，只提取关键信息)
 		markets := make([]map[string]interface{}, 0)
 		for _, market := range oddsChange.Odds.Markets {
 			// 构造 ReplacementContext
-			ctx := &ReplacementContext{
-				Specifiers: market.Specifier,
-				// HomeTeamName 和 AwayTeamName 可以在这里添加，但为了简化，暂时只用 Specifiers
-			}
+			ctx := NewReplacementContext(competitors, market.Specifiers)
 			
 			// 修复 GetMarketName 参数错误: 需要 string 类型的 marketID, specifiers, 和 ctx
 			// 假设 market.ID 是 int，需要转换为 string
 			marketIDStr := strconv.Itoa(market.ID)
-			marketName := p.marketDescService.GetMarketName(marketIDStr, market.Specifier, ctx)
+			marketName := p.marketDescService.GetMarketName(marketIDStr, market.Specifiers, ctx)
 			
 			outcomes := make([]map[string]interface{}, 0)
 			for _, outcome := range market.Outcomes {
-				// 修复 outcome.Name undefined 错误: 移除 Name 字段，因为它不在 OddsChangeMessage.Outcome 结构体中
-				// 结果名称需要通过 GetOutcomeName 获取，但为了简化，暂时只返回 ID 和 Odds
 				outcomes = append(outcomes, map[string]interface{}{
 					"id": outcome.ID,
+					"name": p.marketDescService.GetOutcomeName(marketIDStr, outcome.ID, market.Specifiers, ctx),
 					"odds": outcome.Odds,
 					"active": outcome.Active,
 				})
 			}
 
+			isMainLine := false
+			if family, _, isLine := SplitLineSpecifier(market.Specifiers); isLine {
+				isMainLine = mainLineSpecifiers[mainLineFamilyKey(market.ID, family)] == market.Specifiers
+			}
+
 			markets = append(markets, map[string]interface{}{
 				"id": market.ID,
-				"specifier": market.Specifier,
+				"specifier": market.Specifiers,
 				"name": marketName,
 				"status": market.Status,
+				"is_main_line": isMainLine,
 				"outcomes": outcomes,
 			})
 		}
@@ -250,17 +319,56 @@ func (p *MessageProcessor) extractOddsChangeData(xmlContent string) interface{}
 		"home_team_name": homeTeamName,
 		"away_team_name": awayTeamName,
 		"markets": markets,
+		"main_lines": mainLines,
+		"sport_event_status": eventStatus,
 	}
 }
 
-// extractBetStopData 提取并增强 bet_stop 消息数据
-func (p *MessageProcessor) extractBetStopData(xmlContent string) interface{} {
-	var betStop BetStopMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &betStop); err != nil {
-		logger.Errorf("Failed to parse bet_stop for broadcast: %v", err)
-		return map[string]interface{}{"xml_content": xmlContent}
+// extractOddsChangeDelta 提取 odds_change 的增量数据
+// 只包含与上次广播相比赔率、active 或盘口状态有变化的结果
+func (p *MessageProcessor) extractOddsChangeDelta(oddsChange *uof.OddsChange) interface{} {
+	var homeScore, awayScore *int
+	var matchStatus, status string
+	if oddsChange.SportEventStatus != nil {
+		ses := oddsChange.SportEventStatus
+		homeScore = ses.HomeScore
+		awayScore = ses.AwayScore
+		matchStatus = ses.MatchStatus
+		status = ses.Status
+	}
+
+	// 主盘口已在 extractOddsChangeData 中按本条消息更新
+	mainLines := p.mainLineTracker.MainLines(oddsChange)
+	markets := p.oddsStateCache.Diff(oddsChange)
+	competitors := p.eventCompetitors(oddsChange.EventID, oddsChange.SportEvent.Competitors)
+	for _, market := range markets {
+		marketID, specifiers := market["id"].(int), market["specifier"].(string)
+		market["is_main_line"] = p.mainLineTracker.IsMainLine(oddsChange.EventID, marketID, specifiers)
+
+		// 与完整格式相同的英文名称, 其它语言由 localizeMessageData 替换
+		ctx := NewReplacementContext(competitors, specifiers)
+		marketIDStr := strconv.Itoa(marketID)
+		market["name"] = p.marketDescService.GetMarketName(marketIDStr, specifiers, ctx)
+		for _, outcome := range market["outcomes"].([]map[string]interface{}) {
+			outcome["name"] = p.marketDescService.GetOutcomeName(marketIDStr, outcome["id"].(string), specifiers, ctx)
+		}
 	}
 
+	return map[string]interface{}{
+		"event_id":     oddsChange.EventID,
+		"product_id":   oddsChange.ProductID,
+		"timestamp":    oddsChange.Timestamp,
+		"home_score":   homeScore,
+		"away_score":   awayScore,
+		"match_status": matchStatus,
+		"status":       status,
+		"markets":      markets,
+		"main_lines":   mainLines,
+	}
+}
+
+// extractBetStopData 提取并增强 bet_stop 消息数据
+func (p *MessageProcessor) extractBetStopData(betStop *uof.BetStop) interface{} {
 	return map[string]interface{}{
 		"event_id": betStop.EventID,
 		"product_id": betStop.ProductID,
@@ -272,19 +380,7 @@ func (p *MessageProcessor) extractBetStopData(xmlContent string) interface{} {
 }
 
 // extractFixtureChangeData 提取并增强 fixture_change 消息数据
-func (p *MessageProcessor) extractFixtureChangeData(xmlContent string) interface{} {
-	type FixtureChange struct {
-		StartTime    int64 `xml:"start_time,attr"`
-		NextLiveTime int64 `xml:"next_live_time,attr"`
-		ChangeType   int   `xml:"change_type,attr"`
-		ProductID    int   `xml:"product,attr"`
-	}
-	var fixtureChange FixtureChange
-	if err := xml.Unmarshal([]byte(xmlContent), &fixtureChange); err != nil {
-		logger.Errorf("Failed to parse fixture_change for broadcast: %v", err)
-		return map[string]interface{}{"xml_content": xmlContent}
-	}
-
+func (p *MessageProcessor) extractFixtureChangeData(fixtureChange *uof.FixtureChange) interface{} {
 	// 尝试获取最新的赛事信息（假设 fixtureService 提供了 GetTrackedEventInfo 方法）
 	// 由于没有看到 GetTrackedEventInfo，我们只返回 change 消息的关键信息
 	
@@ -315,49 +411,118 @@ func (p *MessageProcessor) extractFixtureChangeData(xmlContent string) interface
 	}
 }
 
-// extractBetSettlementData 提取并增强 bet_settlement 消息数据
-func (p *MessageProcessor) extractBetSettlementData(xmlContent string) interface{} {
-	// 简化处理，只提取关键信息
-	type BetSettlement struct {
-		EventID string `xml:"event_id,attr"`
-		ProductID int `xml:"product,attr"`
-		Timestamp int64 `xml:"timestamp,attr"`
-		Markets []struct {
-			ID string `xml:"id,attr"`
-			Specifier string `xml:"specifiers,attr"` // 新增 specifier 字段
-			Outcomes []struct {
-				ID string `xml:"id,attr"`
-				Status string `xml:"status,attr"` // Won, Lost, Half_Won, Half_Lost, Void
-			} `xml:"outcome"`
-		} `xml:"market"`
-	}
-	var settlement BetSettlement
-	if err := xml.Unmarshal([]byte(xmlContent), &settlement); err != nil {
-		logger.Errorf("Failed to parse bet_settlement for broadcast: %v", err)
-		return map[string]interface{}{"xml_content": xmlContent}
+// eventCompetitors 返回名称模板使用的参赛方: 优先使用消息中的参赛方, 否则使用 tracked_events 中保存的列表
+func (p *MessageProcessor) eventCompetitors(eventID string, competitors []uof.Competitor) []EventCompetitor {
+	if len(competitors) > 0 {
+		return EventCompetitorsFrom(competitors)
+	}
+	if p.eventMetaCache == nil {
+		return nil
+	}
+	return p.eventMetaCache.Get(eventID).Competitors
+}
+
+// localizeMessageData 为其它配置语言重新渲染广播数据中的队伍 / 盘口 / 结果名称
+// 只处理 odds_change 和 bet_settlement, 返回 lang -> 数据副本
+func (p *MessageProcessor) localizeMessageData(message *uof.Message, published *uof.OddsChange, data interface{}) map[string]interface{} {
+	languages := p.localization.ExtraLanguages()
+	payload, ok := data.(map[string]interface{})
+	if len(languages) == 0 || !ok || p.marketDescService == nil {
+		return nil
+	}
+
+	var competitors []EventCompetitor
+	switch message.Type {
+	case uof.TypeOddsChange:
+		competitors = p.eventCompetitors(message.EventID, published.SportEvent.Competitors)
+	case uof.TypeBetSettlement:
+		competitors = p.eventCompetitors(message.EventID, nil)
+	default:
+		return nil
+	}
+
+	localized := make(map[string]interface{}, len(languages))
+	for _, lang := range languages {
+		localized[lang] = p.localizePayload(payload, competitors, lang)
+	}
+	return localized
+}
+
+// localizePayload 返回广播数据的副本, 队伍 / 盘口 / 结果名称替换为指定语言 (缺少翻译时为英文)
+func (p *MessageProcessor) localizePayload(payload map[string]interface{}, competitors []EventCompetitor, lang string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		copied[k] = v
+	}
+
+	localizedCompetitors := p.localization.LocalizeCompetitors(competitors, lang)
+	for _, comp := range localizedCompetitors {
+		key := comp.Qualifier + "_team_name"
+		if name, ok := copied[key].(string); ok && name != "" {
+			copied[key] = comp.Name
+		}
+	}
+
+	markets, ok := payload["markets"].([]map[string]interface{})
+	if !ok {
+		return copied
+	}
+
+	localizedMarkets := make([]map[string]interface{}, 0, len(markets))
+	for _, market := range markets {
+		marketID := fmt.Sprint(market["id"])
+		specifiers, _ := market["specifier"].(string)
+		ctx := NewReplacementContext(localizedCompetitors, specifiers)
+		ctx.Lang = lang
+
+		copiedMarket := make(map[string]interface{}, len(market))
+		for k, v := range market {
+			copiedMarket[k] = v
+		}
+		copiedMarket["name"] = p.marketDescService.GetMarketName(marketID, specifiers, ctx)
+
+		if outcomes, ok := market["outcomes"].([]map[string]interface{}); ok {
+			localizedOutcomes := make([]map[string]interface{}, 0, len(outcomes))
+			for _, outcome := range outcomes {
+				copiedOutcome := make(map[string]interface{}, len(outcome))
+				for k, v := range outcome {
+					copiedOutcome[k] = v
+				}
+				copiedOutcome["name"] = p.marketDescService.GetOutcomeName(marketID, fmt.Sprint(outcome["id"]), specifiers, ctx)
+				localizedOutcomes = append(localizedOutcomes, copiedOutcome)
+			}
+			copiedMarket["outcomes"] = localizedOutcomes
+		}
+		localizedMarkets = append(localizedMarkets, copiedMarket)
 	}
+	copied["markets"] = localizedMarkets
+	return copied
+}
 
+// extractBetSettlementData 提取并增强 bet_settlement 消息数据
+func (p *MessageProcessor) extractBetSettlementData(settlement *uof.BetSettlement) interface{} {
+	competitors := p.eventCompetitors(settlement.EventID, nil)
 	markets := make([]map[string]interface{}, 0)
-		for _, market := range settlement.Markets {
+		for _, market := range settlement.Outcomes.Markets {
 			// 构造 ReplacementContext
-			ctx := &ReplacementContext{
-				Specifiers: market.Specifier,
-			}
+			ctx := NewReplacementContext(competitors, market.Specifiers)
 			
 			// 修复 GetMarketName 参数错误: 需要 string 类型的 marketID, specifiers, 和 ctx
-			marketName := p.marketDescService.GetMarketName(market.ID, market.Specifier, ctx)
+			marketID := strconv.Itoa(market.ID)
+			marketName := p.marketDescService.GetMarketName(marketID, market.Specifiers, ctx)
 			
 			outcomes := make([]map[string]interface{}, 0)
 			for _, outcome := range market.Outcomes {
 				outcomes = append(outcomes, map[string]interface{}{
 					"id": outcome.ID,
-					"status": outcome.Status,
+					"name": p.marketDescService.GetOutcomeName(marketID, outcome.ID, market.Specifiers, ctx),
+					"result": outcome.Result,
 				})
 			}
 
 			markets = append(markets, map[string]interface{}{
 				"id": market.ID,
-				"specifier": market.Specifier,
+				"specifier": market.Specifiers,
 				"name": marketName,
 				"outcomes": outcomes,
 			})
@@ -372,57 +537,98 @@ func (p *MessageProcessor) extractBetSettlementData(xmlContent string) interface
 }
 
 // handleOddsChange 处理 odds_change 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleOddsChange(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.oddsChangeParser.ParseAndStore(xmlContent); err != nil {
+func (p *MessageProcessor) handleOddsChange(oddsChange *uof.OddsChange) {
+	if err := p.oddsChangeParser.Store(oddsChange); err != nil {
 		logger.Errorf("Failed to handle odds_change: %v", err)
 	}
+
+	// 存储盘口和赔率 (markets / odds), 供快照和查询接口使用
+	if err := p.oddsParser.StoreOdds(oddsChange, oddsChange.ProductID); err != nil {
+		logger.Errorf("Failed to store odds for %s: %v", oddsChange.EventID, err)
+	}
+
+	p.evaluateOddsAlerts(oddsChange)
+}
+
+// evaluateOddsAlerts 用原始赔率检查告警规则, 触发的告警保存后推送到 trader 告警流并发送通知
+func (p *MessageProcessor) evaluateOddsAlerts(oddsChange *uof.OddsChange) {
+	eventID := oddsChange.EventID
+	meta := p.eventMetaCache.Get(eventID)
+	alerts := p.oddsAlertService.Evaluate(oddsChange, meta)
+	if len(alerts) == 0 {
+		return
+	}
+
+	if err := p.oddsAlertService.RecordAlerts(alerts); err != nil {
+		logger.Errorf("Failed to record odds alerts for %s: %v", eventID, err)
+	}
+
+	if p.broadcaster != nil {
+		for _, alert := range alerts {
+			p.broadcaster.Broadcast(map[string]interface{}{
+				"type":          "trader_alert",
+				"message_type":  "odds_alert",
+				"event_id":      eventID,
+				"sport_id":      meta.SportID,
+				"tournament_id": meta.TournamentID,
+				"timestamp":     alert.Timestamp,
+				"data":          alert,
+			})
+		}
+	}
+
+	if p.larkNotifier != nil {
+		if ok, suppressed := p.oddsAlertService.AllowNotify(len(alerts)); ok {
+			go p.larkNotifier.NotifyOddsAlerts(alerts, suppressed)
+		}
+	}
 }
 
 // handleBetStop 处理 bet_stop 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleBetStop(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.betStopProcessor.ProcessBetStop(xmlContent); err != nil {
+func (p *MessageProcessor) handleBetStop(betStop *uof.BetStop) {
+	if err := p.betStopProcessor.ProcessBetStop(betStop); err != nil {
 		logger.Errorf("Failed to handle bet_stop: %v", err)
 	}
 }
 
 // handleBetSettlement 处理 bet_settlement 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleBetSettlement(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.betSettlementParser.ParseAndStore(xmlContent); err != nil {
+func (p *MessageProcessor) handleBetSettlement(settlement *uof.BetSettlement) {
+	if err := p.betSettlementParser.Store(settlement); err != nil {
 		logger.Errorf("Failed to handle bet_settlement: %v", err)
 	}
 }
 
 // handleBetCancel 处理 bet_cancel 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleBetCancel(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.betCancelProcessor.ProcessBetCancel(xmlContent); err != nil {
+func (p *MessageProcessor) handleBetCancel(betCancel *uof.BetCancel) {
+	if err := p.betCancelProcessor.ProcessBetCancel(betCancel); err != nil {
 		logger.Errorf("Failed to handle bet_cancel: %v", err)
 	}
 }
 
 // handleFixture 处理 fixture 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleFixture(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.fixtureParser.ParseAndStore(xmlContent); err != nil {
+func (p *MessageProcessor) handleFixture(fixture *uof.Fixture) {
+	if err := p.fixtureParser.Store(fixture); err != nil {
 		logger.Errorf("Failed to handle fixture: %v", err)
 	}
 }
 
 // handleFixtureChange 处理 fixture_change 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleFixtureChange(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.fixtureParser.ParseFixtureChange(eventID, xmlContent); err != nil {
+func (p *MessageProcessor) handleFixtureChange(fixtureChange *uof.FixtureChange) {
+	if err := p.fixtureParser.ProcessFixtureChange(fixtureChange); err != nil {
 		logger.Errorf("Failed to handle fixture_change: %v", err)
 	}
 }
 
 // handleRollbackBetSettlement 处理 rollback_bet_settlement 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleRollbackBetSettlement(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.rollbackBetSettlementProc.ProcessRollbackBetSettlement(xmlContent); err != nil {
+func (p *MessageProcessor) handleRollbackBetSettlement(rollback *uof.RollbackBetSettlement) {
+	if err := p.rollbackBetSettlementProc.ProcessRollbackBetSettlement(rollback); err != nil {
 		logger.Errorf("Failed to handle rollback_bet_settlement: %v", err)
 	}
 }
 
 // handleRollbackBetCancel 处理 rollback_bet_cancel 消息 (从 AMQPConsumer 迁移过来)
-func (p *MessageProcessor) handleRollbackBetCancel(eventID string, productID *int, xmlContent string, timestamp int64) {
-	if err := p.rollbackBetCancelProc.ProcessRollbackBetCancel(xmlContent); err != nil {
+func (p *MessageProcessor) handleRollbackBetCancel(rollback *uof.RollbackBetCancel) {
+	if err := p.rollbackBetCancelProc.ProcessRollbackBetCancel(rollback); err != nil {
 		logger.Errorf("Failed to handle rollback_bet_cancel: %v", err)
 	}
 }
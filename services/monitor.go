@@ -225,7 +225,7 @@ func (m *Monitor) printHourlyReport() {
 		logger.Println("   3. Account doesn't have odds feed permission")
 	}
 	
-	logger.Println("═══════════════════════════════════════════════════════════\n")
+	logger.Printf("═══════════════════════════════════════════════════════════\n\n")
 }
 
 // GetStats 获取统计信息
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UOF 名称模板 (market / outcome 描述中的 name) 支持的占位符:
+//
+//	{X}              specifier X 的值
+//	{!X}             specifier X 的序数 (1st, 2nd, 3rd ...)
+//	{X+c} / {X-c}    specifier X 的值加减常数, 可与 ! 组合: {!X+1}, {!(X+1)}
+//	{+X}             带符号的 X (正数加 +)
+//	{-X}             X 取反后带符号
+//	{$competitorN}   第 N 个参赛方名称 (1=主队, 2=客队, N>2 为参赛方列表中的顺序)
+//	{$event}         赛事名称
+//	{%X}             specifier X 中的 player / competitor URN 对应的名称
+//
+// 无法解析的占位符原样保留
+
+// ParseSpecifiers 解析 specifiers 字符串 (例如 "hcp=1.5|variant=sr:exact_goals:5+")
+func ParseSpecifiers(specifiers string) map[string]string {
+	values := make(map[string]string)
+	if specifiers == "" {
+		return values
+	}
+	for _, pair := range strings.Split(specifiers, "|") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = parts[1]
+		}
+	}
+	return values
+}
+
+// RenderNameTemplate 渲染名称模板
+// resolve 用于把 {%X} 中不在 ctx.Competitors 里的 URN 转换为名称 (例如 PlayersService.GetPlayerName), 可为 nil
+func RenderNameTemplate(template string, specifiers map[string]string, ctx *ReplacementContext, resolve func(urn string) string) string {
+	if !strings.ContainsAny(template, "{$") {
+		return template
+	}
+
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(rest[:start])
+		token := rest[start+1 : end]
+		if value, ok := renderToken(token, specifiers, ctx, resolve); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(rest[start : end+1])
+		}
+		rest = rest[end+1:]
+	}
+	b.WriteString(rest)
+
+	// 兼容不带花括号的 $competitor1 / $competitor2
+	name := b.String()
+	if ctx != nil && strings.Contains(name, "$competitor") {
+		name = strings.ReplaceAll(name, "$competitor1", ctx.competitorName(1))
+		name = strings.ReplaceAll(name, "$competitor2", ctx.competitorName(2))
+	}
+	return name
+}
+
+// renderToken 渲染单个占位符 (不含花括号), 无法解析时返回 false
+func renderToken(token string, specifiers map[string]string, ctx *ReplacementContext, resolve func(urn string) string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+
+	switch token[0] {
+	case '$':
+		return renderEntityToken(token[1:], ctx)
+	case '%':
+		urn, ok := specifiers[token[1:]]
+		if !ok {
+			return "", false
+		}
+		if ctx != nil {
+			if name, ok := ctx.Competitors[urn]; ok {
+				return name, true
+			}
+		}
+		if resolve != nil {
+			if name := resolve(urn); name != "" {
+				return name, true
+			}
+		}
+		return urn, true
+	case '!':
+		value, ok := evalSpecifierExpr(token[1:], specifiers)
+		if !ok {
+			return "", false
+		}
+		return ordinal(value), true
+	case '+':
+		value, ok := evalSpecifierExpr(token[1:], specifiers)
+		if !ok {
+			return "", false
+		}
+		return signed(value), true
+	case '-':
+		value, ok := evalSpecifierExpr(token[1:], specifiers)
+		if !ok {
+			return "", false
+		}
+		return signed(negate(value)), true
+	default:
+		return evalSpecifierExpr(token, specifiers)
+	}
+}
+
+// renderEntityToken 渲染 $event / $competitorN
+func renderEntityToken(name string, ctx *ReplacementContext) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	if name == "event" {
+		if ctx.EventName != "" {
+			return ctx.EventName, true
+		}
+		if ctx.HomeTeamName != "" && ctx.AwayTeamName != "" {
+			return ctx.HomeTeamName + " vs " + ctx.AwayTeamName, true
+		}
+		return "", false
+	}
+	if !strings.HasPrefix(name, "competitor") {
+		return "", false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(name, "competitor"))
+	if err != nil || n < 1 {
+		return "", false
+	}
+	if competitor := ctx.competitorName(n); competitor != "" {
+		return competitor, true
+	}
+	return "", false
+}
+
+// competitorName 返回第 n 个参赛方名称 (从 1 开始)
+func (ctx *ReplacementContext) competitorName(n int) string {
+	switch {
+	case n == 1 && ctx.HomeTeamName != "":
+		return ctx.HomeTeamName
+	case n == 2 && ctx.AwayTeamName != "":
+		return ctx.AwayTeamName
+	case n <= len(ctx.CompetitorNames):
+		return ctx.CompetitorNames[n-1]
+	}
+	return ""
+}
+
+// evalSpecifierExpr 计算 X / X+c / X-c / (X+c), 返回 specifier 值 (原始字符串或计算结果)
+func evalSpecifierExpr(expr string, specifiers map[string]string) (string, bool) {
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "("), ")")
+
+	if value, ok := specifiers[expr]; ok {
+		return value, true
+	}
+
+	// 从第二个字符开始找运算符, specifier 名称本身不以 + / - 开头
+	if len(expr) < 2 {
+		return "", false
+	}
+	op := strings.IndexAny(expr[1:], "+-")
+	if op < 0 {
+		return "", false
+	}
+	op++
+	value, ok := specifiers[expr[:op]]
+	if !ok {
+		return "", false
+	}
+	base, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", false
+	}
+	delta, err := strconv.ParseFloat(expr[op+1:], 64)
+	if err != nil {
+		return "", false
+	}
+	if expr[op] == '-' {
+		delta = -delta
+	}
+	return strconv.FormatFloat(base+delta, 'f', -1, 64), true
+}
+
+// ordinal 整数转为英文序数 (1st, 2nd, 3rd, 4th, 11th, 21st ...), 非整数原样返回
+func ordinal(value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return value
+	}
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// signed 正数加 + 号, 0 和负数原样返回 (非数字原样返回)
+func signed(value string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		return value
+	}
+	if strings.HasPrefix(value, "+") {
+		return value
+	}
+	return "+" + value
+}
+
+// negate 数值取反, 保留原始的小数位写法 (非数字原样返回)
+func negate(value string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f == 0 {
+		return value
+	}
+	switch {
+	case strings.HasPrefix(value, "-"):
+		return value[1:]
+	case strings.HasPrefix(value, "+"):
+		return "-" + value[1:]
+	default:
+		return "-" + value
+	}
+}
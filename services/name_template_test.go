@@ -0,0 +1,124 @@
+package services
+
+import "testing"
+
+// 用例来自 Betradar UOF 文档 "Market and outcome name templates" 中的示例
+func TestRenderNameTemplate(t *testing.T) {
+	match := &ReplacementContext{HomeTeamName: "Bayern Munich", AwayTeamName: "Borussia Dortmund"}
+	outright := &ReplacementContext{
+		CompetitorNames: []string{"Max Verstappen", "Lewis Hamilton", "Charles Leclerc"},
+		Competitors: map[string]string{
+			"sr:competitor:4521": "Max Verstappen",
+			"sr:competitor:7135": "Lewis Hamilton",
+		},
+	}
+	players := map[string]string{"sr:player:1047": "Robert Lewandowski"}
+	resolve := func(urn string) string { return players[urn] }
+
+	tests := []struct {
+		name       string
+		template   string
+		specifiers string
+		ctx        *ReplacementContext
+		want       string
+	}{
+		// {X}
+		{"plain specifier", "Total {total}", "total=2.5", nil, "Total 2.5"},
+		{"multiple specifiers", "{!setnr} set - race to {pointnr} points", "setnr=2|pointnr=10", nil, "2nd set - race to 10 points"},
+
+		// {!X}
+		{"ordinal 1st", "{!goalnr} goal", "goalnr=1", nil, "1st goal"},
+		{"ordinal 2nd", "{!periodnr} period - total", "periodnr=2", nil, "2nd period - total"},
+		{"ordinal 3rd", "{!inningnr} inning", "inningnr=3", nil, "3rd inning"},
+		{"ordinal 4th", "{!quarternr} quarter", "quarternr=4", nil, "4th quarter"},
+		{"ordinal 11th", "{!goalnr} goal", "goalnr=11", nil, "11th goal"},
+		{"ordinal 12th", "{!goalnr} goal", "goalnr=12", nil, "12th goal"},
+		{"ordinal 13th", "{!goalnr} goal", "goalnr=13", nil, "13th goal"},
+		{"ordinal 21st", "{!mapnr} map", "mapnr=21", nil, "21st map"},
+		{"ordinal 102nd", "{!pointnr} point", "pointnr=102", nil, "102nd point"},
+		{"ordinal 111th", "{!pointnr} point", "pointnr=111", nil, "111th point"},
+
+		// {X+c} / {X-c} / {!X+c}
+		{"arithmetic plus", "Race to {score+1}", "score=3", nil, "Race to 4"},
+		{"arithmetic minus", "{goalnr-1} goals", "goalnr=3", nil, "2 goals"},
+		{"ordinal arithmetic", "{!inningnr+1} inning", "inningnr=2", nil, "3rd inning"},
+		{"ordinal arithmetic parens", "{!(inningnr+1)} inning", "inningnr=1", nil, "2nd inning"},
+
+		// {+X} / {-X}
+		{"plus positive", "{$competitor1} ({+hcp})", "hcp=1.5", match, "Bayern Munich (+1.5)"},
+		{"plus negative", "{$competitor1} ({+hcp})", "hcp=-1.5", match, "Bayern Munich (-1.5)"},
+		{"plus zero", "{$competitor1} ({+hcp})", "hcp=0", match, "Bayern Munich (0)"},
+		{"minus positive", "{$competitor2} ({-hcp})", "hcp=1.5", match, "Borussia Dortmund (-1.5)"},
+		{"minus negative", "{$competitor2} ({-hcp})", "hcp=-0.25", match, "Borussia Dortmund (+0.25)"},
+		{"minus zero", "{$competitor2} ({-hcp})", "hcp=0", match, "Borussia Dortmund (0)"},
+		{"non-numeric handicap", "Handicap {+hcp}", "hcp=0:1", nil, "Handicap 0:1"},
+
+		// {$competitorN} / {$event}
+		{"competitors", "{$competitor1} to win", "", match, "Bayern Munich to win"},
+		{"legacy unbraced competitor", "$competitor2 total", "", match, "Borussia Dortmund total"},
+		{"competitor N > 2", "{$competitor3} to finish on podium", "", outright, "Charles Leclerc to finish on podium"},
+		{"competitor out of range", "{$competitor4} wins", "", outright, "{$competitor4} wins"},
+		{"event from teams", "{$event} - total goals", "", match, "Bayern Munich vs Borussia Dortmund - total goals"},
+		{"event name", "{$event} winner", "", &ReplacementContext{EventName: "Formula 1 2026"}, "Formula 1 2026 winner"},
+
+		// {%X}
+		{"player", "{%player} to score", "player=sr:player:1047", nil, "Robert Lewandowski to score"},
+		{"competitor profile", "{%competitor} head to head", "competitor=sr:competitor:7135", outright, "Lewis Hamilton head to head"},
+		{"unknown profile keeps urn", "{%player} total shots", "player=sr:player:9", nil, "sr:player:9 total shots"},
+
+		// 无法解析
+		{"missing specifier", "Total {total}", "", nil, "Total {total}"},
+		{"no template", "1x2", "", match, "1x2"},
+		{"unclosed brace", "Total {total", "total=2.5", nil, "Total {total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderNameTemplate(tt.template, ParseSpecifiers(tt.specifiers), tt.ctx, resolve)
+			if got != tt.want {
+				t.Errorf("RenderNameTemplate(%q, %q) = %q, want %q", tt.template, tt.specifiers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSpecifiers(t *testing.T) {
+	got := ParseSpecifiers("hcp=1.5|variant=sr:exact_goals:5+|bad")
+	if len(got) != 2 || got["hcp"] != "1.5" || got["variant"] != "sr:exact_goals:5+" {
+		t.Errorf("ParseSpecifiers = %v", got)
+	}
+	if got := ParseSpecifiers(""); len(got) != 0 {
+		t.Errorf("ParseSpecifiers(\"\") = %v, want empty", got)
+	}
+}
+
+func TestGetOutcomeNameUsesTemplateEngine(t *testing.T) {
+	s := &MarketDescriptionsService{
+		markets: map[string]*MarketDescription{
+			"16": {ID: "16", Name: "Handicap {hcp}"},
+		},
+		outcomes: map[string]map[string]*OutcomeDescription{
+			"16": {
+				"1714": {ID: "1714", Name: "{$competitor1} ({+hcp})"},
+				"1715": {ID: "1715", Name: "{$competitor2} ({-hcp})"},
+			},
+		},
+		mappings: map[string]map[string]string{},
+	}
+	ctx := &ReplacementContext{HomeTeamName: "Home", AwayTeamName: "Away"}
+
+	if got := s.GetMarketName("16", "hcp=-1.5", ctx); got != "Handicap -1.5" {
+		t.Errorf("GetMarketName = %q", got)
+	}
+	if got := s.GetOutcomeName("16", "1714", "hcp=-1.5", ctx); got != "Home (-1.5)" {
+		t.Errorf("GetOutcomeName(1714) = %q", got)
+	}
+	if got := s.GetOutcomeName("16", "1715", "hcp=-1.5", ctx); got != "Away (+1.5)" {
+		t.Errorf("GetOutcomeName(1715) = %q", got)
+	}
+
+	outright := NewReplacementContext([]EventCompetitor{{ID: "sr:competitor:1", Name: "Team One"}}, "")
+	if got := s.GetOutcomeName("534", "sr:competitor:1", "", outright); got != "Team One" {
+		t.Errorf("GetOutcomeName(outright) = %q", got)
+	}
+}
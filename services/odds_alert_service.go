@@ -0,0 +1,601 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"uof-service/logger"
+	"uof-service/uof"
+)
+
+// 告警规则类型
+const (
+	AlertRulePriceMove       = "price_move"       // 结果赔率在 window 内变动超过 threshold%
+	AlertRuleSuspensionCount = "suspension_count" // 盘口在 window 内暂停超过 threshold 次
+	AlertRulePrematchDrift   = "prematch_drift"   // prematch 盘口赔率在 window 内漂移超过 threshold%, 且更新次数不超过 max_updates (低流动性)
+)
+
+// 告警级别
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// OddsAlertRule 赔率告警规则
+// sport_id / sr_market_id 为空、producer_id 为 0 表示不限
+type OddsAlertRule struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	RuleType        string    `json:"rule_type"`
+	SportID         string    `json:"sport_id"`
+	SRMarketID      string    `json:"sr_market_id"`
+	ProducerID      int       `json:"producer_id"`
+	Threshold       float64   `json:"threshold"`
+	WindowSeconds   int       `json:"window_seconds"`
+	MaxUpdates      int       `json:"max_updates"`
+	CooldownSeconds int       `json:"cooldown_seconds"`
+	Severity        string    `json:"severity"`
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Validate 校验规则
+func (r *OddsAlertRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.RuleType {
+	case AlertRulePriceMove, AlertRulePrematchDrift:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("threshold (percent) must be > 0")
+		}
+	case AlertRuleSuspensionCount:
+		if r.Threshold < 1 {
+			return fmt.Errorf("threshold (count) must be >= 1")
+		}
+	default:
+		return fmt.Errorf("rule_type must be one of %s, %s, %s", AlertRulePriceMove, AlertRuleSuspensionCount, AlertRulePrematchDrift)
+	}
+	if r.WindowSeconds <= 0 || r.WindowSeconds > 86400 {
+		return fmt.Errorf("window_seconds must be between 1 and 86400")
+	}
+	if r.MaxUpdates < 0 || r.CooldownSeconds < 0 {
+		return fmt.Errorf("max_updates and cooldown_seconds must be >= 0")
+	}
+	switch r.Severity {
+	case AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical:
+	default:
+		return fmt.Errorf("severity must be one of %s, %s, %s", AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical)
+	}
+	return nil
+}
+
+// matches 判断规则是否适用于该盘口
+func (r *OddsAlertRule) matches(meta EventMeta, marketID string, productID int) bool {
+	if r.SportID != "" && r.SportID != meta.SportID {
+		return false
+	}
+	if r.SRMarketID != "" && r.SRMarketID != marketID {
+		return false
+	}
+	if r.RuleType == AlertRulePrematchDrift && productID != 3 {
+		return false
+	}
+	if r.ProducerID != 0 && r.ProducerID != productID {
+		return false
+	}
+	return true
+}
+
+// OddsAlert 触发的告警
+type OddsAlert struct {
+	ID            int64     `json:"id"`
+	RuleID        int       `json:"rule_id"`
+	RuleName      string    `json:"rule_name"`
+	RuleType      string    `json:"rule_type"`
+	Severity      string    `json:"severity"`
+	EventID       string    `json:"event_id"`
+	SportID       string    `json:"sport_id"`
+	SRMarketID    string    `json:"sr_market_id"`
+	Specifiers    string    `json:"specifiers"`
+	OutcomeID     string    `json:"outcome_id,omitempty"`
+	ProducerID    int       `json:"producer_id"`
+	Value         float64   `json:"value"` // 变动百分比或暂停次数
+	Threshold     float64   `json:"threshold"`
+	WindowSeconds int       `json:"window_seconds"`
+	OddsFrom      *float64  `json:"odds_from,omitempty"`
+	OddsTo        *float64  `json:"odds_to,omitempty"`
+	Message       string    `json:"message"`
+	Timestamp     int64     `json:"timestamp"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type alertOddsPoint struct {
+	ts   int64
+	odds float64
+}
+
+type alertEventState struct {
+	outcomes     map[string][]alertOddsPoint // key: market|specifiers|outcome, 只记录赔率变化
+	suspensions  map[string][]int64          // key: market|specifiers, 进入暂停的时间
+	marketStatus map[string]int
+	updatedAt    time.Time
+}
+
+// OddsAlertService 赔率告警规则引擎
+// 规则缓存在内存中, 超过 ttl 后重新加载; 赔率/暂停历史按赛事保存在内存中, 只保留规则所需的最长窗口
+type OddsAlertService struct {
+	db  *sql.DB
+	ttl time.Duration
+
+	rules    []OddsAlertRule
+	loadedAt time.Time
+	rulesMu  sync.RWMutex
+
+	events    map[string]*alertEventState
+	lastAlert map[string]int64 // key: rule|event|market|specifiers|outcome -> 告警时间戳
+	lastGC    time.Time
+	mu        sync.Mutex
+
+	// 通知限流: 每分钟最多 notifyPerMinute 条通知, 超出的告警计入下一条通知
+	notifyPerMinute   int
+	notifyWindowStart time.Time
+	notifyCount       int
+	suppressed        int
+	notifyMu          sync.Mutex
+}
+
+// NewOddsAlertService 创建赔率告警服务
+func NewOddsAlertService(db *sql.DB, ttl time.Duration, notifyPerMinute int) *OddsAlertService {
+	return &OddsAlertService{
+		db:              db,
+		ttl:             ttl,
+		events:          make(map[string]*alertEventState),
+		lastAlert:       make(map[string]int64),
+		notifyPerMinute: notifyPerMinute,
+	}
+}
+
+// Invalidate 清除规则缓存
+func (s *OddsAlertService) Invalidate() {
+	s.rulesMu.Lock()
+	s.loadedAt = time.Time{}
+	s.rulesMu.Unlock()
+}
+
+// activeRules 获取启用的规则 (必要时重新加载)
+func (s *OddsAlertService) activeRules() []OddsAlertRule {
+	s.rulesMu.RLock()
+	if time.Since(s.loadedAt) < s.ttl {
+		rules := s.rules
+		s.rulesMu.RUnlock()
+		return rules
+	}
+	s.rulesMu.RUnlock()
+
+	rules, err := s.queryRules(true)
+
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+	if err != nil {
+		logger.Errorf("[OddsAlert] Failed to load alert rules: %v", err)
+		s.loadedAt = time.Now().Add(-s.ttl + 10*time.Second)
+		return s.rules
+	}
+	s.rules = rules
+	s.loadedAt = time.Now()
+	return rules
+}
+
+// Evaluate 用 odds_change (原始赔率) 更新状态并返回触发的告警
+func (s *OddsAlertService) Evaluate(oddsChange *uof.OddsChange, meta EventMeta) []OddsAlert {
+	rules := s.activeRules()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var maxWindow int64
+	for _, rule := range rules {
+		if w := int64(rule.WindowSeconds) * 1000; w > maxWindow {
+			maxWindow = w
+		}
+	}
+
+	ts := oddsChange.Timestamp
+	if ts == 0 {
+		ts = time.Now().UnixMilli()
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gc(now, maxWindow)
+
+	state, ok := s.events[oddsChange.EventID]
+	if !ok {
+		state = &alertEventState{
+			outcomes:     make(map[string][]alertOddsPoint),
+			suspensions:  make(map[string][]int64),
+			marketStatus: make(map[string]int),
+		}
+		s.events[oddsChange.EventID] = state
+	}
+	state.updatedAt = now
+
+	alerts := make([]OddsAlert, 0)
+	for _, market := range oddsChange.Odds.Markets {
+		marketID := strconv.Itoa(market.ID)
+		marketLabel := marketID + "|" + market.Specifiers
+		// 状态按 producer 区分: prematch 和 live 同时报价时, 交替的消息不应被当作暂停或赔率变动
+		marketKey := strconv.Itoa(oddsChange.ProductID) + "|" + marketLabel
+
+		// 1. 更新暂停次数和赔率历史
+		newSuspension := false
+		prev, seen := state.marketStatus[marketKey]
+		if market.Status == -1 && (!seen || prev != -1) {
+			state.suspensions[marketKey] = append(trimTimes(state.suspensions[marketKey], ts-maxWindow), ts)
+			newSuspension = true
+		}
+		state.marketStatus[marketKey] = market.Status
+
+		for _, outcome := range market.Outcomes {
+			if outcome.Odds <= 1 {
+				continue
+			}
+			key := marketKey + "|" + outcome.ID
+			points := state.outcomes[key]
+			if len(points) == 0 || points[len(points)-1].odds != outcome.Odds {
+				points = append(points, alertOddsPoint{ts: ts, odds: outcome.Odds})
+			}
+			state.outcomes[key] = trimPoints(points, ts-maxWindow)
+		}
+
+		// 2. 检查规则
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.matches(meta, marketID, oddsChange.ProductID) {
+				continue
+			}
+			window := int64(rule.WindowSeconds) * 1000
+
+			base := OddsAlert{
+				RuleID:        rule.ID,
+				RuleName:      rule.Name,
+				RuleType:      rule.RuleType,
+				Severity:      rule.Severity,
+				EventID:       oddsChange.EventID,
+				SportID:       meta.SportID,
+				SRMarketID:    marketID,
+				Specifiers:    market.Specifiers,
+				ProducerID:    oddsChange.ProductID,
+				Threshold:     rule.Threshold,
+				WindowSeconds: rule.WindowSeconds,
+				Timestamp:     ts,
+				CreatedAt:     now,
+			}
+
+			switch rule.RuleType {
+			case AlertRuleSuspensionCount:
+				if !newSuspension {
+					continue
+				}
+				count := len(trimTimes(state.suspensions[marketKey], ts-window))
+				if float64(count) <= rule.Threshold || !s.cooldownPassed(rule, marketKey, oddsChange.EventID, "", ts) {
+					continue
+				}
+				alert := base
+				alert.Value = float64(count)
+				alert.Message = fmt.Sprintf("market %s suspended %d times within %ds", marketLabel, count, rule.WindowSeconds)
+				alerts = append(alerts, alert)
+
+			case AlertRulePriceMove, AlertRulePrematchDrift:
+				for _, outcome := range market.Outcomes {
+					points := state.outcomes[marketKey+"|"+outcome.ID]
+					if len(points) < 2 || points[len(points)-1].ts != ts {
+						continue // 本条消息中该结果赔率没有变化
+					}
+					from, to, move, updates := windowMove(points, ts-window, rule.RuleType == AlertRulePrematchDrift)
+					if move < rule.Threshold {
+						continue
+					}
+					if rule.RuleType == AlertRulePrematchDrift && rule.MaxUpdates > 0 && updates > rule.MaxUpdates {
+						continue
+					}
+					if !s.cooldownPassed(rule, marketKey, oddsChange.EventID, outcome.ID, ts) {
+						continue
+					}
+					alert := base
+					alert.OutcomeID = outcome.ID
+					alert.Value = roundTo(move, 2)
+					alert.OddsFrom = &from
+					alert.OddsTo = &to
+					alert.Message = fmt.Sprintf("outcome %s of market %s moved %.2f → %.2f (%.1f%%) within %ds",
+						outcome.ID, marketLabel, from, to, move, rule.WindowSeconds)
+					alerts = append(alerts, alert)
+				}
+			}
+		}
+	}
+	return alerts
+}
+
+// windowMove 计算窗口内赔率变动百分比
+// 窗口起点赔率取窗口开始时的价格 (窗口前最后一个点); price_move 取窗口内与当前价格相差最大的点, drift 只比较起点
+// updates 为窗口内的赔率变化次数
+func windowMove(points []alertOddsPoint, windowStart int64, driftOnly bool) (from, to, move float64, updates int) {
+	to = points[len(points)-1].odds
+
+	start := 0
+	for i, p := range points {
+		if p.ts <= windowStart {
+			start = i
+		}
+	}
+	for _, p := range points[start:] {
+		if p.ts > windowStart {
+			updates++
+		}
+	}
+
+	candidates := points[start : len(points)-1]
+	if driftOnly {
+		candidates = candidates[:1]
+	}
+	for _, p := range candidates {
+		m := math.Abs(to-p.odds) / p.odds * 100
+		if m > move {
+			from, move = p.odds, m
+		}
+	}
+	return from, to, move, updates
+}
+
+// trimPoints 去掉窗口开始前的点, 保留窗口前最后一个点作为起点价格
+func trimPoints(points []alertOddsPoint, windowStart int64) []alertOddsPoint {
+	drop := 0
+	for drop+1 < len(points) && points[drop+1].ts <= windowStart {
+		drop++
+	}
+	return points[drop:]
+}
+
+// trimTimes 去掉窗口开始前的时间
+func trimTimes(times []int64, windowStart int64) []int64 {
+	drop := 0
+	for drop < len(times) && times[drop] < windowStart {
+		drop++
+	}
+	return times[drop:]
+}
+
+// cooldownPassed 检查并更新同一规则同一盘口/结果的告警间隔 (调用方需持有 s.mu)
+func (s *OddsAlertService) cooldownPassed(rule *OddsAlertRule, marketKey, eventID, outcomeID string, ts int64) bool {
+	key := strconv.Itoa(rule.ID) + "|" + eventID + "|" + marketKey + "|" + outcomeID
+	if last, ok := s.lastAlert[key]; ok && ts-last < int64(rule.CooldownSeconds)*1000 {
+		return false
+	}
+	s.lastAlert[key] = ts
+	return true
+}
+
+// gc 清理长时间未更新的赛事和过期的告警间隔记录 (调用方需持有 s.mu)
+func (s *OddsAlertService) gc(now time.Time, maxWindow int64) {
+	if now.Sub(s.lastGC) < 10*time.Minute {
+		return
+	}
+	idle := time.Duration(maxWindow)*time.Millisecond + time.Hour
+	for eventID, state := range s.events {
+		if now.Sub(state.updatedAt) > idle {
+			delete(s.events, eventID)
+		}
+	}
+	cutoff := now.Add(-24 * time.Hour).UnixMilli()
+	for key, ts := range s.lastAlert {
+		if ts < cutoff {
+			delete(s.lastAlert, key)
+		}
+	}
+	s.lastGC = now
+}
+
+// AllowNotify 通知限流, 返回是否可以发送以及此前被限流跳过的告警数
+func (s *OddsAlertService) AllowNotify(count int) (bool, int) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.notifyWindowStart) >= time.Minute {
+		s.notifyWindowStart = now
+		s.notifyCount = 0
+	}
+	if s.notifyPerMinute > 0 && s.notifyCount >= s.notifyPerMinute {
+		s.suppressed += count
+		return false, 0
+	}
+	s.notifyCount++
+	suppressed := s.suppressed
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// RecordAlerts 保存告警并填充 ID
+func (s *OddsAlertService) RecordAlerts(alerts []OddsAlert) error {
+	for i := range alerts {
+		a := &alerts[i]
+		if err := s.db.QueryRow(`
+			INSERT INTO odds_alerts (rule_id, rule_name, rule_type, severity, event_id, sport_id, sr_market_id, specifiers,
+			                         outcome_id, producer_id, value, threshold, window_seconds, odds_from, odds_to, message, timestamp)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NULLIF($9, ''), $10, $11, $12, $13, $14, $15, $16, $17)
+			RETURNING id, created_at
+		`, a.RuleID, a.RuleName, a.RuleType, a.Severity, a.EventID, a.SportID, a.SRMarketID, a.Specifiers,
+			a.OutcomeID, a.ProducerID, a.Value, a.Threshold, a.WindowSeconds, a.OddsFrom, a.OddsTo, a.Message, a.Timestamp,
+		).Scan(&a.ID, &a.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert odds alert: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAlerts 查询告警, eventID / severity 为空时不过滤
+// sinceID > 0 时按 ID 正序增量拉取 (超过 limit 的部分留给下一次), 否则返回最新的 limit 条 (按 ID 倒序)
+func (s *OddsAlertService) GetAlerts(eventID, severity string, sinceID int64, limit int) ([]OddsAlert, error) {
+	order := "DESC"
+	if sinceID > 0 {
+		order = "ASC"
+	}
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(rule_id, 0), rule_name, rule_type, severity, event_id, COALESCE(sport_id, ''),
+		       sr_market_id, COALESCE(specifiers, ''), COALESCE(outcome_id, ''), COALESCE(producer_id, 0),
+		       value, threshold, window_seconds, odds_from, odds_to, message, timestamp, created_at
+		FROM odds_alerts
+		WHERE id > $1 AND ($2 = '' OR event_id = $2) AND ($3 = '' OR severity = $3)
+		ORDER BY id `+order+`
+		LIMIT $4
+	`, sinceID, eventID, severity, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query odds alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]OddsAlert, 0)
+	for rows.Next() {
+		var a OddsAlert
+		var from, to sql.NullFloat64
+		if err := rows.Scan(&a.ID, &a.RuleID, &a.RuleName, &a.RuleType, &a.Severity, &a.EventID, &a.SportID,
+			&a.SRMarketID, &a.Specifiers, &a.OutcomeID, &a.ProducerID,
+			&a.Value, &a.Threshold, &a.WindowSeconds, &from, &to, &a.Message, &a.Timestamp, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan odds alert: %w", err)
+		}
+		a.OddsFrom = nullFloatPtr(from)
+		a.OddsTo = nullFloatPtr(to)
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+const oddsAlertRuleColumns = `id, name, rule_type, COALESCE(sport_id, ''), COALESCE(sr_market_id, ''), COALESCE(producer_id, 0),
+	threshold, window_seconds, max_updates, cooldown_seconds, severity, enabled, created_at, updated_at`
+
+type oddsAlertRuleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOddsAlertRule(row oddsAlertRuleScanner) (*OddsAlertRule, error) {
+	var r OddsAlertRule
+	if err := row.Scan(&r.ID, &r.Name, &r.RuleType, &r.SportID, &r.SRMarketID, &r.ProducerID,
+		&r.Threshold, &r.WindowSeconds, &r.MaxUpdates, &r.CooldownSeconds, &r.Severity, &r.Enabled,
+		&r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// queryRules 查询规则
+func (s *OddsAlertService) queryRules(enabledOnly bool) ([]OddsAlertRule, error) {
+	query := `SELECT ` + oddsAlertRuleColumns + ` FROM odds_alert_rules`
+	if enabledOnly {
+		query += ` WHERE enabled = true`
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]OddsAlertRule, 0)
+	for rows.Next() {
+		r, err := scanOddsAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// ListRules 列出全部规则
+func (s *OddsAlertService) ListRules() ([]OddsAlertRule, error) {
+	return s.queryRules(false)
+}
+
+// GetRule 获取单个规则, 不存在时返回 nil
+func (s *OddsAlertService) GetRule(id int) (*OddsAlertRule, error) {
+	r, err := scanOddsAlertRule(s.db.QueryRow(`SELECT `+oddsAlertRuleColumns+` FROM odds_alert_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rule: %w", err)
+	}
+	return r, nil
+}
+
+// CreateRule 创建规则
+func (s *OddsAlertService) CreateRule(r *OddsAlertRule) (*OddsAlertRule, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	created, err := scanOddsAlertRule(s.db.QueryRow(`
+		INSERT INTO odds_alert_rules (name, rule_type, sport_id, sr_market_id, producer_id, threshold, window_seconds,
+		                              max_updates, cooldown_seconds, severity, enabled)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, 0), $6, $7, $8, $9, $10, $11)
+		RETURNING `+oddsAlertRuleColumns,
+		r.Name, r.RuleType, r.SportID, NormalizeMarketID(r.SRMarketID), r.ProducerID, r.Threshold, r.WindowSeconds,
+		r.MaxUpdates, r.CooldownSeconds, r.Severity, r.Enabled,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	s.Invalidate()
+	return created, nil
+}
+
+// UpdateRule 更新规则, 不存在时返回 nil
+func (s *OddsAlertService) UpdateRule(id int, r *OddsAlertRule) (*OddsAlertRule, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated, err := scanOddsAlertRule(s.db.QueryRow(`
+		UPDATE odds_alert_rules
+		SET name = $2, rule_type = $3, sport_id = NULLIF($4, ''), sr_market_id = NULLIF($5, ''), producer_id = NULLIF($6, 0),
+		    threshold = $7, window_seconds = $8, max_updates = $9, cooldown_seconds = $10, severity = $11, enabled = $12,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+oddsAlertRuleColumns,
+		id, r.Name, r.RuleType, r.SportID, NormalizeMarketID(r.SRMarketID), r.ProducerID, r.Threshold, r.WindowSeconds,
+		r.MaxUpdates, r.CooldownSeconds, r.Severity, r.Enabled,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	s.Invalidate()
+	return updated, nil
+}
+
+// DeleteRule 删除规则, 返回是否存在
+func (s *OddsAlertService) DeleteRule(id int) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM odds_alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+
+	s.Invalidate()
+	return affected > 0, nil
+}
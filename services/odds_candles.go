@@ -0,0 +1,296 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CandleInterval K 线周期
+type CandleInterval struct {
+	Name   string
+	Millis int64
+}
+
+// CandleIntervals 入库时计算的 K 线周期
+var CandleIntervals = []CandleInterval{
+	{Name: "1s", Millis: 1000},
+	{Name: "10s", Millis: 10 * 1000},
+	{Name: "1m", Millis: 60 * 1000},
+	{Name: "5m", Millis: 5 * 60 * 1000},
+}
+
+// ParseCandleInterval 解析 K 线周期名称
+func ParseCandleInterval(name string) (CandleInterval, bool) {
+	for _, interval := range CandleIntervals {
+		if interval.Name == name {
+			return interval, true
+		}
+	}
+	return CandleInterval{}, false
+}
+
+// OddsCandle 赔率 K 线 (OHLC)
+type OddsCandle struct {
+	BucketStart int64          `json:"bucket_start"` // 区间开始时间 (毫秒)
+	Open        float64        `json:"open"`         // 区间开始时的赔率 (上一区间的收盘价)
+	High        float64        `json:"high"`
+	Low         float64        `json:"low"`
+	Close       float64        `json:"close"`
+	Changes     int            `json:"changes"`             // 区间内赔率变化次数
+	SuspendedMs int64          `json:"suspended_ms"`        // 区间内盘口暂停或结果 inactive 的时长
+	Filled      bool           `json:"filled,omitempty"`    // 区间内没有更新, 由上一根 K 线补齐
+	Formatted   *FormattedOdds `json:"formatted,omitempty"` // 请求 odds_format 时返回 (收盘价)
+}
+
+type candleState struct {
+	bucketStart int64
+	close       float64
+	lastTS      int64
+	suspended   bool
+}
+
+// updateOddsCandles 用一次结果更新增量计算各周期的 K 线
+// suspended 为盘口非 active 或结果 inactive; 赔率无效 (<= 1) 时沿用上一收盘价, 只累计暂停时长
+// 每个结果每个周期的最后一根 K 线保存当前状态 (last_timestamp / closing_suspended), 跨区间时补齐上一根的暂停时长
+// K 线按 (event_id, sr_market_id, specifiers, outcome_id) 定位, 不使用 markets.id (重置数据库后会被新盘口复用)
+func updateOddsCandles(tx *sql.Tx, marketPK int, eventID, srMarketID, specifiers, outcomeID string, odds float64, suspended bool, timestamp int64) error {
+	rows, err := tx.Query(`
+		SELECT DISTINCT ON (interval) interval, bucket_start, close, last_timestamp, closing_suspended
+		FROM odds_candles
+		WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND outcome_id = $4
+		ORDER BY interval, bucket_start DESC
+	`, eventID, srMarketID, specifiers, outcomeID)
+	if err != nil {
+		return fmt.Errorf("failed to query candles: %w", err)
+	}
+	prev := make(map[string]candleState)
+	for rows.Next() {
+		var name string
+		var state candleState
+		if err := rows.Scan(&name, &state.bucketStart, &state.close, &state.lastTS, &state.suspended); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan candle: %w", err)
+		}
+		prev[name] = state
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	// 所有周期的变更合并为一条 upsert
+	return upsertOddsCandles(tx, marketPK, eventID, srMarketID, specifiers, outcomeID, candleRows(prev, odds, suspended, timestamp))
+}
+
+// candleRows 根据各周期的上一根 K 线计算本次更新要写入的行
+// 新区间为完整的 K 线; 已有的 K 线 (同一区间的更新和跨区间时补齐上一根的暂停时长) 为增量, 见 upsertOddsCandles
+func candleRows(prev map[string]candleState, odds float64, suspended bool, timestamp int64) []candleRow {
+	var candles []candleRow
+	for _, interval := range CandleIntervals {
+		bucket := timestamp - timestamp%interval.Millis
+		p, hasPrev := prev[interval.Name]
+		if hasPrev && timestamp < p.lastTS {
+			continue // 乱序消息
+		}
+
+		price := odds
+		validPrice := odds > 1
+		if !validPrice {
+			if !hasPrev {
+				continue
+			}
+			price = p.close
+		}
+
+		// 同一区间: 更新高低收, 累计上次更新以来的暂停时长
+		if hasPrev && p.bucketStart == bucket {
+			var suspendedMs int64
+			if p.suspended {
+				suspendedMs = timestamp - p.lastTS
+			}
+			changes := 0
+			if price != p.close {
+				changes = 1
+			}
+			candles = append(candles, candleRow{interval: interval.Name, bucket: bucket, open: price, high: price, low: price, close: price,
+				changes: changes, suspendedMs: suspendedMs, lastTS: timestamp, suspended: suspended})
+			continue
+		}
+
+		// 新区间: 开盘价为上一收盘价, 上一根 K 线和本区间开头的暂停时长补齐
+		open := price
+		changes := 1
+		var suspendedMs int64
+		if hasPrev {
+			open = p.close
+			if price == open {
+				changes = 0
+			}
+			if p.suspended {
+				// 上一根 K 线只增加暂停时长, 其余字段保持原值
+				candles = append(candles, candleRow{interval: interval.Name, bucket: p.bucketStart, open: p.close, high: p.close, low: p.close, close: p.close,
+					suspendedMs: p.bucketStart + interval.Millis - p.lastTS, lastTS: p.lastTS, suspended: p.suspended})
+				suspendedMs = timestamp - bucket
+			}
+		}
+		high, low := open, open
+		if price > high {
+			high = price
+		}
+		if price < low {
+			low = price
+		}
+		candles = append(candles, candleRow{interval: interval.Name, bucket: bucket, open: open, high: high, low: low, close: price,
+			changes: changes, suspendedMs: suspendedMs, lastTS: timestamp, suspended: suspended})
+	}
+	return candles
+}
+
+// candleRow 一根 K 线的写入值; 与已有 K 线合并时 high/low 取极值, close/last_timestamp/closing_suspended 覆盖,
+// changes/suspended_ms 累加 (即为增量)
+type candleRow struct {
+	interval    string
+	bucket      int64
+	open        float64
+	high        float64
+	low         float64
+	close       float64
+	changes     int
+	suspendedMs int64
+	lastTS      int64
+	suspended   bool
+}
+
+// upsertOddsCandles 用一条语句写入一个结果各周期的 K 线
+func upsertOddsCandles(tx *sql.Tx, marketPK int, eventID, srMarketID, specifiers, outcomeID string, candles []candleRow) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	args := []interface{}{marketPK, eventID, srMarketID, specifiers, outcomeID}
+	values := make([]string, 0, len(candles))
+	for _, c := range candles {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($1, $2, $3, $4, $5, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10))
+		args = append(args, c.interval, c.bucket, c.open, c.high, c.low, c.close, c.changes, c.suspendedMs, c.lastTS, c.suspended)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO odds_candles (market_id, event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start,
+		                          open, high, low, close, changes, suspended_ms, last_timestamp, closing_suspended)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (event_id, sr_market_id, specifiers, outcome_id, interval, bucket_start) DO UPDATE
+		SET market_id = EXCLUDED.market_id,
+		    high = GREATEST(odds_candles.high, EXCLUDED.high),
+		    low = LEAST(odds_candles.low, EXCLUDED.low),
+		    close = EXCLUDED.close,
+		    changes = odds_candles.changes + EXCLUDED.changes,
+		    suspended_ms = odds_candles.suspended_ms + EXCLUDED.suspended_ms,
+		    last_timestamp = EXCLUDED.last_timestamp,
+		    closing_suspended = EXCLUDED.closing_suspended,
+		    updated_at = NOW()
+	`, args...); err != nil {
+		return fmt.Errorf("failed to upsert odds candles: %w", err)
+	}
+	return nil
+}
+
+// GetOddsCandles 获取结果在 [from, to] 内的 K 线 (按时间正序, 最多 limit 根)
+// 没有更新的区间由上一根 K 线补齐 (open = high = low = close = 上一收盘价); 仍在暂停中的区间按当前时间计算暂停时长
+func (p *OddsParser) GetOddsCandles(eventID, marketID, specifiers, outcomeID string, interval CandleInterval, from, to int64, limit int) ([]OddsCandle, error) {
+	rows, err := p.db.Query(`
+		SELECT bucket_start, open, high, low, close, changes, suspended_ms, last_timestamp, closing_suspended
+		FROM odds_candles
+		WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND outcome_id = $4 AND interval = $5
+		  AND bucket_start >= $6 AND bucket_start <= $7
+		ORDER BY bucket_start DESC
+		LIMIT $8
+	`, eventID, NormalizeMarketID(marketID), specifiers, outcomeID, interval.Name, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query odds candles: %w", err)
+	}
+	defer rows.Close()
+
+	type storedCandle struct {
+		OddsCandle
+		lastTS    int64
+		suspended bool
+	}
+	stored := make([]storedCandle, 0)
+	for rows.Next() {
+		var c storedCandle
+		if err := rows.Scan(&c.BucketStart, &c.Open, &c.High, &c.Low, &c.Close, &c.Changes, &c.SuspendedMs,
+			&c.lastTS, &c.suspended); err != nil {
+			return nil, fmt.Errorf("failed to scan odds candle: %w", err)
+		}
+		stored = append(stored, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	candles := make([]OddsCandle, 0, len(stored))
+	if len(stored) == 0 {
+		return candles, nil
+	}
+
+	// 补齐区间到 end (不超过当前时间)
+	end := to
+	if now := time.Now().UnixMilli(); now < end {
+		end = now
+	}
+	filled := func(prev storedCandle, bucket int64, until int64) OddsCandle {
+		c := OddsCandle{
+			BucketStart: bucket,
+			Open:        prev.Close,
+			High:        prev.Close,
+			Low:         prev.Close,
+			Close:       prev.Close,
+			Filled:      true,
+		}
+		if prev.suspended {
+			c.SuspendedMs = until - bucket
+		}
+		return c
+	}
+
+	for i := len(stored) - 1; i >= 0; i-- {
+		c := stored[i]
+		candles = append(candles, c.OddsCandle)
+		bucketEnd := c.BucketStart + interval.Millis
+
+		next := end
+		if i > 0 {
+			next = stored[i-1].BucketStart
+		} else if c.suspended && end > c.lastTS {
+			// 最后一根 K 线: 暂停状态持续到区间结束或当前时间
+			tail := bucketEnd
+			if end < tail {
+				tail = end
+			}
+			candles[len(candles)-1].SuspendedMs += tail - c.lastTS
+		}
+
+		// 超出 limit 的补齐区间最终会被截掉, 直接跳过
+		start := bucketEnd
+		if skip := (next-bucketEnd)/interval.Millis - int64(limit); skip > 0 {
+			start += skip * interval.Millis
+		}
+		for bucket := start; bucket < next; bucket += interval.Millis {
+			until := bucket + interval.Millis
+			if i == 0 && end < until {
+				until = end
+			}
+			candles = append(candles, filled(c, bucket, until))
+		}
+	}
+
+	if len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+	return candles, nil
+}
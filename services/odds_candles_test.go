@@ -0,0 +1,81 @@
+package services
+
+import "testing"
+
+func findCandleRow(rows []candleRow, interval string, bucket int64) *candleRow {
+	for i := range rows {
+		if rows[i].interval == interval && rows[i].bucket == bucket {
+			return &rows[i]
+		}
+	}
+	return nil
+}
+
+func TestCandleRowsFirstUpdate(t *testing.T) {
+	rows := candleRows(map[string]candleState{}, 1.85, false, 61500)
+	if len(rows) != len(CandleIntervals) {
+		t.Fatalf("got %d rows, want one per interval (%d)", len(rows), len(CandleIntervals))
+	}
+	row := findCandleRow(rows, "1m", 60000)
+	if row == nil {
+		t.Fatal("missing 1m candle for bucket 60000")
+	}
+	if row.open != 1.85 || row.close != 1.85 || row.changes != 1 || row.suspendedMs != 0 {
+		t.Errorf("unexpected first candle: %+v", *row)
+	}
+
+	// 无效赔率且没有上一根 K 线: 不写入
+	if rows := candleRows(map[string]candleState{}, 0, true, 61500); len(rows) != 0 {
+		t.Errorf("invalid odds without previous candle: got %d rows, want 0", len(rows))
+	}
+}
+
+func TestCandleRowsSameBucketIsIncremental(t *testing.T) {
+	prev := map[string]candleState{
+		"1m": {bucketStart: 60000, close: 1.85, lastTS: 61000, suspended: true},
+	}
+	rows := candleRows(prev, 1.90, false, 65000)
+
+	row := findCandleRow(rows, "1m", 60000)
+	if row == nil {
+		t.Fatal("missing 1m candle for bucket 60000")
+	}
+	// 同一区间为增量: 变化 1 次, 暂停 61000 → 65000
+	if row.changes != 1 || row.suspendedMs != 4000 || row.close != 1.90 || row.suspended {
+		t.Errorf("unexpected same-bucket row: %+v", *row)
+	}
+}
+
+func TestCandleRowsNewBucketClosesSuspendedCandle(t *testing.T) {
+	prev := map[string]candleState{
+		"1m": {bucketStart: 60000, close: 1.85, lastTS: 100000, suspended: true},
+	}
+	rows := candleRows(prev, 1.85, true, 130000)
+
+	closing := findCandleRow(rows, "1m", 60000)
+	if closing == nil {
+		t.Fatal("missing closing row for the previous 1m candle")
+	}
+	// 上一根 K 线只补齐暂停时长 (100000 → 120000), 其余字段保持原值
+	if closing.suspendedMs != 20000 || closing.changes != 0 || closing.close != 1.85 || closing.lastTS != 100000 {
+		t.Errorf("unexpected closing row: %+v", *closing)
+	}
+
+	next := findCandleRow(rows, "1m", 120000)
+	if next == nil {
+		t.Fatal("missing new 1m candle for bucket 120000")
+	}
+	if next.open != 1.85 || next.changes != 0 || next.suspendedMs != 10000 || !next.suspended {
+		t.Errorf("unexpected new candle: %+v", *next)
+	}
+}
+
+func TestCandleRowsSkipsOutOfOrder(t *testing.T) {
+	prev := make(map[string]candleState)
+	for _, interval := range CandleIntervals {
+		prev[interval.Name] = candleState{bucketStart: 0, close: 1.85, lastTS: 5000}
+	}
+	if rows := candleRows(prev, 2.0, false, 4000); len(rows) != 0 {
+		t.Errorf("out-of-order update: got %d rows, want 0", len(rows))
+	}
+}
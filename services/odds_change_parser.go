@@ -2,116 +2,39 @@ package services
 
 import (
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
+
+	"uof-service/uof"
 )
 
 // OddsChangeParser Odds Change 消息解析器
 type OddsChangeParser struct {
-	db     *sql.DB
-	logger *log.Logger
-}
-
-// OddsChangeMessage Odds Change 消息结构
-// 根据官方文档: sport_event_status 是 odds_change 的直接子元素
-type OddsChangeMessage struct {
-	XMLName          xml.Name          `xml:"odds_change"`
-	EventID          string            `xml:"event_id,attr"`
-	ProductID        int               `xml:"product,attr"`
-	Timestamp        int64             `xml:"timestamp,attr"`
-	SportEvent       SportEventInfo    `xml:"sport_event"`
-	SportEventStatus *SportEventStatus `xml:"sport_event_status"`
-	Odds             OddsInfo          `xml:"odds"`
-}
-
-// SportEventInfo 赛事基本信息
-type SportEventInfo struct {
-	ID          string           `xml:"id,attr"`
-	Scheduled   int64            `xml:"scheduled,attr"`
-	StartTime   int64            `xml:"start_time,attr"`
-	Competitors []OddsCompetitor `xml:"competitors>competitor"`
-}
-
-// SportEventStatus 赛事状态(包含比分信息)
-// 这是 odds_change 的直接子元素,不是嵌套在 sport_event 下
-type SportEventStatus struct {
-	Status       string        `xml:"status,attr"`
-	MatchStatus  string        `xml:"match_status,attr"`
-	HomeScore    *int          `xml:"home_score,attr"`
-	AwayScore    *int          `xml:"away_score,attr"`
-	Clock        *ClockInfo    `xml:"clock"`
-	PeriodScores []PeriodScore `xml:"period_scores>period_score"`
-	Statistics   *Statistics   `xml:"statistics"`
-}
-
-// ClockInfo 比赛时钟信息
-type ClockInfo struct {
-	MatchTime             string `xml:"match_time,attr"`
-	StoppageTime          string `xml:"stoppage_time,attr"`
-	StoppageTimeAnnounced string `xml:"stoppage_time_announced,attr"`
-}
-
-// PeriodScore 分段比分
-type PeriodScore struct {
-	HomeScore int    `xml:"home_score,attr"`
-	AwayScore int    `xml:"away_score,attr"`
-	Type      string `xml:"type,attr"` // regular_period, overtime, penalties
-	Number    int    `xml:"number,attr"`
-}
-
-// Statistics 比赛统计信息
-type Statistics struct {
-	YellowCards    *TeamStats `xml:"yellow_cards"`
-	RedCards       *TeamStats `xml:"red_cards"`
-	YellowRedCards *TeamStats `xml:"yellow_red_cards"`
-	Corners        *TeamStats `xml:"corners"`
-}
-
-// TeamStats 双方统计数据
-type TeamStats struct {
-	Home int `xml:"home,attr"`
-	Away int `xml:"away,attr"`
-}
-
-// OddsInfo 赔率信息
-type OddsInfo struct {
-	Markets []Market `xml:"market"`
-}
-
-// Market 市场信息
-type Market struct {
-	ID        int       `xml:"id,attr"`
-	Specifier string    `xml:"specifiers,attr"`
-	Status   int       `xml:"status,attr"`
-	Outcomes []Outcome `xml:"outcome"`
-}
-
-// Outcome 结果信息
-type Outcome struct {
-	ID     string  `xml:"id,attr"`
-	Odds   float64 `xml:"odds,attr"`
-	Active int     `xml:"active,attr"`
+	db          *sql.DB
+	logger      *log.Logger
+	timeline    *MatchTimelineService
+	eventStatus *EventStatusService
 }
 
 // NewOddsChangeParser 创建 Odds Change 解析器
 func NewOddsChangeParser(db *sql.DB) *OddsChangeParser {
 	return &OddsChangeParser{
-		db:     db,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		db:          db,
+		logger:      log.New(os.Stdout, "", log.LstdFlags),
+		eventStatus: NewEventStatusService(db),
 	}
 }
 
-// ParseAndStore 解析并存储 Odds Change 消息
-func (p *OddsChangeParser) ParseAndStore(xmlContent string) error {
-	var oddsChange OddsChangeMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &oddsChange); err != nil {
-		return fmt.Errorf("failed to parse odds_change message: %w", err)
-	}
+// SetTimelineService 设置比赛时间线服务 (记录 sport_event_status 的变化)
+func (p *OddsChangeParser) SetTimelineService(timeline *MatchTimelineService) {
+	p.timeline = timeline
+}
 
+// Store 存储已解码的 Odds Change 消息
+func (p *OddsChangeParser) Store(oddsChange *uof.OddsChange) error {
 	// 日志在处理完成后输出
 
 	// 提取比分和状态信息
@@ -146,6 +69,18 @@ func (p *OddsChangeParser) ParseAndStore(xmlContent string) error {
 		}
 	}
 
+	// 记录时间线 (必须在更新 tracked_events 之前, 重启后以其中的比分为基准)
+	if p.timeline != nil {
+		if _, err := p.timeline.Record(oddsChange); err != nil {
+			p.logger.Printf("[odds_change] Failed to record timeline for %s: %v", oddsChange.EventID, err)
+		}
+	}
+
+	// 保存完整的 sport_event_status (分段比分 / 统计 / 时钟 / 运动特有属性)
+	if err := p.eventStatus.Save(oddsChange.EventID, oddsChange.SportEventStatus, EventStatusSourceOddsChange, oddsChange.Timestamp); err != nil {
+		p.logger.Printf("[odds_change] Failed to store sport_event_status for %s: %v", oddsChange.EventID, err)
+	}
+
 		// 存储到数据库
 		statusOrder := p.getStatusOrder(status)
 		if err := p.storeOddsChangeData(
@@ -164,6 +99,11 @@ func (p *OddsChangeParser) ParseAndStore(xmlContent string) error {
 		return fmt.Errorf("failed to store odds_change data: %w", err)
 	}
 
+	// 保存赛事类型和参赛方 (非对阵赛事的参赛方列表用于结果命名)
+	if err := SaveEventCompetitors(p.db, oddsChange.EventID, oddsChange.SportEvent.Competitors); err != nil {
+		p.logger.Printf("[odds_change] Failed to store competitors for %s: %v", oddsChange.EventID, err)
+	}
+
 	// 统计市场和结果数量
 	marketCount := len(oddsChange.Odds.Markets)
 	outcomeCount := 0
@@ -201,7 +141,7 @@ func (p *OddsChangeParser) ParseAndStore(xmlContent string) error {
 		}
 	}
 	
-	p.logger.Printf("[odds_change] 比赛 %s: %s",
+	p.logger.Printf("[odds_change] 赛事 %s: %s",
 		oddsChange.EventID, strings.Join(logParts, ", "))
 
 	return nil
@@ -253,7 +193,7 @@ func (p *OddsChangeParser) storeOddsChangeData(
 	}
 	
 	// 更新 tracked_events 表 (不再使用 ld_matches)
-query := `INSERT INTO tracked_events (event_id, home_score, away_score, match_status, status, status_order, home_team_id, away_team_id, home_team_name, away_team_name, last_message_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) ON CONFLICT (event_id) DO UPDATE SET home_score = EXCLUDED.home_score, away_score = EXCLUDED.away_score, match_status = CASE WHEN EXCLUDED.match_status = '' THEN tracked_events.match_status ELSE EXCLUDED.match_status END, status = CASE WHEN EXCLUDED.status = '' THEN tracked_events.status ELSE EXCLUDED.status END, status_order = CASE WHEN EXCLUDED.status_order > tracked_events.status_order THEN EXCLUDED.status_order ELSE tracked_events.status_order END, home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, last_message_at = EXCLUDED.last_message_at, updated_at = EXCLUDED.updated_at`
+query := `INSERT INTO tracked_events (event_id, home_score, away_score, match_status, status, status_order, home_team_id, away_team_id, home_team_name, away_team_name, last_message_at, created_at, updated_at, match_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT (event_id) DO UPDATE SET home_score = EXCLUDED.home_score, away_score = EXCLUDED.away_score, match_status = CASE WHEN EXCLUDED.match_status = '' THEN tracked_events.match_status ELSE EXCLUDED.match_status END, match_time = CASE WHEN EXCLUDED.match_time = '' THEN tracked_events.match_time ELSE EXCLUDED.match_time END, status = CASE WHEN EXCLUDED.status = '' THEN tracked_events.status ELSE EXCLUDED.status END, status_order = CASE WHEN EXCLUDED.status_order > tracked_events.status_order THEN EXCLUDED.status_order ELSE tracked_events.status_order END, home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END, away_team_id = CASE WHEN EXCLUDED.away_team_id = '' THEN tracked_events.away_team_id ELSE EXCLUDED.away_team_id END, home_team_name = CASE WHEN EXCLUDED.home_team_name = '' THEN tracked_events.home_team_name ELSE EXCLUDED.home_team_name END, away_team_name = CASE WHEN EXCLUDED.away_team_name = '' THEN tracked_events.away_team_name ELSE EXCLUDED.away_team_name END, last_message_at = EXCLUDED.last_message_at, updated_at = EXCLUDED.updated_at`
 
 	now := time.Now()
 	var t1Score, t2Score int
@@ -278,7 +218,7 @@ query := `INSERT INTO tracked_events (event_id, home_score, away_score, match_st
 				query,
 						eventID, t1Score, t2Score, finalStatus, statusName, statusOrder,
 						homeTeamID, awayTeamID, homeTeamName, awayTeamName,
-						now, now, now,
+						now, now, now, matchTime,
 			)
 		if err != nil {
 			return fmt.Errorf("failed to upsert tracked_events: %w", err)
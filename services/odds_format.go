@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// OddsFormat 赔率格式
+type OddsFormat string
+
+const (
+	OddsFormatDecimal    OddsFormat = "decimal"
+	OddsFormatFractional OddsFormat = "fractional"
+	OddsFormatAmerican   OddsFormat = "american"
+	OddsFormatHongKong   OddsFormat = "hongkong"
+	OddsFormatMalay      OddsFormat = "malay"
+	OddsFormatIndonesian OddsFormat = "indonesian"
+)
+
+// oddsFormatAliases 支持的格式名称 (不区分大小写)
+var oddsFormatAliases = map[string]OddsFormat{
+	"decimal":    OddsFormatDecimal,
+	"eu":         OddsFormatDecimal,
+	"fractional": OddsFormatFractional,
+	"uk":         OddsFormatFractional,
+	"american":   OddsFormatAmerican,
+	"us":         OddsFormatAmerican,
+	"moneyline":  OddsFormatAmerican,
+	"hongkong":   OddsFormatHongKong,
+	"hk":         OddsFormatHongKong,
+	"malay":      OddsFormatMalay,
+	"my":         OddsFormatMalay,
+	"indonesian": OddsFormatIndonesian,
+	"indo":       OddsFormatIndonesian,
+	"id":         OddsFormatIndonesian,
+}
+
+// ParseOddsFormat 解析赔率格式参数, 空字符串返回 decimal
+func ParseOddsFormat(value string) (OddsFormat, error) {
+	if value == "" {
+		return OddsFormatDecimal, nil
+	}
+	if format, ok := oddsFormatAliases[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported odds_format '%s' (decimal, fractional, american, hongkong, malay, indonesian)", value)
+}
+
+// FormattedOdds 转换后的赔率
+// Value: fractional 为字符串 ("5/2"), american 为整数, 其余为保留两位小数的浮点数
+type FormattedOdds struct {
+	Format             OddsFormat  `json:"format"`
+	Value              interface{} `json:"value"`
+	Display            string      `json:"display"`
+	ImpliedProbability float64     `json:"implied_probability"`
+}
+
+// fractionalLadder 分数赔率阶梯 (分子, 分母), 按十进制赔率升序
+// 转换时取不超过实际赔率的最大阶梯值, 保证显示赔率不高于实际赔率
+var fractionalLadder = [][2]int{
+	{1, 1000}, {1, 500}, {1, 250}, {1, 200}, {1, 150}, {1, 100}, {1, 80}, {1, 66}, {1, 50}, {1, 40},
+	{1, 33}, {1, 28}, {1, 25}, {1, 22}, {1, 20}, {1, 18}, {1, 16}, {1, 15}, {1, 14}, {1, 13},
+	{1, 12}, {1, 11}, {1, 10}, {1, 9}, {1, 8}, {2, 15}, {1, 7}, {2, 13}, {1, 6}, {2, 11},
+	{1, 5}, {2, 9}, {1, 4}, {2, 7}, {3, 10}, {1, 3}, {4, 11}, {2, 5}, {4, 9}, {1, 2},
+	{8, 15}, {4, 7}, {8, 13}, {4, 6}, {8, 11}, {4, 5}, {5, 6}, {10, 11}, {1, 1}, {21, 20},
+	{11, 10}, {6, 5}, {5, 4}, {11, 8}, {7, 5}, {6, 4}, {8, 5}, {13, 8}, {7, 4}, {15, 8},
+	{2, 1}, {85, 40}, {9, 4}, {5, 2}, {11, 4}, {3, 1}, {10, 3}, {7, 2}, {4, 1}, {9, 2},
+	{5, 1}, {11, 2}, {6, 1}, {13, 2}, {7, 1}, {15, 2}, {8, 1}, {17, 2}, {9, 1}, {10, 1},
+	{11, 1}, {12, 1}, {14, 1}, {16, 1}, {18, 1}, {20, 1}, {22, 1}, {25, 1}, {28, 1}, {33, 1},
+	{40, 1}, {50, 1}, {66, 1}, {80, 1}, {100, 1}, {125, 1}, {150, 1}, {200, 1}, {250, 1}, {500, 1},
+	{1000, 1},
+}
+
+// roundTo 四舍五入到指定小数位 (先截断浮点误差, 如 1.005 按 1.005 处理而不是 1.00499...)
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(math.Round(value*scale*1000)/1000) / scale
+}
+
+// ConvertOdds 把十进制赔率转换为指定格式
+// 舍入规则:
+//   - decimal / hongkong / malay / indonesian: 四舍五入到 2 位小数
+//   - american: 四舍五入到整数, >= 2.00 为正数 (+150), < 2.00 为负数 (-200)
+//   - fractional: 取阶梯中不超过实际赔率的最大值; 超出阶梯时为 (赔率-1) 向下取整 /1, 低于阶梯时为 1/n (n 向上取整)
+//   - implied_probability: 1/赔率, 四舍五入到 4 位小数
+//
+// 赔率 <= 1 (无效或已关闭) 返回 nil
+func ConvertOdds(decimal float64, format OddsFormat) *FormattedOdds {
+	if decimal <= 1 {
+		return nil
+	}
+
+	result := &FormattedOdds{
+		Format:             format,
+		ImpliedProbability: roundTo(1/decimal, 4),
+	}
+
+	profit := decimal - 1
+	switch format {
+	case OddsFormatFractional:
+		num, den := toFractional(decimal)
+		result.Value = fmt.Sprintf("%d/%d", num, den)
+		result.Display = result.Value.(string)
+
+	case OddsFormatAmerican:
+		var american int
+		if decimal >= 2 {
+			american = int(math.Round(100 * profit))
+		} else {
+			american = int(math.Round(-100 / profit))
+		}
+		result.Value = american
+		if american > 0 {
+			result.Display = fmt.Sprintf("+%d", american)
+		} else {
+			result.Display = fmt.Sprintf("%d", american)
+		}
+
+	case OddsFormatHongKong:
+		result.Value = roundTo(profit, 2)
+
+	case OddsFormatMalay:
+		// <= 2.00 为正 (等于港盘), > 2.00 为负
+		if decimal <= 2 {
+			result.Value = roundTo(profit, 2)
+		} else {
+			// 极高赔率时保持为 -0.01 而不是 -0.00
+			result.Value = math.Min(roundTo(-1/profit, 2), -0.01)
+		}
+
+	case OddsFormatIndonesian:
+		// >= 2.00 为正 (等于港盘), < 2.00 为负
+		if decimal >= 2 {
+			result.Value = roundTo(profit, 2)
+		} else {
+			result.Value = roundTo(-1/profit, 2)
+		}
+
+	default:
+		result.Format = OddsFormatDecimal
+		result.Value = roundTo(decimal, 2)
+	}
+
+	if result.Display == "" {
+		result.Display = fmt.Sprintf("%.2f", result.Value.(float64))
+	}
+	return result
+}
+
+// toFractional 按阶梯转换为分数赔率
+func toFractional(decimal float64) (int, int) {
+	// 留一点浮点余量, 使 1.5 能匹配 1/2
+	target := decimal + 1e-9
+
+	// 低于第一档时没有不超过实际赔率的阶梯值, 用 1/n 表示 (n 向上取整, 保证不高于实际赔率)
+	first := fractionalLadder[0]
+	if 1+float64(first[0])/float64(first[1]) > target {
+		return 1, int(math.Ceil(1/(decimal-1) - 1e-9))
+	}
+
+	best := first
+	for _, step := range fractionalLadder {
+		if 1+float64(step[0])/float64(step[1]) > target {
+			break
+		}
+		best = step
+	}
+
+	last := fractionalLadder[len(fractionalLadder)-1]
+	if decimal-1 > float64(last[0]) {
+		return int(math.Floor(decimal - 1)), 1
+	}
+	return best[0], best[1]
+}
@@ -0,0 +1,89 @@
+package services
+
+import "testing"
+
+func TestConvertOdds(t *testing.T) {
+	cases := []struct {
+		decimal float64
+		format  OddsFormat
+		value   interface{}
+		display string
+	}{
+		// 1.01: 阶梯最低一档附近
+		{1.01, OddsFormatDecimal, 1.01, "1.01"},
+		{1.01, OddsFormatFractional, "1/100", "1/100"},
+		{1.01, OddsFormatAmerican, -10000, "-10000"},
+		{1.01, OddsFormatHongKong, 0.01, "0.01"},
+		{1.01, OddsFormatMalay, 0.01, "0.01"},
+		{1.01, OddsFormatIndonesian, -100.0, "-100.00"},
+
+		// 1.5
+		{1.5, OddsFormatFractional, "1/2", "1/2"},
+		{1.5, OddsFormatAmerican, -200, "-200"},
+		{1.5, OddsFormatHongKong, 0.5, "0.50"},
+		{1.5, OddsFormatMalay, 0.5, "0.50"},
+		{1.5, OddsFormatIndonesian, -2.0, "-2.00"},
+
+		// 2.00: Malay / Indonesian 的正负分界
+		{2.0, OddsFormatFractional, "1/1", "1/1"},
+		{2.0, OddsFormatAmerican, 100, "+100"},
+		{2.0, OddsFormatHongKong, 1.0, "1.00"},
+		{2.0, OddsFormatMalay, 1.0, "1.00"},
+		{2.0, OddsFormatIndonesian, 1.0, "1.00"},
+
+		// 2.01: 刚过分界
+		{2.01, OddsFormatFractional, "1/1", "1/1"},
+		{2.01, OddsFormatAmerican, 101, "+101"},
+		{2.01, OddsFormatHongKong, 1.01, "1.01"},
+		{2.01, OddsFormatMalay, -0.99, "-0.99"},
+		{2.01, OddsFormatIndonesian, 1.01, "1.01"},
+
+		// 阶梯最高一档, 以及超出阶梯的极高赔率
+		{1001, OddsFormatFractional, "1000/1", "1000/1"},
+		{1500, OddsFormatFractional, "1499/1", "1499/1"},
+		{1500, OddsFormatAmerican, 149900, "+149900"},
+		{1500, OddsFormatHongKong, 1499.0, "1499.00"},
+		{1500, OddsFormatMalay, -0.01, "-0.01"},
+		{1500, OddsFormatIndonesian, 1499.0, "1499.00"},
+
+		// 低于阶梯第一档 (1.001): 1/n, 不高于实际赔率
+		{1.0005, OddsFormatDecimal, 1.0, "1.00"},
+		{1.0005, OddsFormatFractional, "1/2000", "1/2000"},
+		{1.0003, OddsFormatFractional, "1/3334", "1/3334"},
+		{1.0005, OddsFormatAmerican, -200000, "-200000"},
+		{1.0005, OddsFormatHongKong, 0.0, "0.00"},
+		{1.0005, OddsFormatIndonesian, -2000.0, "-2000.00"},
+
+		// 阶梯之间取不超过实际赔率的一档
+		{2.3, OddsFormatFractional, "5/4", "5/4"},
+		{1.005, OddsFormatDecimal, 1.01, "1.01"},
+	}
+
+	for _, c := range cases {
+		got := ConvertOdds(c.decimal, c.format)
+		if got == nil {
+			t.Errorf("ConvertOdds(%v, %s) = nil", c.decimal, c.format)
+			continue
+		}
+		if got.Value != c.value || got.Display != c.display {
+			t.Errorf("ConvertOdds(%v, %s) = %v (%s), want %v (%s)", c.decimal, c.format, got.Value, got.Display, c.value, c.display)
+		}
+	}
+}
+
+func TestConvertOddsInvalid(t *testing.T) {
+	for _, decimal := range []float64{0, 1, -2} {
+		if got := ConvertOdds(decimal, OddsFormatDecimal); got != nil {
+			t.Errorf("ConvertOdds(%v) = %+v, want nil", decimal, got)
+		}
+	}
+}
+
+func TestConvertOddsImpliedProbability(t *testing.T) {
+	cases := map[float64]float64{1.01: 0.9901, 1.5: 0.6667, 2.0: 0.5, 1500: 0.0007}
+	for decimal, want := range cases {
+		if got := ConvertOdds(decimal, OddsFormatAmerican).ImpliedProbability; got != want {
+			t.Errorf("implied probability of %v = %v, want %v", decimal, got, want)
+		}
+	}
+}
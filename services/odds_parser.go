@@ -3,14 +3,19 @@ package services
 
 import (
 	"database/sql"
-	"encoding/xml"
 	"fmt"
+	"strconv"
+
+	"uof-service/uof"
 )
 
 // OddsParser 赔率解析器
 type OddsParser struct {
 	db                *sql.DB
 	marketDescService *MarketDescriptionsService
+	marginService     *MarginService
+	marginTracker     *MarketMarginTracker
+	closingLines      *ClosingLineService
 }
 
 // NewOddsParser 创建赔率解析器
@@ -21,38 +26,37 @@ func NewOddsParser(db *sql.DB, marketDescService *MarketDescriptionsService) *Od
 	}
 }
 
-// OddsChangeData 赔率变化数据结构
-type OddsChangeData struct {
-	EventID   string       `xml:"event_id,attr"`
-	Timestamp int64        `xml:"timestamp,attr"`
-	ProductID int          `xml:"product,attr"`
-	Markets   []MarketData `xml:"odds>market"`
+// SetMarginService 设置利润率服务 (入库时应用利润率)
+func (p *OddsParser) SetMarginService(marginService *MarginService) {
+	p.marginService = marginService
 }
 
-// MarketData 盘口数据
-type MarketData struct {
-	ID        string        `xml:"id,attr"`
-	Status    string        `xml:"status,attr"`
-	Specifiers string       `xml:"specifiers,attr"`
-	Outcomes  []OutcomeData `xml:"outcome"`
+// SetMarginTracker 设置返还率统计 (入库时记录每个盘口的返还率)
+func (p *OddsParser) SetMarginTracker(marginTracker *MarketMarginTracker) {
+	p.marginTracker = marginTracker
 }
 
-// OutcomeData 结果数据
-type OutcomeData struct {
-	ID     string  `xml:"id,attr"`
-	Odds   float64 `xml:"odds,attr"`
-	Active int     `xml:"active,attr"`
+// SetClosingLineService 设置收盘赔率服务 (开赛时保存最后的 prematch 赔率)
+func (p *OddsParser) SetClosingLineService(closingLines *ClosingLineService) {
+	p.closingLines = closingLines
 }
 
-// ParseAndStoreOdds 解析并存储赔率数据
-func (p *OddsParser) ParseAndStoreOdds(xmlData []byte, productID int) error {
-	var oddsChange OddsChangeData
-	if err := xml.Unmarshal(xmlData, &oddsChange); err != nil {
-		return fmt.Errorf("failed to parse odds_change XML: %w", err)
+// StoreOdds 存储 odds_change 中的盘口和赔率
+// 消息由多个处理器共享, 应用利润率前先复制一份
+func (p *OddsParser) StoreOdds(message *uof.OddsChange, productID int) error {
+	oddsChange := message.Clone()
+	
+	// 应用利润率: Odds 为发布赔率, RawOdds 为原始赔率
+	var profiles []*MarginProfile
+	if p.marginService != nil {
+		profiles = p.marginService.ApplyToOddsChange(oddsChange)
 	}
 	
 		// 日志已移至 odds_change_parser.go
 	
+	// 非对阵赛事的结果是参赛方 URN, 准备参赛方名称用于结果命名
+	competitors := p.outrightCompetitors(oddsChange)
+	
 	// 开始事务
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -60,12 +64,35 @@ func (p *OddsParser) ParseAndStoreOdds(xmlData []byte, productID int) error {
 	}
 	defer tx.Rollback()
 	
+	// 收盘赔率快照 (必须在覆盖 prematch 赔率之前)
+	closingCaptured := false
+	if p.closingLines != nil {
+		if closingCaptured, err = p.closingLines.CaptureFromOddsChange(tx, oddsChange, productID); err != nil {
+			return err
+		}
+	}
+	
 	// 存储每个盘口
-	for _, market := range oddsChange.Markets {
-		if err := p.storeMarket(tx, oddsChange.EventID, market, oddsChange.Timestamp, productID); err != nil {
+	lineMarkets := make(map[string]bool)
+	for i, market := range oddsChange.Odds.Markets {
+		var profile *MarginProfile
+		if profiles != nil {
+			profile = profiles[i]
+		}
+		if err := p.storeMarket(tx, oddsChange.EventID, market, profile, competitors, oddsChange.Timestamp, productID); err != nil {
 				// 错误日志已简化
 				continue
 		}
+		if _, _, isLine := SplitLineSpecifier(market.Specifiers); isLine {
+			lineMarkets[strconv.Itoa(market.ID)] = true
+		}
+	}
+	
+	// 重新计算涉及的让球 / 大小球盘口族的主盘口
+	for marketID := range lineMarkets {
+		if err := updateMainLines(tx, oddsChange.EventID, marketID); err != nil {
+			return fmt.Errorf("failed to update main lines: %w", err)
+		}
 	}
 	
 	// 提交事务
@@ -73,65 +100,126 @@ func (p *OddsParser) ParseAndStoreOdds(xmlData []byte, productID int) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
+	// 快照随事务提交后才标记, 回滚时下一条 live 消息会重新快照
+	if closingCaptured {
+		p.closingLines.MarkCaptured(oddsChange.EventID)
+	}
+	
 	// 日志已在 odds_change_parser.go 中输出
 	return nil
 }
 
+// outrightCompetitors 返回非对阵赛事的参赛方列表 (对阵比赛返回 nil, 名称使用 markets 中的主客队)
+// 优先使用消息中的参赛方, 否则读取 fixture / schedule 保存的参赛方列表
+func (p *OddsParser) outrightCompetitors(oddsChange *uof.OddsChange) []EventCompetitor {
+	eventType := uof.EventType(oddsChange.EventID)
+	if eventType == "" || eventType == uof.EventTypeMatch {
+		return nil
+	}
+	if len(oddsChange.SportEvent.Competitors) > 0 {
+		return EventCompetitorsFrom(oddsChange.SportEvent.Competitors)
+	}
+	competitors, err := LoadEventCompetitors(p.db, oddsChange.EventID)
+	if err != nil {
+		return nil
+	}
+	return competitors
+}
+
 // storeMarket 存储盘口数据
-func (p *OddsParser) storeMarket(tx *sql.Tx, eventID string, market MarketData, timestamp int64, productID int) error {
+// profile 为应用的利润率配置 (可为 nil), competitors 为非对阵赛事的参赛方列表 (可为 nil)
+func (p *OddsParser) storeMarket(tx *sql.Tx, eventID string, market uof.Market, profile *MarginProfile, competitors []EventCompetitor, timestamp int64, productID int) error {
+	srMarketID := strconv.Itoa(market.ID)
+
 	// 1. 插入或更新盘口
 	// 注意: markets 表没有 timestamp 字段,我们使用 updated_at 来判断
 	// 但这不是最优方案,理想情况下应该添加 timestamp 字段
+	// prev 读取更新前的盘口状态 (CTE 看到的是语句执行前的数据), 用于判断 K 线是否需要更新
 	marketQuery := `
-		INSERT INTO markets (event_id, sr_market_id, market_type, specifiers, status, producer_id, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		WITH prev AS (
+			SELECT status FROM markets WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $4
+		)
+		INSERT INTO markets (event_id, sr_market_id, market_type, specifiers, status, producer_id, groups, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NOW())
 		ON CONFLICT (event_id, sr_market_id, specifiers) DO UPDATE
 		SET status = EXCLUDED.status, 
 		    producer_id = EXCLUDED.producer_id, 
+		    groups = COALESCE(EXCLUDED.groups, markets.groups),
 		    updated_at = NOW()
-		RETURNING id
+		RETURNING id, (SELECT status FROM prev)
 	`
 	
 	var marketPK int
+	var prevStatus sql.NullString
 	err := tx.QueryRow(marketQuery, 
 		eventID, 
-		market.ID, 
-		p.getMarketType(market.ID),
+		srMarketID, 
+		p.getMarketType(srMarketID),
 		market.Specifiers,
-		market.Status,
+		strconv.Itoa(market.Status),
 		productID,
-	).Scan(&marketPK)
+		p.getMarketGroups(srMarketID),
+	).Scan(&marketPK, &prevStatus)
 	
 	if err != nil {
 		return fmt.Errorf("failed to insert/update market: %w", err)
 	}
 	
-	// 2. 存储每个结果的赔率
+	// 2. 存储每个结果的赔率, 并更新 K 线
+	prevMarketSuspended := prevStatus.String != strconv.Itoa(uof.MarketStatusActive)
 		for _, outcome := range market.Outcomes {
-if err := p.storeOdds(tx, marketPK, eventID, market.ID, market.Specifiers, outcome, timestamp); err != nil {
+				prev, err := p.storeOdds(tx, marketPK, eventID, srMarketID, market.Specifiers, outcome, profile, competitors, timestamp)
+				if err != nil {
 					return fmt.Errorf("failed to store odds: %w", err)
 				}
+				// 赔率和暂停状态都没变时不更新 K 线 (查询时由上一根 K 线补齐, 暂停时长在下次变化时补记)
+				suspended := market.Status != uof.MarketStatusActive || outcome.Active != 1
+				if prev.found && prev.odds == outcome.Odds && (prevMarketSuspended || !prev.active) == suspended {
+					continue
+				}
+				if err := updateOddsCandles(tx, marketPK, eventID, srMarketID, market.Specifiers, outcome.ID, outcome.Odds, suspended, timestamp); err != nil {
+					return err
+				}
 			}
+	
+	// 3. 记录盘口返还率
+	if p.marginTracker != nil {
+		if err := p.marginTracker.Record(tx, marketPK, eventID, market, profile, timestamp); err != nil {
+			return fmt.Errorf("failed to record market margin: %w", err)
+		}
+	}
 	return nil
 }
 
-// storeOdds stores the odds
+// storedOdds 入库前的结果状态
+type storedOdds struct {
+	found  bool
+	odds   float64
+	active bool
+}
+
+// storeOdds stores the odds, 返回入库前的结果状态
 func (p *OddsParser) storeOdds(
 	tx *sql.Tx, 
 	marketPK int, 
 	eventID string, 
 	marketID string, 
 	specifiers string, 
-	outcome OutcomeData, 
+	outcome uof.Outcome, 
+	marginProfile *MarginProfile,
+	competitors []EventCompetitor,
 	timestamp int64,
-) error {
+) (storedOdds, error) {
 	// 查询旧赔率
+	var prev storedOdds
 	var oldOdds sql.NullFloat64
-	oldOddsQuery := `SELECT odds_value FROM odds WHERE market_id = $1 AND outcome_id = $2`
-	err := tx.QueryRow(oldOddsQuery, marketPK, outcome.ID).Scan(&oldOdds)
+	oldOddsQuery := `SELECT odds_value, COALESCE(active, false) FROM odds WHERE market_id = $1 AND outcome_id = $2`
+	err := tx.QueryRow(oldOddsQuery, marketPK, outcome.ID).Scan(&oldOdds, &prev.active)
 if err != nil && err != sql.ErrNoRows {
-    return fmt.Errorf("failed to query old odds: %w", err)
+    return prev, fmt.Errorf("failed to query old odds: %w", err)
 }
+	prev.found = err == nil
+	prev.odds = oldOdds.Float64
 	
 	// 计算隐含概率
 	probability := 0.0
@@ -145,27 +233,41 @@ if err != nil && err != sql.ErrNoRows {
 	err = tx.QueryRow(teamQuery, marketPK).Scan(&homeTeamName, &awayTeamName)
 if err != nil && err != sql.ErrNoRows {
     // 这里的 markets 表应该总是有数据，如果没数据说明 marketPK 是错的，应该返回错误
-    return fmt.Errorf("failed to query market info for ReplacementContext: %w", err)
+    return prev, fmt.Errorf("failed to query market info for ReplacementContext: %w", err)
 }
 	
 	// 使用 MarketDescriptionsService 获取 outcome 名称
 	outcomeName := p.getOutcomeName(outcome.ID) // fallback
 	if p.marketDescService != nil {
-		ctx := &ReplacementContext{
-			HomeTeamName: homeTeamName.String,
-			AwayTeamName: awayTeamName.String,
-			Specifiers:   specifiers,
+		ctx := NewReplacementContext(competitors, specifiers)
+		if homeTeamName.String != "" {
+			ctx.HomeTeamName = homeTeamName.String
+		}
+		if awayTeamName.String != "" {
+			ctx.AwayTeamName = awayTeamName.String
 		}
 		outcomeName = p.marketDescService.GetOutcomeName(marketID, outcome.ID, specifiers, ctx)
 	}
 	
+	// 未应用利润率时原始赔率即发布赔率
+	rawOdds := outcome.RawOdds
+	if rawOdds == 0 {
+		rawOdds = outcome.Odds
+	}
+	var marginProfileID *int
+	if marginProfile != nil {
+		marginProfileID = &marginProfile.ID
+	}
+	
 	// 插入或更新当前赔率
 	oddsQuery := `
-		INSERT INTO odds (market_id, event_id, outcome_id, outcome_name, odds_value, probability, active, timestamp, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		INSERT INTO odds (market_id, event_id, outcome_id, outcome_name, odds_value, raw_odds_value, margin_profile_id, probability, active, timestamp, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
 		ON CONFLICT (market_id, outcome_id) DO UPDATE
 		SET 
 			    odds_value = EXCLUDED.odds_value,
+			    raw_odds_value = EXCLUDED.raw_odds_value,
+			    margin_profile_id = EXCLUDED.margin_profile_id,
 			    outcome_name = EXCLUDED.outcome_name,
 			    probability = EXCLUDED.probability,
 			    active = EXCLUDED.active,
@@ -180,13 +282,15 @@ if err != nil && err != sql.ErrNoRows {
 		outcome.ID,  // 使用完整的 URN
 		outcomeName,
 		outcome.Odds,
+		rawOdds,
+		marginProfileID,
 		probability,
 		outcome.Active == 1,
 		timestamp,
 	)
 	
 	if err != nil {
-		return fmt.Errorf("failed to insert/update odds: %w", err)
+		return prev, fmt.Errorf("failed to insert/update odds: %w", err)
 	}
 	
 	// 如果赔率有变化,记录到历史表
@@ -213,7 +317,7 @@ if err != nil && err != sql.ErrNoRows {
 		)
 		
 					if err != nil {
-						return fmt.Errorf("failed to insert odds history: %w", err)
+						return prev, fmt.Errorf("failed to insert odds history: %w", err)
 					}
 	} else if !oldOdds.Valid {
 		// 新赔率
@@ -224,11 +328,19 @@ if err != nil && err != sql.ErrNoRows {
 		
 				_, err = tx.Exec(historyQuery, marketPK, eventID, outcome.ID, outcomeName, outcome.Odds, probability, timestamp)
 					if err != nil {
-						return fmt.Errorf("failed to insert new odds history: %w", err)
+						return prev, fmt.Errorf("failed to insert new odds history: %w", err)
 					}
 	}
 	
-	return nil
+	return prev, nil
+}
+
+// getMarketGroups 获取盘口所属的组 (来自 market_descriptions, 用于按组暂停和标签页)
+func (p *OddsParser) getMarketGroups(marketID string) string {
+	if p.marketDescService == nil {
+		return ""
+	}
+	return p.marketDescService.GetMarketGroups(marketID)
 }
 
 // getMarketType 获取盘口类型
@@ -281,6 +393,7 @@ func (p *OddsParser) getOutcomeName(outcomeID string) string {
 func (p *OddsParser) GetMarketOdds(eventID, marketID string) ([]OddsDetail, error) {
 	query := `
 		SELECT 
+			COALESCE(m.specifiers, ''),
 			o.outcome_id,
 			o.outcome_name,
 			o.odds_value,
@@ -291,7 +404,7 @@ func (p *OddsParser) GetMarketOdds(eventID, marketID string) ([]OddsDetail, erro
 		FROM odds o
 		JOIN markets m ON o.market_id = m.id
 		WHERE m.event_id = $1 AND m.sr_market_id = $2
-		ORDER BY o.outcome_id
+		ORDER BY m.specifiers, o.outcome_id
 	`
 	
 	rows, err := p.db.Query(query, eventID, marketID)
@@ -304,6 +417,7 @@ func (p *OddsParser) GetMarketOdds(eventID, marketID string) ([]OddsDetail, erro
 	for rows.Next() {
 		var odds OddsDetail
 		err := rows.Scan(
+			&odds.Specifiers,
 			&odds.OutcomeID,
 			&odds.OutcomeName,
 			&odds.OddsValue,
@@ -322,10 +436,11 @@ func (p *OddsParser) GetMarketOdds(eventID, marketID string) ([]OddsDetail, erro
 	return oddsList, nil
 }
 
-// GetOddsHistory 获取赔率变化历史
-func (p *OddsParser) GetOddsHistory(eventID, marketID, outcomeID string, limit int) ([]OddsHistoryInfo, error) {
+// GetOddsHistory 获取赔率变化历史, specifiers 为空时返回该盘口所有盘口线的历史
+func (p *OddsParser) GetOddsHistory(eventID, marketID, specifiers, outcomeID string, limit int) ([]OddsHistoryInfo, error) {
 	query := `
 		SELECT 
+			COALESCE(m.specifiers, ''),
 			oh.odds_value,
 			oh.probability,
 			oh.change_type,
@@ -333,11 +448,13 @@ func (p *OddsParser) GetOddsHistory(eventID, marketID, outcomeID string, limit i
 			oh.created_at
 		FROM odds_history oh
 		JOIN markets m ON oh.market_id = m.id
-		WHERE m.event_id = $1 AND m.sr_market_id = $2 AND oh.outcome_id = $3	ORDER BY oh.created_at DESC
+		WHERE m.event_id = $1 AND m.sr_market_id = $2 AND oh.outcome_id = $3
+		  AND ($5 = '' OR COALESCE(m.specifiers, '') = $5)
+		ORDER BY oh.created_at DESC
 		LIMIT $4
 	`
 	
-	rows, err := p.db.Query(query, eventID, marketID, outcomeID, limit)
+	rows, err := p.db.Query(query, eventID, marketID, outcomeID, limit, specifiers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query odds history: %w", err)
 	}
@@ -347,6 +464,7 @@ func (p *OddsParser) GetOddsHistory(eventID, marketID, outcomeID string, limit i
 	for rows.Next() {
 		var history OddsHistoryInfo
 		err := rows.Scan(
+			&history.Specifiers,
 			&history.OddsValue,
 			&history.Probability,
 			&history.ChangeType,
@@ -365,6 +483,7 @@ func (p *OddsParser) GetOddsHistory(eventID, marketID, outcomeID string, limit i
 
 // OddsDetail 赔率详情
 type OddsDetail struct {
+	Specifiers  string  `json:"specifiers,omitempty"`
 	OutcomeID   string  `json:"outcome_id"`
 	OutcomeName string  `json:"outcome_name"`
 	OddsValue   float64 `json:"odds_value"`
@@ -372,15 +491,18 @@ type OddsDetail struct {
 	Active      bool    `json:"active"`
 	Timestamp   int64   `json:"timestamp"`
 	UpdatedAt   string  `json:"updated_at"`
+	Formatted   *FormattedOdds `json:"formatted,omitempty"` // 请求 odds_format 时返回
 }
 
 // OddsHistoryInfo 赔率历史信息
 type OddsHistoryInfo struct {
+	Specifiers  string  `json:"specifiers,omitempty"`
 	OddsValue   float64 `json:"odds_value"`
 	Probability float64 `json:"probability"`
 	ChangeType  string  `json:"change_type"`
 	Timestamp   int64   `json:"timestamp"`
 	CreatedAt   string  `json:"created_at"`
+	Formatted   *FormattedOdds `json:"formatted,omitempty"` // 请求 odds_format 时返回
 }
 
 // GetEventMarkets 获取比赛的所有盘口
@@ -393,6 +515,7 @@ func (p *OddsParser) GetEventMarkets(eventID string) ([]OddsMarketInfo, error) {
 			m.market_name,
 			m.specifiers,
 			m.status,
+			COALESCE(m.groups, '') as groups,
 			COUNT(o.id) as odds_count,
 			m.updated_at
 		FROM markets m
@@ -413,6 +536,7 @@ func (p *OddsParser) GetEventMarkets(eventID string) ([]OddsMarketInfo, error) {
 		var market OddsMarketInfo
 		var specifiers sql.NullString
 		var marketName sql.NullString
+		var groups string
 		
 		err := rows.Scan(
 			&market.ID,
@@ -421,6 +545,7 @@ func (p *OddsParser) GetEventMarkets(eventID string) ([]OddsMarketInfo, error) {
 			&marketName,
 			&specifiers,
 			&market.Status,
+			&groups,
 			&market.OddsCount,
 			&market.UpdatedAt,
 		)
@@ -437,6 +562,10 @@ func (p *OddsParser) GetEventMarkets(eventID string) ([]OddsMarketInfo, error) {
 		} else {
 			market.MarketName = p.getMarketTypeName(market.MarketType)
 		}
+		if groups == "" {
+			groups = p.getMarketGroups(market.MarketID)
+		}
+		market.Groups = SplitMarketGroups(groups)
 		
 		markets = append(markets, market)
 	}
@@ -452,6 +581,7 @@ type OddsMarketInfo struct {
 	MarketName  string `json:"market_name"`
 	Specifiers  string `json:"specifiers,omitempty"`
 	Status      string `json:"status"`
+	Groups      []string `json:"groups"`
 	OddsCount   int    `json:"odds_count"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"uof-service/uof"
+)
+
+// OddsStateCache 每个赛事最近一次广播的盘口状态
+// 用于生成增量 (delta) 格式的 odds_change: 只包含赔率/active/盘口状态有变化的部分
+type OddsStateCache struct {
+	events  map[string]*eventOddsState
+	maxIdle time.Duration
+	lastGC  time.Time
+	mu      sync.Mutex
+}
+
+type eventOddsState struct {
+	markets   map[string]*marketOddsState // key: producer|market_id|specifier
+	updatedAt time.Time
+}
+
+type marketOddsState struct {
+	status   int
+	outcomes map[string]outcomeOddsState
+}
+
+type outcomeOddsState struct {
+	odds   float64
+	active int
+}
+
+// NewOddsStateCache 创建赔率状态缓存, maxIdle 内没有更新的赛事会被清理
+func NewOddsStateCache(maxIdle time.Duration) *OddsStateCache {
+	return &OddsStateCache{
+		events:  make(map[string]*eventOddsState),
+		maxIdle: maxIdle,
+	}
+}
+
+// Diff 将 odds_change 与缓存状态比较, 返回有变化的盘口 (并更新缓存)
+// 首次出现的盘口/结果视为变化
+func (c *OddsStateCache) Diff(oddsChange *uof.OddsChange) []map[string]interface{} {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gc(now)
+
+	state, ok := c.events[oddsChange.EventID]
+	if !ok {
+		state = &eventOddsState{markets: make(map[string]*marketOddsState)}
+		c.events[oddsChange.EventID] = state
+	}
+	state.updatedAt = now
+
+	changed := make([]map[string]interface{}, 0)
+	for _, market := range oddsChange.Odds.Markets {
+		// prematch 和 live producer 会同时为同一盘口报价, 各自维护状态, 避免交替的消息被当作变化
+		key := strconv.Itoa(oddsChange.ProductID) + "|" + strconv.Itoa(market.ID) + "|" + market.Specifiers
+
+		ms, exists := state.markets[key]
+		if !exists {
+			ms = &marketOddsState{status: market.Status, outcomes: make(map[string]outcomeOddsState)}
+			state.markets[key] = ms
+		}
+		statusChanged := !exists || ms.status != market.Status
+		ms.status = market.Status
+
+		outcomes := make([]map[string]interface{}, 0)
+		for _, outcome := range market.Outcomes {
+			prev, seen := ms.outcomes[outcome.ID]
+			if seen && prev.odds == outcome.Odds && prev.active == outcome.Active {
+				continue
+			}
+			ms.outcomes[outcome.ID] = outcomeOddsState{odds: outcome.Odds, active: outcome.Active}
+			outcomes = append(outcomes, map[string]interface{}{
+				"id":     outcome.ID,
+				"odds":   outcome.Odds,
+				"active": outcome.Active,
+			})
+		}
+
+		if !statusChanged && len(outcomes) == 0 {
+			continue
+		}
+
+		changed = append(changed, map[string]interface{}{
+			"id":        market.ID,
+			"specifier": market.Specifiers,
+			"status":    market.Status,
+			"outcomes":  outcomes,
+		})
+	}
+
+	return changed
+}
+
+// gc 清理长时间未更新的赛事 (调用方需持有 c.mu)
+func (c *OddsStateCache) gc(now time.Time) {
+	if now.Sub(c.lastGC) < c.maxIdle/4 {
+		return
+	}
+	for eventID, state := range c.events {
+		if now.Sub(state.updatedAt) > c.maxIdle {
+			delete(c.events, eventID)
+		}
+	}
+	c.lastGC = now
+}
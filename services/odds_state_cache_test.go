@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"uof-service/uof"
+)
+
+func oddsChangeFor(productID int, odds float64) *uof.OddsChange {
+	oc := &uof.OddsChange{}
+	oc.EventID = "sr:match:1"
+	oc.ProductID = productID
+	oc.Odds.Markets = []uof.Market{{
+		ID:       1,
+		Status:   1,
+		Outcomes: []uof.Outcome{{ID: "1", Odds: odds, Active: 1}},
+	}}
+	return oc
+}
+
+func TestOddsStateCacheDiffPerProducer(t *testing.T) {
+	cache := NewOddsStateCache(time.Hour)
+
+	if got := cache.Diff(oddsChangeFor(3, 1.80)); len(got) != 1 {
+		t.Fatalf("first prematch odds_change: got %d changed markets, want 1", len(got))
+	}
+	if got := cache.Diff(oddsChangeFor(1, 1.90)); len(got) != 1 {
+		t.Fatalf("first live odds_change: got %d changed markets, want 1", len(got))
+	}
+
+	// 两个 producer 交替发送不变的赔率, 不应产生增量
+	if got := cache.Diff(oddsChangeFor(3, 1.80)); len(got) != 0 {
+		t.Errorf("unchanged prematch odds_change: got %d changed markets, want 0", len(got))
+	}
+	if got := cache.Diff(oddsChangeFor(1, 1.90)); len(got) != 0 {
+		t.Errorf("unchanged live odds_change: got %d changed markets, want 0", len(got))
+	}
+
+	if got := cache.Diff(oddsChangeFor(1, 2.00)); len(got) != 1 {
+		t.Errorf("changed live odds_change: got %d changed markets, want 1", len(got))
+	}
+}
@@ -39,6 +39,7 @@ type PlayersService struct {
 	apiBaseURL  string
 	token       string
 	players     map[string]string // player_id -> player_name
+	localization *LocalizationService // 多语言名称服务 (可选)
 	mu          sync.RWMutex
 	lastUpdated time.Time
 }
@@ -53,6 +54,11 @@ func NewPlayersService(token string, apiBaseURL string, db *sql.DB) *PlayersServ
 	}
 }
 
+// SetLocalizationService 设置多语言名称服务 (可选)
+func (s *PlayersService) SetLocalizationService(localization *LocalizationService) {
+	s.localization = localization
+}
+
 // Start 启动服务
 func (s *PlayersService) Start() error {
 	logger.Println("[PlayersService] Starting Players Service...")
@@ -163,6 +169,14 @@ func (s *PlayersService) GetPlayerName(playerID string) string {
 	return profile.Player.Name
 }
 
+// GetLocalizedPlayerName 获取指定语言的球员名称, 没有翻译时返回英文名称 (翻译在后台加载)
+func (s *PlayersService) GetLocalizedPlayerName(playerID, lang string) string {
+	if name := s.localization.Localize(playerID, lang, ""); name != "" {
+		return name
+	}
+	return s.GetPlayerName(playerID)
+}
+
 // loadPlayerFromAPI 从 API 加载球员信息
 // 该方法现在只在 PreloadPlayers 中使用
 func (s *PlayersService) loadPlayerFromAPI(playerID string) error {
@@ -247,6 +261,18 @@ func (s *PlayersService) savePlayer(player *PlayerProfile) error {
 	return nil
 }
 
+// cachePlayerNames 更新球员名称缓存 (球员已由其它服务写入数据库, 例如参赛方阵容)
+func (s *PlayersService) cachePlayerNames(names map[string]string) {
+	if s == nil || len(names) == 0 {
+		return
+	}
+	s.mu.Lock()
+	for playerID, name := range names {
+		s.players[playerID] = name
+	}
+	s.mu.Unlock()
+}
+
 // PreloadPlayers 批量预加载球员信息
 func (s *PlayersService) PreloadPlayers(players []PlayerInfo) {
 	// 使用 goroutine 并发加载球员信息
@@ -7,8 +7,10 @@ import (
 	"io"
 	"net/http"
 	"time"
+
 	"uof-service/config"
 	"uof-service/logger"
+	"uof-service/uof"
 )
 
 // PrematchService Pre-match 赛事订阅服务
@@ -47,6 +49,7 @@ type PrematchEvent struct {
 	Status      string               `xml:"status,attr"`
 	LiveOdds    string               `xml:"liveodds,attr"`
 	Sport       PrematchSport        `xml:"sport"`
+	Tournament  uof.Tournament       `xml:"tournament"`
 	Competitors []PrematchCompetitor `xml:"competitors>competitor"`
 }
 
@@ -151,8 +154,11 @@ func (s *PrematchService) StorePrematchEvents(events []PrematchEvent) (int, erro
 			}
 		}
 
-		// 获取 sport_id
+		// 获取 sport_id (非对阵赛事可能只在 tournament 下带有 sport)
 		sportID := event.Sport.ID
+		if sportID == "" {
+			sportID = event.Tournament.Sport.ID
+		}
 		if sportID == "" {
 			sportID = "unknown"
 		}
@@ -162,10 +168,13 @@ func (s *PrematchService) StorePrematchEvents(events []PrematchEvent) (int, erro
 			INSERT INTO tracked_events (
 				event_id, sport_id, status, schedule_time, 
 				home_team_id, home_team_name, away_team_id, away_team_name,
-				subscribed, created_at, updated_at
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				subscribed, created_at, updated_at,
+				tournament_id, tournament_name
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 			ON CONFLICT (event_id) DO UPDATE SET
 				sport_id = CASE WHEN EXCLUDED.sport_id = 'unknown' THEN tracked_events.sport_id ELSE EXCLUDED.sport_id END,
+				tournament_id = COALESCE(NULLIF(EXCLUDED.tournament_id, ''), tracked_events.tournament_id),
+				tournament_name = COALESCE(NULLIF(EXCLUDED.tournament_name, ''), tracked_events.tournament_name),
 				status = EXCLUDED.status,
 				schedule_time = EXCLUDED.schedule_time,
 				home_team_id = CASE WHEN EXCLUDED.home_team_id = '' THEN tracked_events.home_team_id ELSE EXCLUDED.home_team_id END,
@@ -188,6 +197,8 @@ func (s *PrematchService) StorePrematchEvents(events []PrematchEvent) (int, erro
 			false, // 默认未订阅
 			time.Now(),
 			time.Now(),
+			event.Tournament.ID,
+			event.Tournament.Name,
 		)
 
 		if err != nil {
@@ -195,6 +206,14 @@ func (s *PrematchService) StorePrematchEvents(events []PrematchEvent) (int, erro
 			continue
 		}
 
+		competitors := make([]uof.Competitor, 0, len(event.Competitors))
+		for _, comp := range event.Competitors {
+			competitors = append(competitors, uof.Competitor{ID: comp.ID, Name: comp.Name, Qualifier: comp.Qualifier})
+		}
+		if err := SaveEventCompetitors(s.db, event.ID, competitors); err != nil {
+			logger.Printf("[PrematchService] ⚠️  Failed to store competitors for %s: %v", event.ID, err)
+		}
+
 		stored++
 	}
 
@@ -1,46 +1,33 @@
 package services
 
 import (
-"os"
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+
+	"uof-service/uof"
 )
 
 // RollbackBetCancelProcessor Rollback Bet Cancel 消息处理器
 type RollbackBetCancelProcessor struct {
-	db     *sql.DB
-	logger *log.Logger
-}
-
-// RollbackBetCancelMessage Rollback Bet Cancel 消息结构
-type RollbackBetCancelMessage struct {
-	XMLName   xml.Name `xml:"rollback_bet_cancel"`
-	EventID   string   `xml:"event_id,attr"`
-	ProductID int      `xml:"product,attr"`
-	Timestamp int64    `xml:"timestamp,attr"`
-	Market    []struct {
-		ID         string `xml:"id,attr"`
-		Specifiers string `xml:"specifiers,attr"`
-	} `xml:"market"`
+	db      *sql.DB
+	cancels *BetCancelService
+	logger  *log.Logger
 }
 
 // NewRollbackBetCancelProcessor 创建 Rollback Bet Cancel 处理器
 func NewRollbackBetCancelProcessor(db *sql.DB) *RollbackBetCancelProcessor {
 	return &RollbackBetCancelProcessor{
-		db:     db,
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		db:      db,
+		cancels: NewBetCancelService(db),
+		logger:  log.New(os.Stdout, "", log.LstdFlags),
 	}
 }
 
 // ProcessRollbackBetCancel 处理 Rollback Bet Cancel 消息
-func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(xmlContent string) error {
-	var rollback RollbackBetCancelMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &rollback); err != nil {
-		return fmt.Errorf("failed to parse rollback_bet_cancel message: %w", err)
-	}
-
+func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(rollback *uof.RollbackBetCancel) error {
 	// 开始事务
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -49,13 +36,15 @@ func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(xmlContent string)
 	defer tx.Rollback()
 
 	// 遍历所有市场
-	for _, market := range rollback.Market {
+	for _, market := range rollback.Markets {
+		marketID := strconv.Itoa(market.ID)
+
 		// 1. 删除 bet_cancels 表中的取消记录
 		deleteQuery := `
 			DELETE FROM bet_cancels
 			WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND producer_id = $4
 		`
-		_, err := tx.Exec(deleteQuery, rollback.EventID, market.ID, market.Specifiers, rollback.ProductID)
+		_, err := tx.Exec(deleteQuery, rollback.EventID, marketID, market.Specifiers, rollback.ProductID)
 		if err != nil {
 			p.logger.Printf("Warning: failed to delete cancel record: %v", err)
 		}
@@ -66,7 +55,7 @@ func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(xmlContent string)
 			SET status = 1, updated_at = NOW()
 			WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3
 		`
-		_, err = tx.Exec(updateQuery, rollback.EventID, market.ID, market.Specifiers)
+		_, err = tx.Exec(updateQuery, rollback.EventID, marketID, market.Specifiers)
 		if err != nil {
 			p.logger.Printf("Warning: failed to restore market status to active: %v", err)
 		}
@@ -88,7 +77,7 @@ func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(xmlContent string)
 			rollback.EventID,
 			rollback.ProductID,
 			rollback.Timestamp,
-			market.ID,
+			marketID,
 			market.Specifiers,
 		)
 		if err != nil {
@@ -96,14 +85,20 @@ func (p *RollbackBetCancelProcessor) ProcessRollbackBetCancel(xmlContent string)
 		}
 	}
 
+	// 4. 回滚匹配的取消窗口 (保留记录, 标记回滚时间)
+	windows, err := p.cancels.RecordRollback(tx, rollback)
+	if err != nil {
+		return fmt.Errorf("failed to roll back bet cancel windows: %w", err)
+	}
+
 	// 提交事务
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	// 输出自然语言日志
-	p.logger.Printf("[rollback_bet_cancel] 比赛 %s 的 %d个市场取消已回滚",
-		rollback.EventID, len(rollback.Market))
+	p.logger.Printf("[rollback_bet_cancel] 比赛 %s 的 %d个市场取消已回滚, %d 个取消窗口失效",
+		rollback.EventID, len(rollback.Markets), windows)
 
 	return nil
 }
@@ -1,46 +1,33 @@
 package services
 
 import (
-"os"
 	"database/sql"
-	"encoding/xml"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+
+	"uof-service/uof"
 )
 
 // RollbackBetSettlementProcessor Rollback Bet Settlement 消息处理器
 type RollbackBetSettlementProcessor struct {
 	db     *sql.DB
+	ledger *SettlementLedgerService
 	logger *log.Logger
 }
 
-// RollbackBetSettlementMessage Rollback Bet Settlement 消息结构
-type RollbackBetSettlementMessage struct {
-	XMLName   xml.Name `xml:"rollback_bet_settlement"`
-	EventID   string   `xml:"event_id,attr"`
-	ProductID int      `xml:"product,attr"`
-	Timestamp int64    `xml:"timestamp,attr"`
-	Market    []struct {
-		ID         string `xml:"id,attr"`
-		Specifiers string `xml:"specifiers,attr"`
-	} `xml:"market"`
-}
-
 // NewRollbackBetSettlementProcessor 创建 Rollback Bet Settlement 处理器
 func NewRollbackBetSettlementProcessor(db *sql.DB) *RollbackBetSettlementProcessor {
 	return &RollbackBetSettlementProcessor{
 		db:     db,
+		ledger: NewSettlementLedgerService(db),
 		logger: log.New(os.Stdout, "", log.LstdFlags),
 	}
 }
 
 // ProcessRollbackBetSettlement 处理 Rollback Bet Settlement 消息
-func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(xmlContent string) error {
-	var rollback RollbackBetSettlementMessage
-	if err := xml.Unmarshal([]byte(xmlContent), &rollback); err != nil {
-		return fmt.Errorf("failed to parse rollback_bet_settlement message: %w", err)
-	}
-
+func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(rollback *uof.RollbackBetSettlement) error {
 	// 开始事务
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -49,13 +36,16 @@ func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(xmlContent
 	defer tx.Rollback()
 
 	// 遍历所有市场
-	for _, market := range rollback.Market {
+	ledgerEntries := 0
+	for _, market := range rollback.Markets {
+		marketID := strconv.Itoa(market.ID)
+
 		// 1. 删除 bet_settlements 表中的结算记录
 		deleteQuery := `
 			DELETE FROM bet_settlements
 			WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND producer_id = $4
 		`
-		_, err := tx.Exec(deleteQuery, rollback.EventID, market.ID, market.Specifiers, rollback.ProductID)
+		_, err := tx.Exec(deleteQuery, rollback.EventID, marketID, market.Specifiers, rollback.ProductID)
 		if err != nil {
 			p.logger.Printf("Warning: failed to delete settlement record: %v", err)
 		}
@@ -66,7 +56,7 @@ func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(xmlContent
 			SET status = 1, updated_at = NOW()
 			WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3
 		`
-		_, err = tx.Exec(updateQuery, rollback.EventID, market.ID, market.Specifiers)
+		_, err = tx.Exec(updateQuery, rollback.EventID, marketID, market.Specifiers)
 		if err != nil {
 			p.logger.Printf("Warning: failed to restore market status to active: %v", err)
 		}
@@ -88,12 +78,19 @@ func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(xmlContent
 			rollback.EventID,
 			rollback.ProductID,
 			rollback.Timestamp,
-			market.ID,
+			marketID,
 			market.Specifiers,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert rollback_bet_settlement: %w", err)
 		}
+
+		// 4. 账本中记录回滚 (保留历史版本)
+		n, err := p.ledger.RecordRollback(tx, rollback.EventID, rollback.ProductID, rollback.Timestamp, marketID, market.Specifiers)
+		if err != nil {
+			return fmt.Errorf("failed to record settlement rollback: %w", err)
+		}
+		ledgerEntries += n
 	}
 
 	// 提交事务
@@ -102,8 +99,8 @@ func (p *RollbackBetSettlementProcessor) ProcessRollbackBetSettlement(xmlContent
 	}
 
 	// 输出自然语言日志
-	p.logger.Printf("[rollback_bet_settlement] 比赛 %s 的 %d个市场结算已回滚",
-		rollback.EventID, len(rollback.Market))
+	p.logger.Printf("[rollback_bet_settlement] 比赛 %s 的 %d个市场结算已回滚, 账本新增 %d 条",
+		rollback.EventID, len(rollback.Markets), ledgerEntries)
 
 	return nil
 }
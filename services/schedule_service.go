@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 	"uof-service/logger"
+	"uof-service/uof"
 )
 
 // ScheduleService 赛程服务
@@ -16,6 +17,7 @@ type ScheduleService struct {
 	apiBaseURL  string
 	accessToken string
 	client      *http.Client
+	eventStatus *EventStatusService
 }
 
 // TournamentScheduleResponse API 响应
@@ -32,6 +34,7 @@ type SportEventSummaryResponse struct {
 	SportEvent struct {
 		ID string `xml:"id,attr"`
 	} `xml:"sport_event"`
+	SportEventStatus *uof.SportEventStatus `xml:"sport_event_status"`
 	Lineups struct {
 		Players []struct {
 			ID string `xml:"id,attr"`
@@ -47,6 +50,7 @@ func NewScheduleService(db *sql.DB, accessToken, apiBaseURL string) *ScheduleSer
 		apiBaseURL:  apiBaseURL,
 		accessToken: accessToken,
 		client:      &http.Client{Timeout: 60 * time.Second},
+		eventStatus: NewEventStatusService(db),
 	}
 }
 
@@ -115,7 +119,7 @@ func (s *ScheduleService) FetchUpcomingSchedule() ([]string, error) {
 	return eventIDs, nil
 }
 
-// FetchSportEventSummary 获取比赛阵容信息
+// FetchSportEventSummary 获取比赛阵容信息, 同时保存 summary 中的赛事状态
 func (s *ScheduleService) FetchSportEventSummary(eventID string) ([]PlayerInfo, error) {
 	// 构造 URL: /v1/sports/en/sport_events/{event_id}/summary.xml
 	url := fmt.Sprintf("%s/sports/en/sport_events/%s/summary.xml", s.apiBaseURL, eventID)
@@ -155,6 +159,10 @@ func (s *ScheduleService) FetchSportEventSummary(eventID string) ([]PlayerInfo,
 		return nil, fmt.Errorf("failed to parse XML for event %s: %w", eventID, err)
 	}
 
+	if err := s.eventStatus.Save(eventID, summary.SportEventStatus, EventStatusSourceSummary, 0); err != nil {
+		logger.Errorf("[Schedule] Failed to store sport_event_status for %s: %v", eventID, err)
+	}
+
 	players := make([]PlayerInfo, len(summary.Lineups.Players))
 	for i, p := range summary.Lineups.Players {
 		players[i] = PlayerInfo{
@@ -0,0 +1,551 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"uof-service/uof"
+)
+
+// 账本动作
+const (
+	LedgerActionSettle   = "settle"   // 首次结算
+	LedgerActionResettle = "resettle" // 结果/因子/确定性变化或回滚后再次结算
+	LedgerActionRollback = "rollback" // 结算回滚
+)
+
+// 结算生命周期阶段
+const (
+	SettlementStageEarly      = "early"       // certainty 1: 根据直播数据提前结算, 可能变化
+	SettlementStageConfirmed  = "confirmed"   // certainty 2: 赛后确认
+	SettlementStageRolledBack = "rolled_back" // 已回滚
+)
+
+// SettlementStage 根据 certainty 判断结算阶段
+func SettlementStage(certainty int) string {
+	if certainty >= 2 {
+		return SettlementStageConfirmed
+	}
+	return SettlementStageEarly
+}
+
+// SettlementFlip 提前结算在确认时结果发生变化
+type SettlementFlip struct {
+	EventID            string    `json:"event_id"`
+	ProducerID         int       `json:"producer_id"`
+	SRMarketID         string    `json:"sr_market_id"`
+	Specifiers         string    `json:"specifiers"`
+	OutcomeID          string    `json:"outcome_id"`
+	EarlyEntryID       int64     `json:"early_entry_id"`
+	EarlyProducerID    int       `json:"early_producer_id"`
+	EarlyOutcome       string    `json:"early_outcome"`
+	EarlyWinFactor     float64   `json:"early_win_factor"`
+	EarlyRefundFactor  float64   `json:"early_refund_factor"`
+	EarlyTimestamp     int64     `json:"early_timestamp"`
+	ConfirmedEntryID   int64     `json:"confirmed_entry_id"`
+	ConfirmedOutcome   string    `json:"confirmed_outcome"`
+	ConfirmedWinFactor float64   `json:"confirmed_win_factor"`
+	ConfirmedRefund    float64   `json:"confirmed_refund_factor"`
+	ConfirmedTimestamp int64     `json:"confirmed_timestamp"`
+	ConfirmedAt        time.Time `json:"confirmed_at"`
+}
+
+// SettlementLedgerEntry 结算账本条目 (只追加, 不修改)
+//
+// 有效赔付 = 投注额 * (refund_factor + win_factor * 赔率)
+//   - refund_factor = void_factor (退还本金的比例)
+//   - win_factor    = result * (1 - void_factor) * dead_heat_factor (按赔率派彩的比例)
+type SettlementLedgerEntry struct {
+	ID               int64     `json:"id"`
+	EventID          string    `json:"event_id"`
+	ProducerID       int       `json:"producer_id"`
+	SRMarketID       string    `json:"sr_market_id"`
+	Specifiers       string    `json:"specifiers"`
+	OutcomeID        string    `json:"outcome_id"`
+	Version          int       `json:"version"`
+	Action           string    `json:"action"`
+	Result           *int      `json:"result"`
+	VoidFactor       *float64  `json:"void_factor"`
+	DeadHeatFactor   *float64  `json:"dead_heat_factor"`
+	WinFactor        float64   `json:"win_factor"`
+	RefundFactor     float64   `json:"refund_factor"`
+	Outcome          string    `json:"outcome"` // win, lose, void, half_win, half_lose, dead_heat, rolled_back
+	Stage            string    `json:"stage"`   // early, confirmed, rolled_back
+	Certainty        *int      `json:"certainty"`
+	FlippedFromID    *int64    `json:"flipped_from_id,omitempty"` // 确认结算与之前的提前结算结果不同时, 指向该提前结算条目
+	MessageTimestamp int64     `json:"message_timestamp"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Settled 该条目是否代表有效结算 (回滚后为 false)
+func (e *SettlementLedgerEntry) Settled() bool {
+	return e.Action != LedgerActionRollback
+}
+
+// SettlementLedgerService 结算账本服务
+type SettlementLedgerService struct {
+	db *sql.DB
+}
+
+// NewSettlementLedgerService 创建结算账本服务
+func NewSettlementLedgerService(db *sql.DB) *SettlementLedgerService {
+	return &SettlementLedgerService{db: db}
+}
+
+// EffectiveFactors 根据 result、void_factor 和 dead_heat_factor 计算有效赔付因子
+func EffectiveFactors(result int, voidFactor, deadHeatFactor *float64) (winFactor, refundFactor float64, outcome string) {
+	v := 0.0
+	if voidFactor != nil {
+		v = *voidFactor
+	}
+	dh := 1.0
+	if deadHeatFactor != nil {
+		dh = *deadHeatFactor
+	}
+
+	refundFactor = v
+	if result == 1 {
+		winFactor = (1 - v) * dh
+	}
+
+	switch {
+	case v >= 1:
+		outcome = "void"
+	case result == 1 && v > 0:
+		outcome = "half_win"
+	case result == 1 && dh < 1:
+		outcome = "dead_heat"
+	case result == 1:
+		outcome = "win"
+	case v > 0:
+		outcome = "half_lose"
+	default:
+		outcome = "lose"
+	}
+	return winFactor, refundFactor, outcome
+}
+
+// ledgerLockKey 账本写入的全局 advisory lock (超出 hashtext 的 int4 范围, 不与按文本加的锁冲突)
+const ledgerLockKey int64 = 0x736574746c656467
+
+// lockLedger 在事务内对账本加全局锁, 直到事务提交 / 回滚后释放
+// 写入串行化后, 条目 id 在锁内分配, 较小的 id 总是先提交:
+//   - 同一赛事的版本号顺序递增
+//   - 增量拉取 (id > since_id) 不会漏掉晚提交的较小 id
+func lockLedger(tx *sql.Tx) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, ledgerLockKey); err != nil {
+		return fmt.Errorf("failed to lock settlement ledger: %w", err)
+	}
+	return nil
+}
+
+// latestEntries 查询市场下每个结果的最新账本条目
+func latestEntries(tx *sql.Tx, eventID, marketID, specifiers string) (map[string]*SettlementLedgerEntry, error) {
+	rows, err := tx.Query(`
+		SELECT DISTINCT ON (outcome_id)
+			outcome_id, version, action, result, void_factor, dead_heat_factor, certainty, message_timestamp
+		FROM settlement_ledger
+		WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3
+		ORDER BY outcome_id, version DESC
+	`, eventID, marketID, specifiers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement ledger: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[string]*SettlementLedgerEntry)
+	for rows.Next() {
+		var e SettlementLedgerEntry
+		var result, certainty sql.NullInt64
+		var voidFactor, deadHeat sql.NullFloat64
+		if err := rows.Scan(&e.OutcomeID, &e.Version, &e.Action, &result, &voidFactor, &deadHeat, &certainty, &e.MessageTimestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement ledger: %w", err)
+		}
+		e.Result = nullIntPtr(result)
+		e.Certainty = nullIntPtr(certainty)
+		e.VoidFactor = nullFloatPtr(voidFactor)
+		e.DeadHeatFactor = nullFloatPtr(deadHeat)
+		latest[e.OutcomeID] = &e
+	}
+	return latest, rows.Err()
+}
+
+// lastSettledEntries 查询市场下每个结果最近一次有效结算 (跳过回滚), 用于判断确认时结果是否变化
+func lastSettledEntries(tx *sql.Tx, eventID, marketID, specifiers string) (map[string]*SettlementLedgerEntry, error) {
+	rows, err := tx.Query(`
+		SELECT DISTINCT ON (outcome_id)
+			id, outcome_id, COALESCE(producer_id, 0), COALESCE(stage, ''), COALESCE(outcome, ''),
+			win_factor, refund_factor, message_timestamp
+		FROM settlement_ledger
+		WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND action <> $4
+		ORDER BY outcome_id, version DESC
+	`, eventID, marketID, specifiers, LedgerActionRollback)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement ledger: %w", err)
+	}
+	defer rows.Close()
+
+	settled := make(map[string]*SettlementLedgerEntry)
+	for rows.Next() {
+		var e SettlementLedgerEntry
+		if err := rows.Scan(&e.ID, &e.OutcomeID, &e.ProducerID, &e.Stage, &e.Outcome,
+			&e.WinFactor, &e.RefundFactor, &e.MessageTimestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement ledger: %w", err)
+		}
+		settled[e.OutcomeID] = &e
+	}
+	return settled, rows.Err()
+}
+
+// RecordSettlement 在事务内把 bet_settlement 写入账本
+// 与当前有效结算完全相同 (重复消息、recovery 重放) 或比当前更旧的消息不会产生新版本
+// 返回新增条目数, 以及确认结算 (certainty 2) 与之前提前结算结果不同的结果列表
+func (s *SettlementLedgerService) RecordSettlement(tx *sql.Tx, settlement *uof.BetSettlement) (int, []SettlementFlip, error) {
+	if err := lockLedger(tx); err != nil {
+		return 0, nil, err
+	}
+
+	stage := SettlementStage(settlement.Certainty)
+	recorded := 0
+	var flips []SettlementFlip
+	for _, market := range settlement.Outcomes.Markets {
+		marketID := strconv.Itoa(market.ID)
+		latest, err := latestEntries(tx, settlement.EventID, marketID, market.Specifiers)
+		if err != nil {
+			return recorded, flips, err
+		}
+		lastSettled, err := lastSettledEntries(tx, settlement.EventID, marketID, market.Specifiers)
+		if err != nil {
+			return recorded, flips, err
+		}
+
+		for _, outcome := range market.Outcomes {
+			voidFactor := outcome.VoidFactor
+			if voidFactor == nil {
+				voidFactor = market.VoidFactor
+			}
+
+			action := LedgerActionSettle
+			version := 1
+			if prev, ok := latest[outcome.ID]; ok {
+				if settlement.Timestamp < prev.MessageTimestamp {
+					continue
+				}
+				if prev.Settled() && sameSettlement(prev, outcome.Result, voidFactor, outcome.DeadHeatFactor, settlement.Certainty) {
+					continue
+				}
+				action = LedgerActionResettle
+				version = prev.Version + 1
+			}
+
+			winFactor, refundFactor, label := EffectiveFactors(outcome.Result, voidFactor, outcome.DeadHeatFactor)
+
+			// 确认结算时, 与最近一次提前结算 (即使中间被回滚) 比较有效赔付是否变化
+			var early *SettlementLedgerEntry
+			if prev, ok := lastSettled[outcome.ID]; ok && stage == SettlementStageConfirmed && prev.Stage == SettlementStageEarly &&
+				(!sameFactor(&prev.WinFactor, &winFactor) || !sameFactor(&prev.RefundFactor, &refundFactor)) {
+				early = prev
+			}
+			var flippedFrom *int64
+			if early != nil {
+				flippedFrom = &early.ID
+			}
+
+			var entryID int64
+			var createdAt time.Time
+			if err := tx.QueryRow(`
+				INSERT INTO settlement_ledger (
+					event_id, producer_id, sr_market_id, specifiers, outcome_id, version, action,
+					result, void_factor, dead_heat_factor, win_factor, refund_factor, outcome,
+					stage, certainty, flipped_from_id, message_timestamp, created_at
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW())
+				RETURNING id, created_at
+			`,
+				settlement.EventID, settlement.ProductID, marketID, market.Specifiers, outcome.ID, version, action,
+				outcome.Result, voidFactor, outcome.DeadHeatFactor, winFactor, refundFactor, label,
+				stage, settlement.Certainty, flippedFrom, settlement.Timestamp,
+			).Scan(&entryID, &createdAt); err != nil {
+				return recorded, flips, fmt.Errorf("failed to insert settlement ledger entry: %w", err)
+			}
+			recorded++
+
+			if early != nil {
+				flips = append(flips, SettlementFlip{
+					EventID:            settlement.EventID,
+					ProducerID:         settlement.ProductID,
+					SRMarketID:         marketID,
+					Specifiers:         market.Specifiers,
+					OutcomeID:          outcome.ID,
+					EarlyEntryID:       early.ID,
+					EarlyProducerID:    early.ProducerID,
+					EarlyOutcome:       early.Outcome,
+					EarlyWinFactor:     early.WinFactor,
+					EarlyRefundFactor:  early.RefundFactor,
+					EarlyTimestamp:     early.MessageTimestamp,
+					ConfirmedEntryID:   entryID,
+					ConfirmedOutcome:   label,
+					ConfirmedWinFactor: winFactor,
+					ConfirmedRefund:    refundFactor,
+					ConfirmedTimestamp: settlement.Timestamp,
+					ConfirmedAt:        createdAt,
+				})
+			}
+		}
+	}
+
+	return recorded, flips, nil
+}
+
+// RecordRollback 在事务内为市场下所有已结算的结果写入回滚条目
+func (s *SettlementLedgerService) RecordRollback(tx *sql.Tx, eventID string, producerID int, timestamp int64, marketID, specifiers string) (int, error) {
+	if err := lockLedger(tx); err != nil {
+		return 0, err
+	}
+
+	latest, err := latestEntries(tx, eventID, marketID, specifiers)
+	if err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for outcomeID, prev := range latest {
+		if !prev.Settled() || timestamp < prev.MessageTimestamp {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO settlement_ledger (
+				event_id, producer_id, sr_market_id, specifiers, outcome_id, version, action,
+				win_factor, refund_factor, outcome, stage, message_timestamp, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, 0, 0, 'rolled_back', $8, $9, NOW())
+		`, eventID, producerID, marketID, specifiers, outcomeID, prev.Version+1, LedgerActionRollback,
+			SettlementStageRolledBack, timestamp); err != nil {
+			return recorded, fmt.Errorf("failed to insert settlement rollback entry: %w", err)
+		}
+		recorded++
+	}
+
+	return recorded, nil
+}
+
+// GetEntries 按 id 增量拉取账本条目 (id > sinceID), 供下游结算系统同步
+// 写入时持有 lockLedger, id 顺序与提交顺序一致, 以最后一条的 id 作为下次的 sinceID 不会漏掉条目
+func (s *SettlementLedgerService) GetEntries(sinceID int64, eventID string, limit int) ([]SettlementLedgerEntry, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 500
+	}
+
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM settlement_ledger
+		WHERE id > $1 AND ($2 = '' OR event_id = $2)
+		ORDER BY id
+		LIMIT $3
+	`
+	rows, err := s.db.Query(query, sinceID, eventID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement ledger: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLedgerEntries(rows)
+}
+
+// GetCurrent 获取赛事每个结果当前的有效结算 (每个结果的最新版本)
+func (s *SettlementLedgerService) GetCurrent(eventID string) ([]SettlementLedgerEntry, error) {
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM (
+			SELECT DISTINCT ON (sr_market_id, specifiers, outcome_id) *
+			FROM settlement_ledger
+			WHERE event_id = $1
+			ORDER BY sr_market_id, specifiers, outcome_id, version DESC
+		) current
+		ORDER BY sr_market_id, specifiers, outcome_id
+	`
+	rows, err := s.db.Query(query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query current settlements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLedgerEntries(rows)
+}
+
+// GetOutcome 获取单个结果当前的有效结算, 未结算时返回 nil
+func (s *SettlementLedgerService) GetOutcome(eventID, marketID, specifiers, outcomeID string) (*SettlementLedgerEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT `+ledgerColumns+`
+		FROM settlement_ledger
+		WHERE event_id = $1 AND sr_market_id = $2 AND specifiers = $3 AND outcome_id = $4
+		ORDER BY version DESC
+		LIMIT 1
+	`, eventID, marketID, specifiers, outcomeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanLedgerEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// SettlementFlipFilter 结果变化查询条件
+type SettlementFlipFilter struct {
+	EventID    string
+	ProducerID int // 提前结算的 producer
+	Since      *time.Time
+	Limit      int
+}
+
+// SettlementFlipSummary 按 producer 统计提前结算的可靠性
+type SettlementFlipSummary struct {
+	ProducerID       int     `json:"producer_id"`
+	EarlySettlements int     `json:"early_settlements"`
+	Confirmed        int     `json:"confirmed"`
+	Flipped          int     `json:"flipped"`
+	FlipRate         float64 `json:"flip_rate"`
+}
+
+// GetFlips 查询提前结算在确认时结果发生变化的记录
+func (s *SettlementLedgerService) GetFlips(filter SettlementFlipFilter) ([]SettlementFlip, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	rows, err := s.db.Query(`
+		SELECT c.event_id, COALESCE(c.producer_id, 0), c.sr_market_id, c.specifiers, c.outcome_id,
+		       e.id, COALESCE(e.producer_id, 0), COALESCE(e.outcome, ''), e.win_factor, e.refund_factor, e.message_timestamp,
+		       c.id, COALESCE(c.outcome, ''), c.win_factor, c.refund_factor, c.message_timestamp, c.created_at
+		FROM settlement_ledger c
+		JOIN settlement_ledger e ON e.id = c.flipped_from_id
+		WHERE ($1 = '' OR c.event_id = $1)
+		  AND ($2 = 0 OR e.producer_id = $2)
+		  AND ($3::TIMESTAMP IS NULL OR c.created_at >= $3::TIMESTAMP)
+		ORDER BY c.id DESC
+		LIMIT $4
+	`, filter.EventID, filter.ProducerID, filter.Since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement flips: %w", err)
+	}
+	defer rows.Close()
+
+	flips := make([]SettlementFlip, 0)
+	for rows.Next() {
+		var f SettlementFlip
+		if err := rows.Scan(
+			&f.EventID, &f.ProducerID, &f.SRMarketID, &f.Specifiers, &f.OutcomeID,
+			&f.EarlyEntryID, &f.EarlyProducerID, &f.EarlyOutcome, &f.EarlyWinFactor, &f.EarlyRefundFactor, &f.EarlyTimestamp,
+			&f.ConfirmedEntryID, &f.ConfirmedOutcome, &f.ConfirmedWinFactor, &f.ConfirmedRefund, &f.ConfirmedTimestamp, &f.ConfirmedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement flip: %w", err)
+		}
+		flips = append(flips, f)
+	}
+	return flips, rows.Err()
+}
+
+// GetFlipSummary 按提前结算的 producer 统计: 提前结算数、已确认数、确认时结果变化数
+func (s *SettlementLedgerService) GetFlipSummary(since *time.Time) ([]SettlementFlipSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(e.producer_id, 0),
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM settlement_ledger c
+		           WHERE c.event_id = e.event_id AND c.sr_market_id = e.sr_market_id
+		             AND c.specifiers = e.specifiers AND c.outcome_id = e.outcome_id
+		             AND c.version > e.version AND c.stage = $1
+		       )),
+		       COUNT(*) FILTER (WHERE EXISTS (
+		           SELECT 1 FROM settlement_ledger c WHERE c.flipped_from_id = e.id
+		       ))
+		FROM settlement_ledger e
+		WHERE e.stage = $2 AND ($3::TIMESTAMP IS NULL OR e.created_at >= $3::TIMESTAMP)
+		GROUP BY COALESCE(e.producer_id, 0)
+		ORDER BY 1
+	`, SettlementStageConfirmed, SettlementStageEarly, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlement flip summary: %w", err)
+	}
+	defer rows.Close()
+
+	summary := make([]SettlementFlipSummary, 0)
+	for rows.Next() {
+		var item SettlementFlipSummary
+		if err := rows.Scan(&item.ProducerID, &item.EarlySettlements, &item.Confirmed, &item.Flipped); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement flip summary: %w", err)
+		}
+		if item.Confirmed > 0 {
+			item.FlipRate = float64(item.Flipped) / float64(item.Confirmed)
+		}
+		summary = append(summary, item)
+	}
+	return summary, rows.Err()
+}
+
+const ledgerColumns = `id, event_id, COALESCE(producer_id, 0), sr_market_id, specifiers, outcome_id, version, action,
+		result, void_factor, dead_heat_factor, win_factor, refund_factor, COALESCE(outcome, ''),
+		COALESCE(stage, ''), certainty, flipped_from_id, message_timestamp, created_at`
+
+func scanLedgerEntries(rows *sql.Rows) ([]SettlementLedgerEntry, error) {
+	entries := make([]SettlementLedgerEntry, 0)
+	for rows.Next() {
+		var e SettlementLedgerEntry
+		var result, certainty, flippedFrom sql.NullInt64
+		var voidFactor, deadHeat sql.NullFloat64
+		if err := rows.Scan(
+			&e.ID, &e.EventID, &e.ProducerID, &e.SRMarketID, &e.Specifiers, &e.OutcomeID, &e.Version, &e.Action,
+			&result, &voidFactor, &deadHeat, &e.WinFactor, &e.RefundFactor, &e.Outcome,
+			&e.Stage, &certainty, &flippedFrom, &e.MessageTimestamp, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement ledger: %w", err)
+		}
+		e.Result = nullIntPtr(result)
+		e.Certainty = nullIntPtr(certainty)
+		e.VoidFactor = nullFloatPtr(voidFactor)
+		e.DeadHeatFactor = nullFloatPtr(deadHeat)
+		e.FlippedFromID = nullInt64Ptr(flippedFrom)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// sameSettlement 判断新结算与已有条目是否一致
+func sameSettlement(prev *SettlementLedgerEntry, result int, voidFactor, deadHeat *float64, certainty int) bool {
+	if prev.Result == nil || *prev.Result != result {
+		return false
+	}
+	if prev.Certainty == nil || *prev.Certainty != certainty {
+		return false
+	}
+	return sameFactor(prev.VoidFactor, voidFactor) && sameFactor(prev.DeadHeatFactor, deadHeat)
+}
+
+func sameFactor(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	diff := *a - *b
+	return diff < 1e-6 && diff > -1e-6
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+func nullFloatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"uof-service/database"
+	"uof-service/uof"
+)
+
+// TestLedgerCursorFollowsCommitOrder 两个结算事务交错提交时, 按 next_since_id 增量拉取不会漏掉条目
+// 需要 PostgreSQL: TEST_DATABASE_URL=postgres://... go test ./services/ -run Ledger
+func TestLedgerCursorFollowsCommitOrder(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := NewSettlementLedgerService(db)
+	suffix := time.Now().UnixNano()
+	first := fmt.Sprintf("sr:match:test%d1", suffix)
+	second := fmt.Sprintf("sr:match:test%d2", suffix)
+	defer db.Exec(`DELETE FROM settlement_ledger WHERE event_id IN ($1, $2)`, first, second)
+
+	settlement := func(eventID string) *uof.BetSettlement {
+		s := &uof.BetSettlement{Header: uof.Header{EventID: eventID, ProductID: 1, Timestamp: suffix / int64(time.Millisecond)}, Certainty: 2}
+		s.Outcomes.Markets = []uof.SettlementMarket{{ID: 1, Outcomes: []uof.SettlementOutcome{{ID: "1", Result: 1}, {ID: "2", Result: 0}}}}
+		return s
+	}
+
+	var cursor int64
+	if err := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM settlement_ledger`).Scan(&cursor); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]int)
+	poll := func() {
+		entries, err := ledger.GetEntries(cursor, "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			seen[e.EventID]++
+			cursor = e.ID
+		}
+	}
+
+	// 事务 1 先写入但暂不提交
+	tx1, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx1.Rollback()
+	if _, _, err := ledger.RecordSettlement(tx1, settlement(first)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 事务 2 写入另一赛事并立即提交
+	done := make(chan error, 1)
+	go func() {
+		tx2, err := db.Begin()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer tx2.Rollback()
+		if _, _, err := ledger.RecordSettlement(tx2, settlement(second)); err != nil {
+			done <- err
+			return
+		}
+		done <- tx2.Commit()
+	}()
+
+	// 事务 1 提交前拉取: 不能看到 (也不能跳过) 任何条目
+	select {
+	case err := <-done:
+		t.Fatalf("second transaction committed while the first held the ledger lock (err=%v)", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	poll()
+
+	if err := tx1.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	poll()
+	poll()
+
+	if seen[first] != 2 || seen[second] != 2 {
+		t.Errorf("entries seen by cursor = %v, want 2 per event", seen)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"uof-service/uof"
 )
 
 // SRNMappingService SRN ID 映射服务
@@ -79,8 +81,13 @@ func (s *SRNMappingService) GetSRNID(eventID string) (string, error) {
 // fetchSRNIDFromAPI 从 API 获取 SRN ID
 func (s *SRNMappingService) fetchSRNIDFromAPI(eventID string) (string, error) {
 	// UOF API endpoint for event mappings
-	url := fmt.Sprintf("%s/sports/en/sport_events/sr:match:%s/mappings.json?api_token=%s",
-		s.apiBaseURL, eventID, s.apiToken)
+	// eventID 通常是完整 URN (sr:match / sr:stage / sr:season ...), 纯数字时按 match 处理
+	eventURN := eventID
+	if _, err := uof.ParseURN(eventID); err != nil {
+		eventURN = "sr:match:" + eventID
+	}
+	url := fmt.Sprintf("%s/sports/en/sport_events/%s/mappings.json?api_token=%s",
+		s.apiBaseURL, eventURN, s.apiToken)
 
 	s.logger.Printf("Fetching SRN mapping for event: %s", eventID)
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 	"uof-service/logger"
 )
@@ -16,6 +17,11 @@ type StaticDataService struct {
 	apiBaseURL  string
 	accessToken string
 	client      *http.Client
+
+	localization *LocalizationService // 多语言名称服务 (可选)
+
+	voidReasons map[int]string // 作废原因缓存 (id -> 描述)
+	mu          sync.RWMutex
 }
 
 // NewStaticDataService 创建静态数据服务
@@ -25,13 +31,24 @@ func NewStaticDataService(db *sql.DB, accessToken, apiBaseURL string) *StaticDat
 		apiBaseURL:  apiBaseURL,
 		accessToken: accessToken,
 		client:      &http.Client{Timeout: 30 * time.Second},
+		voidReasons: make(map[int]string),
 	}
 }
 
+// SetLocalizationService 设置多语言名称服务 (可选, 设置后加载各语言的运动 / 分类 / 比赛状态名称)
+func (s *StaticDataService) SetLocalizationService(localization *LocalizationService) {
+	s.localization = localization
+}
+
 // Start 启动静态数据服务
 func (s *StaticDataService) Start() error {
 	logger.Println("[StaticData] Starting static data service...")
 
+	// 先从数据库加载缓存, API 不可用时仍可使用上次加载的数据
+	if err := s.loadVoidReasonsFromDatabase(); err != nil {
+		logger.Errorf("[StaticData] ⚠️  Failed to load cached void reasons: %v", err)
+	}
+
 	// 启动时立即加载一次
 	if err := s.LoadAllStaticData(); err != nil {
 		logger.Errorf("[StaticData] ❌ Failed to load static data: %v", err)
@@ -84,6 +101,11 @@ func (s *StaticDataService) LoadAllStaticData() error {
 		logger.Errorf("[StaticData] ⚠️  Failed to load betstop reasons: %v", err)
 	}
 
+	// 加载各语言的名称
+	if err := s.LoadLocalizedNames(); err != nil {
+		logger.Errorf("[StaticData] ⚠️  Failed to load localized names: %v", err)
+	}
+
 	logger.Println("[StaticData] ✅ All static data loaded")
 	return nil
 }
@@ -322,6 +344,7 @@ func (s *StaticDataService) LoadVoidReasons() error {
 	defer tx.Rollback()
 
 	count := 0
+	loaded := make(map[int]string, len(voidReasonsData.VoidReasons))
 	for _, reason := range voidReasonsData.VoidReasons {
 		_, err := tx.Exec(`
 			INSERT INTO void_reasons (id, description, updated_at)
@@ -335,6 +358,7 @@ func (s *StaticDataService) LoadVoidReasons() error {
 			logger.Errorf("[StaticData] ⚠️  Failed to insert void reason %d: %v", reason.ID, err)
 			continue
 		}
+		loaded[reason.ID] = reason.Description
 		count++
 	}
 
@@ -342,10 +366,55 @@ func (s *StaticDataService) LoadVoidReasons() error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	s.mu.Lock()
+	for id, description := range loaded {
+		s.voidReasons[id] = description
+	}
+	s.mu.Unlock()
+
 	logger.Printf("[StaticData] ✅ Loaded %d void reasons", count)
 	return nil
 }
 
+// loadVoidReasonsFromDatabase 从 void_reasons 表加载作废原因缓存
+func (s *StaticDataService) loadVoidReasonsFromDatabase() error {
+	rows, err := s.db.Query(`SELECT id, description FROM void_reasons`)
+	if err != nil {
+		return fmt.Errorf("failed to query void reasons: %w", err)
+	}
+	defer rows.Close()
+
+	loaded := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var description sql.NullString
+		if err := rows.Scan(&id, &description); err != nil {
+			return fmt.Errorf("failed to scan void reason: %w", err)
+		}
+		loaded[id] = description.String
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for id, description := range loaded {
+		s.voidReasons[id] = description
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// VoidReasonDescription 返回作废原因的描述 (来自缓存), 未知的原因返回空字符串
+func (s *StaticDataService) VoidReasonDescription(id int) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.voidReasons[id]
+}
+
 // LoadBetstopReasons 加载停止投注原因
 func (s *StaticDataService) LoadBetstopReasons() error {
 	url := fmt.Sprintf("%s/descriptions/betstop_reasons.xml", s.apiBaseURL)
@@ -426,3 +495,77 @@ func (s *StaticDataService) fetchAPI(url string) ([]byte, error) {
 	return body, nil
 }
 
+// LoadLocalizedNames 加载各配置语言的运动 / 分类 / 比赛状态名称到 LocalizationService
+// 英文也会加载, 作为其它语言缺少翻译时的回退
+func (s *StaticDataService) LoadLocalizedNames() error {
+	if s.localization == nil {
+		return nil
+	}
+
+	for _, lang := range s.localization.Languages() {
+		names := make(map[string]string)
+
+		// 运动和分类
+		body, err := s.fetchAPI(fmt.Sprintf("%s/sports/%s/sports.xml", s.apiBaseURL, lang))
+		if err != nil {
+			logger.Errorf("[StaticData] ⚠️  Failed to fetch %s sports: %v", lang, err)
+		} else {
+			var sportsData struct {
+				Sports []struct {
+					ID   string `xml:"id,attr"`
+					Name string `xml:"name,attr"`
+				} `xml:"sport"`
+			}
+			if err := xml.Unmarshal(body, &sportsData); err != nil {
+				logger.Errorf("[StaticData] ⚠️  Failed to parse %s sports XML: %v", lang, err)
+			}
+
+			for _, sport := range sportsData.Sports {
+				names[sport.ID] = sport.Name
+
+				body, err := s.fetchAPI(fmt.Sprintf("%s/sports/%s/sports/%s/categories.xml", s.apiBaseURL, lang, sport.ID))
+				if err != nil {
+					continue
+				}
+				var categoriesData struct {
+					Categories []struct {
+						ID   string `xml:"id,attr"`
+						Name string `xml:"name,attr"`
+					} `xml:"categories>category"`
+				}
+				if err := xml.Unmarshal(body, &categoriesData); err != nil {
+					continue
+				}
+				for _, category := range categoriesData.Categories {
+					names[category.ID] = category.Name
+				}
+			}
+		}
+
+		// 比赛状态
+		body, err = s.fetchAPI(fmt.Sprintf("%s/descriptions/%s/match_status.xml", s.apiBaseURL, lang))
+		if err != nil {
+			logger.Errorf("[StaticData] ⚠️  Failed to fetch %s match statuses: %v", lang, err)
+		} else {
+			var statusData struct {
+				MatchStatuses []struct {
+					ID          string `xml:"id,attr"`
+					Description string `xml:"description,attr"`
+				} `xml:"match_status"`
+			}
+			if err := xml.Unmarshal(body, &statusData); err != nil {
+				logger.Errorf("[StaticData] ⚠️  Failed to parse %s match statuses XML: %v", lang, err)
+			}
+			for _, status := range statusData.MatchStatuses {
+				names[matchStatusNameKey+status.ID] = status.Description
+			}
+		}
+
+		if err := s.localization.SaveNames(lang, names); err != nil {
+			return fmt.Errorf("failed to save %s names: %w", lang, err)
+		}
+		logger.Printf("[StaticData] ✅ Loaded %d %s names", len(names), lang)
+	}
+
+	return nil
+}
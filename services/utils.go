@@ -4,17 +4,20 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"uof-service/uof"
 )
 
 
 
-// ExtractEventIDFromURN 从 event URN (sr:match:123) 中提取数字 ID (123)
+// ExtractEventIDFromURN 从 event URN 中提取数字 ID
+// 支持所有赛事类型: sr:match:123, sr:stage:123, sr:season:123, sr:simple_tournament:123 等
 func ExtractEventIDFromURN(urn string) (int64, error) {
-	parts := strings.Split(urn, ":")
-	if len(parts) != 3 || parts[0] != "sr" || parts[1] != "match" {
+	parsed, err := uof.ParseURN(urn)
+	if err != nil {
 		return 0, fmt.Errorf("invalid event URN format: %s", urn)
 	}
-	return strconv.ParseInt(parts[2], 10, 64)
+	return parsed.ID, nil
 }
 
 
@@ -27,6 +30,15 @@ func ExtractMarketIDFromURN(urn string) (int64, error) {
 	return strconv.ParseInt(parts[2], 10, 64)
 }
 
+// NormalizeMarketID 统一市场 ID 格式
+// UOF 消息中的市场 ID 是纯数字 (如 "18"), 也兼容 "sr:market:18" 形式
+func NormalizeMarketID(id string) string {
+	if strings.HasPrefix(id, "sr:market:") {
+		return strings.TrimPrefix(id, "sr:market:")
+	}
+	return id
+}
+
 // CleanSQLQuery 清理 SQL 语句中的换行符和多余空格，使其成为单行
 func CleanSQLQuery(query string) string {
 	// 替换所有换行符和制表符为空格
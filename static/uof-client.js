@@ -43,7 +43,9 @@
       console.log('Connecting to', this.config.wsUrl);
 
       try {
-        this.ws = new WebSocket(this.config.wsUrl);
+        // 开启鉴权时通过子协议传递 API Key (浏览器无法设置请求头)
+        const protocols = this.config.apiKey ? ['uof.v1', 'apikey.' + this.config.apiKey] : undefined;
+        this.ws = new WebSocket(this.config.wsUrl, protocols);
 
         this.ws.onopen = () => {
           this.isConnected = true;
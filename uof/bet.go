@@ -0,0 +1,71 @@
+package uof
+
+import "encoding/xml"
+
+// BetStop bet_stop 消息
+type BetStop struct {
+	XMLName xml.Name `xml:"bet_stop"`
+	Header
+	MarketStatus *int   `xml:"market_status,attr"` // 可选, 默认 -1 (suspended)
+	Groups       string `xml:"groups,attr"`        // "all" 或 "1|2|3"
+}
+
+// BetSettlement bet_settlement 消息
+type BetSettlement struct {
+	XMLName xml.Name `xml:"bet_settlement"`
+	Header
+	Certainty int `xml:"certainty,attr"`
+	Outcomes  struct {
+		Markets []SettlementMarket `xml:"market"`
+	} `xml:"outcomes"`
+}
+
+// SettlementMarket 结算盘口
+type SettlementMarket struct {
+	ID         int                 `xml:"id,attr"`
+	Specifiers string              `xml:"specifiers,attr"`
+	VoidReason *int                `xml:"void_reason,attr"`
+	VoidFactor *float64            `xml:"void_factor,attr"` // 可选
+	Outcomes   []SettlementOutcome `xml:"outcome"`
+}
+
+// SettlementOutcome 结算结果
+type SettlementOutcome struct {
+	ID             string   `xml:"id,attr"`
+	Result         int      `xml:"result,attr"`           // 0=lose, 1=win
+	VoidFactor     *float64 `xml:"void_factor,attr"`      // 可选, outcome 级别
+	DeadHeatFactor *float64 `xml:"dead_heat_factor,attr"` // 可选
+}
+
+// BetCancel bet_cancel 消息
+type BetCancel struct {
+	XMLName xml.Name `xml:"bet_cancel"`
+	Header
+	StartTime    *int64         `xml:"start_time,attr"`
+	EndTime      *int64         `xml:"end_time,attr"`
+	SupercededBy *string        `xml:"superceded_by,attr"`
+	Markets      []CancelMarket `xml:"market"`
+}
+
+// CancelMarket bet_cancel / rollback 消息中的盘口
+type CancelMarket struct {
+	ID         int    `xml:"id,attr"`
+	Specifiers string `xml:"specifiers,attr"`
+	VoidReason *int   `xml:"void_reason,attr"`
+}
+
+// RollbackBetSettlement rollback_bet_settlement 消息
+type RollbackBetSettlement struct {
+	XMLName xml.Name `xml:"rollback_bet_settlement"`
+	Header
+	Markets []CancelMarket `xml:"market"`
+}
+
+// RollbackBetCancel rollback_bet_cancel 消息
+type RollbackBetCancel struct {
+	XMLName xml.Name `xml:"rollback_bet_cancel"`
+	Header
+	StartTime *int64         `xml:"start_time,attr"` // 与原 bet_cancel 相同, 用于匹配取消窗口
+	EndTime   *int64         `xml:"end_time,attr"`
+	Markets   []CancelMarket `xml:"market"`
+}
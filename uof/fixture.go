@@ -0,0 +1,60 @@
+package uof
+
+import "encoding/xml"
+
+// Fixture fixture 消息 (赛程信息)
+type Fixture struct {
+	XMLName xml.Name `xml:"fixture"`
+	Header
+	ScheduledTime    int64             `xml:"scheduled,attr"`
+	StartTime        int64             `xml:"start_time,attr"`
+	NextLiveTime     int64             `xml:"next_live_time,attr"`
+	Status           string            `xml:"status,attr"`
+	Sport            Sport             `xml:"sport"`
+	Tournament       Tournament        `xml:"tournament"`
+	Competitors      []Competitor      `xml:"competitors>competitor"`
+	SportEventStatus *SportEventStatus `xml:"sport_event_status"` // 部分 fixture 带有赛事状态
+}
+
+// FixtureChange fixture_change 消息
+type FixtureChange struct {
+	XMLName xml.Name `xml:"fixture_change"`
+	Header
+	StartTime    int64 `xml:"start_time,attr"`
+	NextLiveTime int64 `xml:"next_live_time,attr"`
+	ChangeType   int   `xml:"change_type,attr"`
+}
+
+// Alive alive 消息 (producer 心跳)
+type Alive struct {
+	XMLName xml.Name `xml:"alive"`
+	Header
+	Subscribed int `xml:"subscribed,attr"`
+}
+
+// SnapshotComplete snapshot_complete 消息 (恢复完成)
+type SnapshotComplete struct {
+	XMLName xml.Name `xml:"snapshot_complete"`
+	Header
+}
+
+// MarketDescriptionsChanged 盘口描述变化通知
+// 列出变化的盘口 (可以为空, 表示需要重新加载全部描述)
+type MarketDescriptionsChanged struct {
+	XMLName xml.Name `xml:"market_descriptions_changed"`
+	Header
+	Markets []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"market"`
+}
+
+// MarketIDs 返回通知中的盘口 ID
+func (m *MarketDescriptionsChanged) MarketIDs() []string {
+	ids := make([]string, 0, len(m.Markets))
+	for _, market := range m.Markets {
+		if market.ID != "" {
+			ids = append(ids, market.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,128 @@
+// Package uof 定义 Unified Odds Feed 消息的类型化结构
+// 消息在接收时解码一次 (Decode), 之后以 *Message 在 Broker 和各处理器之间传递
+package uof
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// 消息类型 (XML 根元素名称)
+const (
+	TypeOddsChange            = "odds_change"
+	TypeBetStop               = "bet_stop"
+	TypeBetSettlement         = "bet_settlement"
+	TypeBetCancel             = "bet_cancel"
+	TypeRollbackBetSettlement = "rollback_bet_settlement"
+	TypeRollbackBetCancel     = "rollback_bet_cancel"
+	TypeFixture               = "fixture"
+	TypeFixtureChange         = "fixture_change"
+	TypeAlive                 = "alive"
+	TypeSnapshotComplete      = "snapshot_complete"
+
+	TypeMarketDescriptionsChanged = "market_descriptions_changed"
+)
+
+// Header 所有消息共有的属性
+type Header struct {
+	EventID   string `xml:"event_id,attr"`
+	ProductID int    `xml:"product,attr"`
+	Timestamp int64  `xml:"timestamp,attr"`
+	RequestID int    `xml:"request_id,attr"` // 恢复 (recovery) 请求触发的消息才有
+	SportID   string `xml:"sport_id,attr"`
+}
+
+// Message 解码后的消息: 公共属性 + 对应类型的结构体 (只有与 Type 对应的字段非 nil)
+type Message struct {
+	Header
+	Type string
+	Raw  []byte // 原始 XML, 用于存储和透传
+
+	OddsChange            *OddsChange
+	BetStop               *BetStop
+	BetSettlement         *BetSettlement
+	BetCancel             *BetCancel
+	RollbackBetSettlement *RollbackBetSettlement
+	RollbackBetCancel     *RollbackBetCancel
+	Fixture               *Fixture
+	FixtureChange         *FixtureChange
+	Alive                 *Alive
+	SnapshotComplete      *SnapshotComplete
+
+	MarketDescriptionsChanged *MarketDescriptionsChanged
+}
+
+// Decode 解码一条 UOF 消息 (只解析一遍 XML)
+// 未知类型只解析公共属性; XML 格式错误时返回 error
+func Decode(data []byte) (*Message, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var start xml.StartElement
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("empty uof message")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read uof message: %w", err)
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			start = se
+			break
+		}
+	}
+
+	msg := &Message{Type: start.Name.Local, Raw: data}
+	var target interface{}
+	switch msg.Type {
+	case TypeOddsChange:
+		msg.OddsChange = &OddsChange{}
+		target = msg.OddsChange
+	case TypeBetStop:
+		msg.BetStop = &BetStop{}
+		target = msg.BetStop
+	case TypeBetSettlement:
+		msg.BetSettlement = &BetSettlement{}
+		target = msg.BetSettlement
+	case TypeBetCancel:
+		msg.BetCancel = &BetCancel{}
+		target = msg.BetCancel
+	case TypeRollbackBetSettlement:
+		msg.RollbackBetSettlement = &RollbackBetSettlement{}
+		target = msg.RollbackBetSettlement
+	case TypeRollbackBetCancel:
+		msg.RollbackBetCancel = &RollbackBetCancel{}
+		target = msg.RollbackBetCancel
+	case TypeFixture:
+		msg.Fixture = &Fixture{}
+		target = msg.Fixture
+	case TypeFixtureChange:
+		msg.FixtureChange = &FixtureChange{}
+		target = msg.FixtureChange
+	case TypeAlive:
+		msg.Alive = &Alive{}
+		target = msg.Alive
+	case TypeSnapshotComplete:
+		msg.SnapshotComplete = &SnapshotComplete{}
+		target = msg.SnapshotComplete
+	case TypeMarketDescriptionsChanged:
+		msg.MarketDescriptionsChanged = &MarketDescriptionsChanged{}
+		target = msg.MarketDescriptionsChanged
+	default:
+		target = &msg.Header
+	}
+
+	if err := decoder.DecodeElement(target, &start); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", msg.Type, err)
+	}
+	if h, ok := target.(interface{ header() Header }); ok {
+		msg.Header = h.header()
+	}
+	return msg, nil
+}
+
+// header 返回消息的公共属性 (各消息类型嵌入 Header 后自动实现)
+func (h Header) header() Header {
+	return h
+}
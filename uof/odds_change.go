@@ -0,0 +1,61 @@
+package uof
+
+import "encoding/xml"
+
+// 盘口状态 (market status)
+const (
+	MarketStatusActive     = 1
+	MarketStatusInactive   = 0
+	MarketStatusSuspended  = -1
+	MarketStatusHandedOver = -2
+	MarketStatusSettled    = -3
+	MarketStatusCancelled  = -4
+)
+
+// OddsChange odds_change 消息
+type OddsChange struct {
+	XMLName xml.Name `xml:"odds_change"`
+	Header
+	OddsChangeReason *int              `xml:"odds_change_reason,attr"`
+	SportEvent       SportEvent        `xml:"sport_event"`
+	SportEventStatus *SportEventStatus `xml:"sport_event_status"`
+	Odds             Odds              `xml:"odds"`
+}
+
+// Odds odds_change 中的赔率部分
+type Odds struct {
+	BetstopReason *int     `xml:"betstop_reason,attr"`
+	BettingStatus *int     `xml:"betting_status,attr"`
+	Markets       []Market `xml:"market"`
+}
+
+// Market odds_change 中的盘口
+type Market struct {
+	ID                 int       `xml:"id,attr"`
+	Specifiers         string    `xml:"specifiers,attr"`
+	ExtendedSpecifiers string    `xml:"extended_specifiers,attr"`
+	Status             int       `xml:"status,attr"` // 1=active, 0=inactive, -1=suspended, -2=handed over, -3=settled, -4=cancelled
+	Favourite          int       `xml:"favourite,attr"`
+	Outcomes           []Outcome `xml:"outcome"`
+}
+
+// Outcome odds_change 中的结果
+type Outcome struct {
+	ID            string   `xml:"id,attr"`
+	Odds          float64  `xml:"odds,attr"`
+	Probabilities *float64 `xml:"probabilities,attr"`
+	Active        int      `xml:"active,attr"`
+	Team          *int     `xml:"team,attr"`
+	RawOdds       float64  `xml:"-"` // 应用利润率前的原始赔率
+}
+
+// Clone 深拷贝盘口和结果, 修改赔率 (例如应用利润率) 时不影响其它处理器共享的消息
+func (o *OddsChange) Clone() *OddsChange {
+	clone := *o
+	clone.Odds.Markets = make([]Market, len(o.Odds.Markets))
+	for i, market := range o.Odds.Markets {
+		market.Outcomes = append([]Outcome(nil), market.Outcomes...)
+		clone.Odds.Markets[i] = market
+	}
+	return &clone
+}
@@ -0,0 +1,133 @@
+package uof
+
+// Competitor 参赛方
+type Competitor struct {
+	ID        string `xml:"id,attr"`
+	Name      string `xml:"name,attr"`
+	Qualifier string `xml:"qualifier,attr"` // home/away
+}
+
+// SportEvent 赛事基本信息 (odds_change 中的 sport_event)
+type SportEvent struct {
+	ID          string       `xml:"id,attr"`
+	Scheduled   int64        `xml:"scheduled,attr"`
+	StartTime   int64        `xml:"start_time,attr"`
+	Competitors []Competitor `xml:"competitors>competitor"`
+}
+
+// Sport 运动
+type Sport struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+}
+
+// Tournament 锦标赛
+type Tournament struct {
+	ID    string `xml:"id,attr"`
+	Name  string `xml:"name,attr"`
+	Sport Sport  `xml:"sport"` // Sports API 的 schedule / fixture 中带有所属运动
+}
+
+// SportEventStatus 赛事状态 (包含比分信息)
+// 是 odds_change 的直接子元素, 不是嵌套在 sport_event 下
+// 同样的结构也出现在 fixture 和 REST sport_event summary 中 (summary 的 status / match_status 为名称而不是数字)
+type SportEventStatus struct {
+	Status       string        `xml:"status,attr"`
+	MatchStatus  string        `xml:"match_status,attr"`
+	HomeScore    *int          `xml:"home_score,attr"`
+	AwayScore    *int          `xml:"away_score,attr"`
+	WinnerID     string        `xml:"winner_id,attr"`
+	Clock        *Clock        `xml:"clock"`
+	PeriodScores []PeriodScore `xml:"period_scores>period_score"`
+	Statistics   *Statistics   `xml:"statistics"`
+	SportSpecificStatus
+}
+
+// SportSpecificStatus 各运动特有的状态属性 (只有对应运动会出现)
+type SportSpecificStatus struct {
+	// 网球 / 排球 / 乒乓球 / 羽毛球: 当前局比分和发球方
+	HomeGameScore *int  `xml:"home_gamescore,attr" json:"home_gamescore,omitempty"`
+	AwayGameScore *int  `xml:"away_gamescore,attr" json:"away_gamescore,omitempty"`
+	CurrentServer *int  `xml:"current_server,attr" json:"current_server,omitempty"`
+	Tiebreak      *bool `xml:"tiebreak,attr" json:"tiebreak,omitempty"`
+	ExpediteMode  *bool `xml:"expedite_mode,attr" json:"expedite_mode,omitempty"`
+
+	// 飞镖
+	HomeLegScore *int `xml:"home_legscore,attr" json:"home_legscore,omitempty"`
+	AwayLegScore *int `xml:"away_legscore,attr" json:"away_legscore,omitempty"`
+	Throw        *int `xml:"throw,attr" json:"throw,omitempty"`
+	Visit        *int `xml:"visit,attr" json:"visit,omitempty"`
+
+	// 点球大战
+	HomePenaltyScore *int `xml:"home_penalty_score,attr" json:"home_penalty_score,omitempty"`
+	AwayPenaltyScore *int `xml:"away_penalty_score,attr" json:"away_penalty_score,omitempty"`
+
+	// 棒球
+	HomeBatter *int   `xml:"home_batter,attr" json:"home_batter,omitempty"`
+	AwayBatter *int   `xml:"away_batter,attr" json:"away_batter,omitempty"`
+	Outs       *int   `xml:"outs,attr" json:"outs,omitempty"`
+	Balls      *int   `xml:"balls,attr" json:"balls,omitempty"`
+	Strikes    *int   `xml:"strikes,attr" json:"strikes,omitempty"`
+	Bases      string `xml:"bases,attr" json:"bases,omitempty"`
+
+	// 板球
+	Innings        *int `xml:"innings,attr" json:"innings,omitempty"`
+	Over           *int `xml:"over,attr" json:"over,omitempty"`
+	Delivery       *int `xml:"delivery,attr" json:"delivery,omitempty"`
+	HomeDismissals *int `xml:"home_dismissals,attr" json:"home_dismissals,omitempty"`
+	AwayDismissals *int `xml:"away_dismissals,attr" json:"away_dismissals,omitempty"`
+
+	// 美式足球 / 橄榄球
+	Possession *int `xml:"possession,attr" json:"possession,omitempty"`
+	Position   *int `xml:"position,attr" json:"position,omitempty"`
+	Try        *int `xml:"try,attr" json:"try,omitempty"`
+	Yards      *int `xml:"yards,attr" json:"yards,omitempty"`
+
+	// 手球 / 冰球: 罚下人数
+	HomeSuspend *int `xml:"home_suspend,attr" json:"home_suspend,omitempty"`
+	AwaySuspend *int `xml:"away_suspend,attr" json:"away_suspend,omitempty"`
+
+	// 冰壶 / 草地滚球
+	CurrentEnd         *int `xml:"current_end,attr" json:"current_end,omitempty"`
+	HomeRemainingBowls *int `xml:"home_remaining_bowls,attr" json:"home_remaining_bowls,omitempty"`
+	AwayRemainingBowls *int `xml:"away_remaining_bowls,attr" json:"away_remaining_bowls,omitempty"`
+
+	// 斯诺克
+	RemainingReds *int `xml:"remaining_reds,attr" json:"remaining_reds,omitempty"`
+
+	// 电竞 (CS:GO)
+	CurrentCtTeam *int `xml:"current_ct_team,attr" json:"current_ct_team,omitempty"`
+}
+
+// Clock 比赛时钟
+type Clock struct {
+	MatchTime             string `xml:"match_time,attr" json:"match_time,omitempty"`
+	StoppageTime          string `xml:"stoppage_time,attr" json:"stoppage_time,omitempty"`
+	StoppageTimeAnnounced string `xml:"stoppage_time_announced,attr" json:"stoppage_time_announced,omitempty"`
+	RemainingTime         string `xml:"remaining_time,attr" json:"remaining_time,omitempty"`
+	RemainingTimeInPeriod string `xml:"remaining_time_in_period,attr" json:"remaining_time_in_period,omitempty"`
+	Stopped               bool   `xml:"stopped,attr" json:"stopped"`
+}
+
+// PeriodScore 分段比分 (足球半场 / 网球盘 / 篮球节 / 棒球局 等)
+type PeriodScore struct {
+	HomeScore       int    `xml:"home_score,attr" json:"home_score"`
+	AwayScore       int    `xml:"away_score,attr" json:"away_score"`
+	Type            string `xml:"type,attr" json:"type,omitempty"` // regular_period, overtime, penalties
+	Number          int    `xml:"number,attr" json:"number"`
+	MatchStatusCode int    `xml:"match_status_code,attr" json:"match_status_code,omitempty"`
+}
+
+// Statistics 比赛统计
+type Statistics struct {
+	YellowCards    *TeamStats `xml:"yellow_cards" json:"yellow_cards,omitempty"`
+	RedCards       *TeamStats `xml:"red_cards" json:"red_cards,omitempty"`
+	YellowRedCards *TeamStats `xml:"yellow_red_cards" json:"yellow_red_cards,omitempty"`
+	Corners        *TeamStats `xml:"corners" json:"corners,omitempty"`
+}
+
+// TeamStats 双方统计数据
+type TeamStats struct {
+	Home int `xml:"home,attr" json:"home"`
+	Away int `xml:"away,attr" json:"away"`
+}
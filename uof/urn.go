@@ -0,0 +1,61 @@
+package uof
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 赛事 URN 类型 (sr:<type>:<id> 中的 type)
+// 除 match 外都是非对阵赛事 (冠军 / 赛季 / 阶段等), 盘口的结果是参赛方列表而不是主客队
+const (
+	EventTypeMatch            = "match"
+	EventTypeStage            = "stage"
+	EventTypeSeason           = "season"
+	EventTypeTournament       = "tournament"
+	EventTypeSimpleTournament = "simple_tournament"
+)
+
+// URN Sportradar 资源标识 (例如 sr:match:123, sr:season:456, sr:competitor:789)
+type URN struct {
+	Prefix string // sr, 或自定义赛事的 wns / vf 等
+	Type   string
+	ID     int64
+}
+
+// ParseURN 解析 prefix:type:id 格式的 URN, id 必须是数字
+func ParseURN(s string) (URN, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return URN{}, fmt.Errorf("invalid URN format: %s", s)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return URN{}, fmt.Errorf("invalid URN id: %s", s)
+	}
+	return URN{Prefix: parts[0], Type: parts[1], ID: id}, nil
+}
+
+// String 返回 URN 字符串
+func (u URN) String() string {
+	return fmt.Sprintf("%s:%s:%d", u.Prefix, u.Type, u.ID)
+}
+
+// IsMatch 是否为两队对阵的比赛
+func (u URN) IsMatch() bool {
+	return u.Type == EventTypeMatch
+}
+
+// IsOutright 是否为非对阵赛事 (outright / stage / season / tournament)
+func (u URN) IsOutright() bool {
+	return !u.IsMatch()
+}
+
+// EventType 返回赛事 URN 的类型; 无法解析时返回空字符串
+func EventType(eventID string) string {
+	urn, err := ParseURN(eventID)
+	if err != nil {
+		return ""
+	}
+	return urn.Type
+}
@@ -0,0 +1,243 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+const (
+	auditMaxBodyBytes   = 16 * 1024 // 记录的请求体上限
+	auditMaxResultBytes = 2 * 1024  // 记录的响应体上限
+)
+
+// auditRecorder 捕获响应状态码和响应体
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *auditRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *auditRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if remaining := auditMaxResultBytes - r.body.Len(); remaining > 0 {
+		if len(b) > remaining {
+			r.body.Write(b[:remaining])
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// auditSkipRoutes 使用 POST 但不修改状态的路由, 不记录审计日志
+var auditSkipRoutes = map[string]bool{
+	"/api/bets/validate": true,
+}
+
+// auditIdentity 审计中间件放入请求上下文, 由 authMiddleware 填入识别出的 API Key
+// 被拒绝 (403) 的请求也能记录调用方; 401 时为空
+type auditIdentity struct {
+	key *services.APIKey
+}
+
+const auditIdentityContextKey contextKey = "audit_identity"
+
+// setAuditKey 记录鉴权识别出的 API Key (请求不经过审计中间件时忽略)
+func setAuditKey(r *http.Request, key *services.APIKey) {
+	if identity, ok := r.Context().Value(auditIdentityContextKey).(*auditIdentity); ok {
+		identity.key = key
+	}
+}
+
+// auditMiddleware 记录所有修改状态的请求 (非 GET) 到 audit_log
+// 需在 authMiddleware 之前执行, 鉴权失败 (401 / 403) 的写请求同样记录
+func (s *Server) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if t, err := current.GetPathTemplate(); err == nil {
+				route = t
+			}
+		}
+		if auditSkipRoutes[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+
+		// 读取请求体并放回, 供后续 handler 使用
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(r.Body, auditMaxBodyBytes+1))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		recorder := &auditRecorder{ResponseWriter: w}
+		identity := &auditIdentity{}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), auditIdentityContextKey, identity)))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		entry := &services.AuditEntry{
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Route:      route,
+			Params:     auditParams(r, body),
+			StatusCode: recorder.status,
+			Success:    recorder.status < 400,
+			Result:     strings.TrimSpace(recorder.body.String()),
+			DurationMs: time.Since(start).Milliseconds(),
+			RemoteAddr: clientIP(r),
+		}
+		if key := identity.key; key != nil {
+			id := key.ID
+			entry.APIKeyID = &id
+			entry.APIKeyName = key.Name
+			entry.Role = string(key.Role)
+		}
+
+		// 异步写入, 不影响请求耗时
+		go s.auditService.Record(entry)
+	})
+}
+
+// auditParams 汇总路径参数、查询参数和请求体, 敏感字段脱敏
+func auditParams(r *http.Request, body []byte) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	if vars := mux.Vars(r); len(vars) > 0 {
+		params["path"] = vars
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		q := make(map[string]interface{}, len(query))
+		for k, v := range query {
+			if isSensitiveParam(k) {
+				q[k] = "***"
+			} else if len(v) == 1 {
+				q[k] = v[0]
+			} else {
+				q[k] = v
+			}
+		}
+		params["query"] = q
+	}
+
+	if len(body) > 0 {
+		if len(body) > auditMaxBodyBytes {
+			params["body"] = string(body[:auditMaxBodyBytes]) + "...(truncated)"
+		} else {
+			var parsed map[string]interface{}
+			if err := json.Unmarshal(body, &parsed); err == nil {
+				for k := range parsed {
+					if isSensitiveParam(k) {
+						parsed[k] = "***"
+					}
+				}
+				params["body"] = parsed
+			} else {
+				params["body"] = string(body)
+			}
+		}
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "password") || strings.Contains(name, "token") ||
+		strings.Contains(name, "secret") || name == "api_key"
+}
+
+// clientIP 获取客户端 IP (优先使用代理头)
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+	return r.RemoteAddr
+}
+
+// handleGetAuditLog 查询审计日志
+// GET /api/audit?api_key_id=1&route=/api/database/reset&method=POST&success=false&since=2024-01-01T00:00:00Z&limit=100&offset=0
+func (s *Server) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := services.AuditFilter{
+		Route:  q.Get("route"),
+		Method: q.Get("method"),
+	}
+	if v := q.Get("api_key_id"); v != "" {
+		if id, err := strconv.Atoi(v); err == nil {
+			filter.APIKeyID = &id
+		}
+	}
+	if v := q.Get("success"); v != "" {
+		success := v == "true"
+		filter.Success = &success
+	}
+	if v := q.Get("since"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			filter.Since = &t
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			filter.Until = &t
+		}
+	}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(q.Get("offset"))
+
+	entries, total, err := s.auditService.Query(filter)
+	if err != nil {
+		log.Printf("[API] Failed to query audit log: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"total":   total,
+		"count":   len(entries),
+		"entries": entries,
+	})
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"uof-service/services"
+)
+
+type contextKey string
+
+const apiKeyContextKey contextKey = "api_key"
+
+// WebSocket 子协议: 浏览器无法设置请求头, 通过 new WebSocket(url, ["uof.v1", "apikey.<key>"]) 传递 API Key
+// 服务端只回应 uof.v1, 不回显 key
+const (
+	wsProtocol       = "uof.v1"
+	wsAPIKeyProtocol = "apikey."
+)
+
+// publicRoutes 无需鉴权的路由
+var publicRoutes = map[string]bool{
+	"/api/health": true,
+}
+
+// routeRoles 特定路由的角色要求 (未列出的 GET 路由需要 read, 其他方法默认需要 admin)
+var routeRoles = map[string]services.APIKeyRole{
+	"/api/audit":                        services.RoleAdmin,
+	"/api/recovery/event/{event_id}":    services.RoleTrader,
+	"/api/recovery/stateful/{event_id}": services.RoleTrader,
+	"/api/booking/match/{match_id}":     services.RoleTrader,
+	"/api/bets/validate":                services.RoleRead, // 只读校验, 不修改状态
+	"/api/odds-alerts":                  services.RoleTrader,
+	"/api/margins/anomalies":            services.RoleTrader, // 返回原始赔率
+	"/api/margins/{event_id}":           services.RoleTrader,
+	"/api/margins/{event_id}/history":   services.RoleTrader,
+}
+
+// requiredRole 返回请求所需的最低角色, 空字符串表示公开路由
+func requiredRole(r *http.Request) services.APIKeyRole {
+	template := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			template = t
+		}
+	}
+
+	if publicRoutes[template] {
+		return ""
+	}
+	if role, ok := routeRoles[template]; ok {
+		return role
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return services.RoleRead
+	}
+	return services.RoleAdmin
+}
+
+// extractAPIKey 从请求中提取 API Key
+// 支持 X-API-Key 头和 Authorization: Bearer; allowProtocol 时也接受 Sec-WebSocket-Protocol 中的 apikey.<key>
+// 不接受查询参数, 避免 key 出现在访问日志和代理日志中
+func extractAPIKey(r *http.Request, allowProtocol bool) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if allowProtocol {
+		for _, protocol := range websocket.Subprotocols(r) {
+			if strings.HasPrefix(protocol, wsAPIKeyProtocol) {
+				return strings.TrimPrefix(protocol, wsAPIKeyProtocol)
+			}
+		}
+	}
+	return ""
+}
+
+// APIKeyFromContext 获取当前请求的 API Key (鉴权关闭或公开路由时为 nil)
+func APIKeyFromContext(ctx context.Context) *services.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*services.APIKey)
+	return key
+}
+
+// authMiddleware API Key 鉴权中间件
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.APIAuthEnabled || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		role := requiredRole(r)
+		if role == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := s.apiKeyService.Authenticate(extractAPIKey(r, false))
+		if key == nil {
+			writeAuthError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		setAuditKey(r, key)
+		if !key.Role.Allows(role) {
+			writeAuthError(w, http.StatusForbidden, "API key role '"+string(key.Role)+"' is not allowed, requires '"+string(role)+"'")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+	})
+}
+
+// checkOrigin 检查 WebSocket 请求来源是否在允许列表中
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(s.config.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range s.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetBetCancelWindows 获取赛事的所有取消窗口
+// GET /api/bet-cancels/{event_id}
+func (s *Server) handleGetBetCancelWindows(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	w.Header().Set("Content-Type", "application/json")
+
+	windows, err := s.betCancelService.GetWindows(eventID)
+	if err != nil {
+		log.Printf("[API] Failed to query bet cancel windows for %s: %v", eventID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"event_id": eventID,
+		"count":    len(windows),
+		"windows":  windows,
+	})
+}
+
+// handleCheckBetVoided 判断在某个时间投注的结果是否被作废
+// GET /api/bet-cancels/{event_id}/check?market_id=18&specifiers=total=2.5&outcome_id=12&placed_at=1700000000000
+// placed_at 支持毫秒时间戳或 RFC3339
+func (s *Server) handleCheckBetVoided(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	marketID := q.Get("market_id")
+	placedAt, ok := parsePlacedAt(q.Get("placed_at"))
+	if marketID == "" || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "market_id and placed_at (milliseconds or RFC3339) are required",
+		})
+		return
+	}
+
+	status, err := s.betCancelService.CheckVoided(eventID, marketID, q.Get("specifiers"), q.Get("outcome_id"), placedAt)
+	if err != nil {
+		log.Printf("[API] Failed to check bet void status for %s: %v", eventID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"result":  status,
+	})
+}
+
+// parsePlacedAt 解析投注时间 (毫秒时间戳或 RFC3339)
+func parsePlacedAt(value string) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ms, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"uof-service/services"
+)
+
+// maxBetSlipSelections 单次校验的最大选项数
+const maxBetSlipSelections = 50
+
+// validateBetsRequest 投注校验请求
+type validateBetsRequest struct {
+	Selections       []services.BetSelection `json:"selections"`
+	AcceptHigherOdds *bool                   `json:"accept_higher_odds"` // 默认 true
+}
+
+// handleValidateBets 校验投注单中的选项是否可以接受
+// POST /api/bets/validate
+func (s *Server) handleValidateBets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req validateBetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if len(req.Selections) == 0 || len(req.Selections) > maxBetSlipSelections {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "selections must contain between 1 and 50 items",
+		})
+		return
+	}
+
+	acceptHigherOdds := true
+	if req.AcceptHigherOdds != nil {
+		acceptHigherOdds = *req.AcceptHigherOdds
+	}
+
+	result, err := s.betValidationService.ValidateSlip(req.Selections, acceptHigherOdds)
+	if err != nil {
+		log.Printf("[API] Failed to validate bets: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"accepted":   result.Accepted,
+		"tolerance":  result.Tolerance,
+		"selections": result.Selections,
+	})
+}
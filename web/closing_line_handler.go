@@ -0,0 +1,65 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+// handleGetClosingLines 获取赛事的收盘赔率
+// GET /api/closing-lines/{event_id}?market_id=18&specifiers=total=2.5&lang=zh
+func (s *Server) handleGetClosingLines(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	lines, err := s.closingLineService.GetClosingLines(eventID, q.Get("market_id"), q.Get("specifiers"))
+	if err != nil {
+		log.Printf("[API] Failed to query closing lines for %s: %v", eventID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !s.canViewRawOdds(r) {
+		hideRawClosingOdds(lines)
+	}
+
+	// 结果名称按 lang 参数翻译
+	if lang := s.requestLanguage(r); lang != "" && len(lines) > 0 {
+		ctx := s.eventMarketContext(eventID, lang)
+		for i := range lines {
+			lines[i].OutcomeName = s.localizedOutcomeName(lines[i].SRMarketID, lines[i].OutcomeID, lines[i].OutcomeName, lines[i].Specifiers, ctx)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"event_id":      eventID,
+		"count":         len(lines),
+		"closing_lines": lines,
+	})
+}
+
+// canViewRawOdds 调用方是否可以查看应用利润率前的原始赔率 (鉴权关闭或 trader / admin key)
+func (s *Server) canViewRawOdds(r *http.Request) bool {
+	if !s.config.APIAuthEnabled {
+		return true
+	}
+	key := APIKeyFromContext(r.Context())
+	return key != nil && key.Role.Allows(services.RoleTrader)
+}
+
+// hideRawClosingOdds 清除收盘赔率中的原始赔率
+func hideRawClosingOdds(lines []services.ClosingLine) {
+	for i := range lines {
+		lines[i].RawOddsValue = 0
+	}
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+// handleGetCompetitor 获取参赛方资料 (简称 / 国家 / 主场 / 球衣 / 主教练 / 阵容)
+// GET /api/competitors/{competitor_id}?refresh=true&lang=zh
+func (s *Server) handleGetCompetitor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.competitorService == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "competitor service not available",
+		})
+		return
+	}
+
+	competitorID, err := services.ParseCompetitorID(mux.Vars(r)["competitor_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 同步请求 Sports API 只对 trader / admin 开放; read key 只读缓存, 缺失或过期时加入后台刷新队列 (受重试间隔限流)
+	refresh := r.URL.Query().Get("refresh") == "true"
+	refreshQueued := false
+	var profile *services.CompetitorProfile
+	if s.canForceRefresh(r) {
+		profile, err = s.competitorService.GetProfile(competitorID, refresh)
+	} else {
+		profile, refreshQueued, err = s.competitorService.CachedProfile(competitorID, refresh)
+	}
+	if err != nil {
+		log.Printf("[API] Failed to get competitor %s: %v", competitorID, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if profile == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":        false,
+			"error":          "competitor not found",
+			"refresh_queued": refreshQueued,
+		})
+		return
+	}
+
+	// 名称按 lang 参数翻译
+	if lang := s.requestLanguage(r); lang != "" {
+		profile.Name = s.localization.Localize(profile.ID, lang, profile.Name)
+		for i := range profile.Players {
+			profile.Players[i].Name = s.localization.Localize(profile.Players[i].ID, lang, profile.Players[i].Name)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"refresh_queued": refreshQueued,
+		"competitor":     profile,
+	})
+}
+
+// canForceRefresh 调用方是否可以同步强制刷新外部资料 (鉴权关闭或 trader / admin key)
+func (s *Server) canForceRefresh(r *http.Request) bool {
+	if !s.config.APIAuthEnabled {
+		return true
+	}
+	key := APIKeyFromContext(r.Context())
+	return key != nil && key.Role.Allows(services.RoleTrader)
+}
+
+// competitorSummary 返回参赛方的简称和国家代码 (teamID 为空或资料未加载时为空)
+func (s *Server) competitorSummary(teamID *string) services.CompetitorSummary {
+	if teamID == nil {
+		return services.CompetitorSummary{}
+	}
+	summary, _ := s.competitorService.Summary(*teamID)
+	return summary
+}
+
+// enrichCompetitors 为参赛方列表填充简称和国家代码
+func (s *Server) enrichCompetitors(competitors []services.EventCompetitor) {
+	for i := range competitors {
+		summary := s.competitorSummary(&competitors[i].ID)
+		competitors[i].Abbreviation = summary.Abbreviation
+		competitors[i].CountryCode = summary.CountryCode
+	}
+}
+
+// enrichEvent 为赛事填充主客队和参赛方的简称 / 国家代码
+func (s *Server) enrichEvent(event *EnhancedEvent) {
+	home := s.competitorSummary(event.HomeTeamID)
+	away := s.competitorSummary(event.AwayTeamID)
+	event.HomeTeamAbbreviation, event.HomeTeamCountryCode = home.Abbreviation, home.CountryCode
+	event.AwayTeamAbbreviation, event.AwayTeamCountryCode = away.Abbreviation, away.CountryCode
+	s.enrichCompetitors(event.Competitors)
+}
+
+// enrichMatch 同 enrichEvent, 用于前端比赛接口
+func (s *Server) enrichMatch(match *EnhancedMatchDetail) {
+	home := s.competitorSummary(match.HomeTeamID)
+	away := s.competitorSummary(match.AwayTeamID)
+	match.HomeTeamAbbreviation, match.HomeTeamCountryCode = home.Abbreviation, home.CountryCode
+	match.AwayTeamAbbreviation, match.AwayTeamCountryCode = away.Abbreviation, away.CountryCode
+	s.enrichCompetitors(match.Competitors)
+}
+
+// enrichMatches 批量填充比赛列表的参赛方资料
+func (s *Server) enrichMatches(matches []EnhancedMatchDetail) {
+	for i := range matches {
+		s.enrichMatch(&matches[i])
+	}
+}
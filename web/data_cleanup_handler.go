@@ -16,6 +16,9 @@ func (s *Server) handleGetTableStats(w http.ResponseWriter, r *http.Request) {
 		RetainDaysBets:     s.config.CleanupRetainDaysBets,
 		RetainDaysLiveData: s.config.CleanupRetainDaysLiveData,
 		RetainDaysEvents:   s.config.CleanupRetainDaysEvents,
+		RetainDaysAudit:    s.config.CleanupRetainDaysAudit,
+		RetainDaysCandles:  s.config.CleanupRetainDaysCandles,
+		RetainDaysClosingLines: s.config.CleanupRetainDaysClosingLines,
 	}
 	dataCleanup := services.NewDataCleanupService(s.db, cleanupConfig)
 	
@@ -76,6 +79,9 @@ func (s *Server) handleManualCleanup(w http.ResponseWriter, r *http.Request) {
 		RetainDaysBets:     s.config.CleanupRetainDaysBets,
 		RetainDaysLiveData: s.config.CleanupRetainDaysLiveData,
 		RetainDaysEvents:   s.config.CleanupRetainDaysEvents,
+		RetainDaysAudit:    s.config.CleanupRetainDaysAudit,
+		RetainDaysCandles:  s.config.CleanupRetainDaysCandles,
+		RetainDaysClosingLines: s.config.CleanupRetainDaysClosingLines,
 	}
 	dataCleanup := services.NewDataCleanupService(s.db, cleanupConfig)
 	
@@ -21,14 +21,22 @@ func (s *Server) handleResetDatabase(w http.ResponseWriter, r *http.Request) {
 	tables := []string{
 		"odds",                  // 赔率数据（依赖 markets）
 		"odds_history",          // 赔率历史数据
+		"odds_candles",          // 赔率 K 线
+		"closing_lines",         // 收盘赔率
+		"market_margin_history", // 盘口返还率历史
+		"odds_alerts",           // 赔率异动告警
 		"markets",               // 盘口数据（依赖 odds_changes）
 		"bet_settlements",       // 结算数据
+		// settlement_ledger 为只追加账本, 下游按 since_id 对账, 不随重置清空
+		"bet_cancel_windows",    // 投注取消窗口
 		"bet_stops",             // 停止投注数据
 		"odds_changes",          // 赔率变化数据
 		"ld_lineups",            // 阵容数据
 		"ld_events",             // Live Data 事件
 		"ld_matches",            // Live Data 比赛
 		"uof_messages",          // 原始消息
+		"match_timeline",        // 比赛时间线
+		"event_status",          // 完整赛事状态
 		"tracked_events",        // 跟踪的赛事
 		"producer_status",       // Producer 状态
 		"recovery_status",       // Recovery 状态
@@ -59,9 +67,15 @@ func (s *Server) handleResetDatabase(w http.ResponseWriter, r *http.Request) {
 		"odds_changes_id_seq",
 		"bet_stops_id_seq",
 		"bet_settlements_id_seq",
+		"bet_cancel_windows_id_seq",
 		"markets_id_seq",
 		"odds_id_seq",
 		"odds_history_id_seq",
+		"closing_lines_id_seq",
+		"market_margin_history_id_seq",
+		"odds_candles_id_seq",
+		"match_timeline_id_seq",
+		// odds_alerts_id_seq 不重置: 告警流客户端以 id 作为 since_id 游标续传, 重置后会漏收新告警
 		"ld_events_id_seq",
 		"ld_matches_id_seq",
 		"ld_lineups_id_seq",
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	
 	"uof-service/services"
+	"uof-service/uof"
 )
 
 // EnhancedEvent 增强的赛事信息
@@ -19,12 +20,14 @@ type EnhancedEvent struct {
 	SportID        string  `json:"sport_id"`
 	Status         string  `json:"status"`
 	ScheduleTime   *string `json:"schedule_time"`
+	EventType      string  `json:"event_type"` // match / stage / season / tournament / simple_tournament
 	
-	// 球队信息
+	// 球队信息 (非对阵赛事只有 competitors)
 	HomeTeamID     *string `json:"home_team_id"`
 	HomeTeamName   *string `json:"home_team_name"`
 	AwayTeamID     *string `json:"away_team_id"`
 	AwayTeamName   *string `json:"away_team_name"`
+	Competitors    []services.EventCompetitor `json:"competitors,omitempty"`
 	
 	// 比分和状态
 	HomeScore      *int    `json:"home_score"`
@@ -50,6 +53,12 @@ type EnhancedEvent struct {
 	IsLive             bool   `json:"is_live"`
 	IsEnded            bool   `json:"is_ended"`
 	
+	// 参赛方资料 (简称 / 国家代码, 资料未加载时为空)
+	HomeTeamAbbreviation string `json:"home_team_abbreviation,omitempty"`
+	HomeTeamCountryCode  string `json:"home_team_country_code,omitempty"`
+	AwayTeamAbbreviation string `json:"away_team_abbreviation,omitempty"`
+	AwayTeamCountryCode  string `json:"away_team_country_code,omitempty"`
+	
 	// 盘口信息
 	Markets []MarketInfo `json:"markets"`
 }
@@ -61,6 +70,8 @@ type MarketInfo struct {
 	Specifiers     string        `json:"specifiers,omitempty"`
 	Status         string        `json:"status"`
 	ProducerID     int           `json:"producer_id"`
+	IsMainLine     bool          `json:"is_main_line"` // 让球 / 大小球盘口族中最接近平手盘的线
+	Groups         []string      `json:"groups"`       // 盘口组, 例如 ["all", "score", "regular_play"]
 	Outcomes       []OutcomeInfo `json:"outcomes"`
 	OutcomesCount  int           `json:"outcomes_count"`
 	UpdatedAt      string        `json:"updated_at"`
@@ -74,6 +85,7 @@ type OutcomeInfo struct {
 	Odds        float64 `json:"odds"`
 	Probability float64 `json:"probability"`
 	Active      bool    `json:"active"`
+	Formatted   *services.FormattedOdds `json:"formatted,omitempty"` // 请求 odds_format 时返回
 }
 
 // handleGetEnhancedEvents 获取增强的赛事信息
@@ -89,6 +101,22 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 	isLive := r.URL.Query().Get("is_live")
 	isEnded := r.URL.Query().Get("is_ended")
 	hasMarkets := r.URL.Query().Get("has_markets")
+	mainLinesOnly := r.URL.Query().Get("main_lines_only") == "true"
+	marketGroup := r.URL.Query().Get("market_group")
+	lang := s.requestLanguage(r)
+	
+	// event_type=match 只返回对阵比赛, event_type=outright 只返回冠军 / 赛季 / 阶段等非对阵赛事
+	eventTypeCondition, err := services.EventTypeCondition("te.event_type", r.URL.Query().Get("event_type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	
 	page := 1
 	pageSize := 100
@@ -132,10 +160,15 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 				args = append(args, sportID)
 			}
 		
-		// 添加 search 过滤 (event_id 精确匹配或队伍名称模糊匹配)
+		// 添加 event_type 过滤
+		if eventTypeCondition != "" {
+			whereClauses = append(whereClauses, eventTypeCondition)
+		}
+		
+		// 添加 search 过滤 (event_id 精确匹配或队伍 / 参赛方名称模糊匹配, 参赛方只匹配 name 字段而不是整个 JSON)
 		if search != "" {
 			searchPattern := "%" + search + "%"
-			whereClauses = append(whereClauses, "(te.event_id = $"+fmt.Sprintf("%d", len(args)+1)+" OR te.home_team_name ILIKE $"+fmt.Sprintf("%d", len(args)+2)+" OR te.away_team_name ILIKE $"+fmt.Sprintf("%d", len(args)+3)+")")
+			whereClauses = append(whereClauses, "(te.event_id = $"+fmt.Sprintf("%d", len(args)+1)+" OR te.home_team_name ILIKE $"+fmt.Sprintf("%d", len(args)+2)+" OR te.away_team_name ILIKE $"+fmt.Sprintf("%d", len(args)+3)+" OR EXISTS (SELECT 1 FROM jsonb_array_elements(CASE WHEN jsonb_typeof(te.competitors) = 'array' THEN te.competitors ELSE '[]'::jsonb END) c WHERE c->>'name' ILIKE $"+fmt.Sprintf("%d", len(args)+3)+"))")
 			args = append(args, search, searchPattern, searchPattern)
 		}
 		
@@ -170,6 +203,12 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 		// 只返回有 markets 数据的比赛 (使用 defensive cast 避免类型不匹配)
 		whereClauses = append(whereClauses, "EXISTS (SELECT 1 FROM markets m WHERE m.event_id::text = te.event_id)")
 	}
+	
+	// 添加 market_group 过滤: 只返回有该组盘口的比赛, 盘口列表也只保留该组 ("all" 不过滤)
+	if marketGroup != "" && marketGroup != services.MarketGroupAll {
+		whereClauses = append(whereClauses, "EXISTS (SELECT 1 FROM markets m WHERE m.event_id::text = te.event_id AND "+services.MarketGroupCondition("m.groups", len(args)+1)+")")
+		args = append(args, marketGroup)
+	}
 		
 		// 构建 WHERE 子句
 		whereClause := ""
@@ -188,7 +227,7 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 				te.home_team_id, te.home_team_name, te.away_team_id, te.away_team_name,
 				te.home_score, te.away_score, te.match_status, te.match_time,
 				te.message_count, te.last_message_at, te.subscribed,
-				te.created_at, te.updated_at,
+				te.created_at, te.updated_at, te.event_type, te.competitors,
 				COALESCE(MAX(m.updated_at), te.last_message_at) as last_update
 			FROM tracked_events te
 			LEFT JOIN markets m ON m.event_id::text = te.event_id
@@ -197,7 +236,7 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 				te.home_team_id, te.home_team_name, te.away_team_id, te.away_team_name,
 				te.home_score, te.away_score, te.match_status, te.match_time,
 				te.message_count, te.last_message_at, te.subscribed,
-				te.created_at, te.updated_at
+				te.created_at, te.updated_at, te.event_type, te.competitors
 		`
 	
 		// 添加排序和限制 (支持 page/page_size)
@@ -225,6 +264,7 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 		var srnID, homeTeamID, homeTeamName, awayTeamID, awayTeamName sql.NullString
 		var homeScore, awayScore sql.NullInt64
 		var matchStatus, matchTime sql.NullString
+		var eventType, competitors sql.NullString
 		
 		var lastUpdate sql.NullTime
 		err := rows.Scan(
@@ -232,7 +272,7 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 			&homeTeamID, &homeTeamName, &awayTeamID, &awayTeamName,
 			&homeScore, &awayScore, &matchStatus, &matchTime,
 			&event.MessageCount, &lastMessageAt, &event.Subscribed,
-			&event.CreatedAt, &event.UpdatedAt,
+			&event.CreatedAt, &event.UpdatedAt, &eventType, &competitors,
 			&lastUpdate, // last_update 字段
 		)
 		
@@ -245,6 +285,15 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 		if srnID.Valid {
 			event.SRNID = &srnID.String
 		}
+		event.EventType = eventType.String
+		if event.EventType == "" {
+			event.EventType = uof.EventType(event.EventID)
+		}
+		if competitors.Valid {
+			if err := json.Unmarshal([]byte(competitors.String), &event.Competitors); err != nil {
+				log.Printf("[API] Failed to parse competitors for %s: %v", event.EventID, err)
+			}
+		}
 		if scheduleTime.Valid {
 			t := scheduleTime.Time.Format("2006-01-02T15:04:05Z")
 			event.ScheduleTime = &t
@@ -315,9 +364,14 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 		if event.AwayTeamName != nil {
 			localAwayTeamName = *event.AwayTeamName
 		}
+		ctx := s.marketContext(event.Competitors, homeTeamID.String, localHomeTeamName, awayTeamID.String, localAwayTeamName, lang)
+		
+		// 名称按 lang 参数翻译, 附带参赛方简称和国家代码
+		s.localizeEvent(&event, lang)
+		s.enrichEvent(&event)
 		
 		// 获取盘口信息 (按 producer 过滤)
-		markets, err := s.getEventMarketsWithProducer(event.EventID, producer, localHomeTeamName, localAwayTeamName)
+		markets, err := s.getEventMarketsWithProducer(event.EventID, producer, ctx)
 			if err != nil {
 				log.Printf("[API] Failed to get markets for %s: %v", event.EventID, err)
 				event.Markets = []MarketInfo{} // 空数组而不是 null
@@ -330,10 +384,22 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 				}
 			}
 			
+			// main_lines_only=true 时让球 / 大小球只保留主盘口
+			if mainLinesOnly {
+				event.Markets = filterMainLines(event.Markets)
+			}
+			if marketGroup != "" {
+				event.Markets = filterMarketGroup(event.Markets, marketGroup)
+			}
+			
 			// 如果 has_markets=true，过滤掉没有 markets 的比赛
 			if hasMarkets == "true" && len(event.Markets) == 0 {
 				continue
 			}
+			
+			if formatOdds {
+				formatMarketOutcomes(event.Markets, oddsFormat)
+			}
 		
 		events = append(events, event)
 	}
@@ -357,14 +423,15 @@ func (s *Server) handleGetEnhancedEvents(w http.ResponseWriter, r *http.Request)
 // getEventMarkets 获取赛事的盘口信息
 func (s *Server) getEventMarkets(eventID string) ([]MarketInfo, error) {
 	// 传入空字符串作为默认值
-	return s.getEventMarketsWithProducer(eventID, "", "", "")
+	return s.getEventMarketsWithProducer(eventID, "", nil)
 }
 
 // getEventMarketsWithProducer 获取赛事的盘口信息 (按 producer 过滤)
-func (s *Server) getEventMarketsWithProducer(eventID string, producer string, homeTeamName string, awayTeamName string) ([]MarketInfo, error) {
+// ctx 提供队伍 / 参赛方名称和名称语言, 可以为 nil
+func (s *Server) getEventMarketsWithProducer(eventID string, producer string, ctx *services.ReplacementContext) ([]MarketInfo, error) {
 	query := `
 		SELECT DISTINCT ON (sr_market_id, specifiers)
-			id, sr_market_id, specifiers, status, producer_id, updated_at
+			id, sr_market_id, specifiers, status, producer_id, COALESCE(is_main_line, false), COALESCE(groups, ''), updated_at
 		FROM markets
 		WHERE event_id = $1
 	`
@@ -394,8 +461,9 @@ func (s *Server) getEventMarketsWithProducer(eventID string, producer string, ho
 		var specifiers sql.NullString
 		
 		var producerID sql.NullInt64
+		var groups string
 		
-		err := rows.Scan(&marketPK, &market.MarketID, &specifiers, &market.Status, &producerID, &market.UpdatedAt)
+		err := rows.Scan(&marketPK, &market.MarketID, &specifiers, &market.Status, &producerID, &market.IsMainLine, &groups, &market.UpdatedAt)
 		if err != nil {
 			log.Printf("[API] Failed to scan market: %v", err)
 			continue
@@ -409,11 +477,17 @@ func (s *Server) getEventMarketsWithProducer(eventID string, producer string, ho
 			market.ProducerID = int(producerID.Int64)
 		}
 		
+		// 描述加载前入库的盘口没有 groups, 使用当前描述
+		if groups == "" && s.marketDescService != nil {
+			groups = s.marketDescService.GetMarketGroups(market.MarketID)
+		}
+		market.Groups = services.SplitMarketGroups(groups)
+		
 	// 获取市场名称 (简化版,可以后续从 market descriptions 获取)
-				market.MarketName = s.getMarketName(market.MarketID, homeTeamName, awayTeamName, market.Specifiers)
+				market.MarketName = s.getMarketName(market.MarketID, ctx, market.Specifiers)
 			
 				// 获取该盘口的赔率 (使用 marketPK)
-				outcomes, err := s.getMarketOutcomes(marketPK, market.MarketID, homeTeamName, awayTeamName, market.Specifiers)
+				outcomes, err := s.getMarketOutcomes(marketPK, market.MarketID, ctx, market.Specifiers)
 		if err != nil {
 			log.Printf("[API] Failed to get outcomes for market %s: %v", market.MarketID, err)
 			market.Outcomes = []OutcomeInfo{}
@@ -428,10 +502,33 @@ func (s *Server) getEventMarketsWithProducer(eventID string, producer string, ho
 	return markets, nil
 }
 
+// filterMainLines 过滤掉让球 / 大小球盘口中非主盘口的线, 其他盘口保留
+func filterMainLines(markets []MarketInfo) []MarketInfo {
+	filtered := make([]MarketInfo, 0, len(markets))
+	for _, market := range markets {
+		if _, _, isLine := services.SplitLineSpecifier(market.Specifiers); isLine && !market.IsMainLine {
+			continue
+		}
+		filtered = append(filtered, market)
+	}
+	return filtered
+}
+
+// filterMarketGroup 只保留属于指定盘口组的盘口
+func filterMarketGroup(markets []MarketInfo, group string) []MarketInfo {
+	filtered := make([]MarketInfo, 0, len(markets))
+	for _, market := range markets {
+		if services.HasMarketGroup(market.Groups, group) {
+			filtered = append(filtered, market)
+		}
+	}
+	return filtered
+}
+
 // getMarketOutcomes 获取盘口的赔率
-func (s *Server) getMarketOutcomes(marketPK int, marketID string, homeTeamName string, awayTeamName string, specifiers string) ([]OutcomeInfo, error) {
+func (s *Server) getMarketOutcomes(marketPK int, marketID string, ctx *services.ReplacementContext, specifiers string) ([]OutcomeInfo, error) {
 	query := `
-		SELECT outcome_id, odds_value, probability, active, updated_at
+		SELECT outcome_id, COALESCE(outcome_name, ''), odds_value, probability, active, updated_at
 		FROM odds
 		WHERE market_id = $1
 		ORDER BY outcome_id
@@ -448,10 +545,10 @@ func (s *Server) getMarketOutcomes(marketPK int, marketID string, homeTeamName s
 	
 	for rows.Next() {
 		var outcome OutcomeInfo
-		var updatedAt string
+		var updatedAt, storedName string
 		
 		var probability sql.NullFloat64
-		err := rows.Scan(&outcome.OutcomeID, &outcome.Odds, &probability, &outcome.Active, &updatedAt)
+		err := rows.Scan(&outcome.OutcomeID, &storedName, &outcome.Odds, &probability, &outcome.Active, &updatedAt)
 		if probability.Valid {
 			outcome.Probability = probability.Float64
 		}
@@ -461,7 +558,11 @@ func (s *Server) getMarketOutcomes(marketPK int, marketID string, homeTeamName s
 		}
 		
 		// 获取结果名称 (简化版)
-			outcome.OutcomeName = s.getOutcomeName(marketID, outcome.OutcomeID, homeTeamName, awayTeamName, specifiers)
+			outcome.OutcomeName = s.getOutcomeName(marketID, outcome.OutcomeID, ctx, specifiers)
+		// 非对阵赛事的参赛方结果 (sr:competitor:xxx) 在入库时已按参赛方列表命名
+		if outcome.OutcomeName == outcome.OutcomeID && storedName != "" {
+			outcome.OutcomeName = storedName
+		}
 		outcomes = append(outcomes, outcome)
 	}
 	
@@ -469,15 +570,10 @@ func (s *Server) getMarketOutcomes(marketPK int, marketID string, homeTeamName s
 }
 
 // getMarketName 获取市场名称
-func (s *Server) getMarketName(marketID string, homeTeamName string, awayTeamName string, specifiers string) string {
+func (s *Server) getMarketName(marketID string, ctx *services.ReplacementContext, specifiers string) string {
 	// 优先使用 Market Descriptions Service
 	if s.marketDescService != nil {
-		ctx := services.ReplacementContext{
-			HomeTeamName: homeTeamName,
-			AwayTeamName: awayTeamName,
-			Specifiers:   specifiers,
-		}
-			name := s.marketDescService.GetMarketName(marketID, specifiers, &ctx)
+			name := s.marketDescService.GetMarketName(marketID, specifiers, withSpecifiers(ctx, specifiers))
 		// 如果不是默认的 "Market X" 格式,说明找到了
 		if name != "Market "+marketID {
 			return name
@@ -512,16 +608,21 @@ func (s *Server) getMarketName(marketID string, homeTeamName string, awayTeamNam
 	return "Market " + marketID
 }
 
+// withSpecifiers 复制名称模板上下文并设置盘口的 specifiers (ctx 可以为 nil)
+func withSpecifiers(ctx *services.ReplacementContext, specifiers string) *services.ReplacementContext {
+	c := services.ReplacementContext{}
+	if ctx != nil {
+		c = *ctx
+	}
+	c.Specifiers = specifiers
+	return &c
+}
+
 // getOutcomeName 获取结果名称
-func (s *Server) getOutcomeName(marketID string, outcomeID string, homeTeamName string, awayTeamName string, specifiers string) string {
+func (s *Server) getOutcomeName(marketID string, outcomeID string, ctx *services.ReplacementContext, specifiers string) string {
 	// 优先使用 Market Descriptions Service
 	if s.marketDescService != nil {
-		ctx := services.ReplacementContext{
-			HomeTeamName: homeTeamName,
-			AwayTeamName: awayTeamName,
-			Specifiers:   specifiers,
-		}
-			name := s.marketDescService.GetOutcomeName(marketID, outcomeID, specifiers, &ctx)
+			name := s.marketDescService.GetOutcomeName(marketID, outcomeID, specifiers, withSpecifiers(ctx, specifiers))
 		// 如果不是默认的 "Outcome X" 格式,说明找到了
 		if name != "Outcome "+outcomeID {
 			return name
@@ -98,6 +98,8 @@ func (s *Server) handleGetEventsWithFilters(w http.ResponseWriter, r *http.Reque
 	
 	// 使用 SR 映射器转换数据
 	enhancedMatches := MapMatchList(matches, s.srMapper)
+	s.localizeMatches(enhancedMatches, s.requestLanguage(r))
+	s.enrichMatches(enhancedMatches)
 
 	// 构建响应
 	response := map[string]interface{}{
@@ -135,6 +137,9 @@ type EventFilters struct {
 	// 体育类型筛选 (支持多选,逗号分隔)
 	SportIDs []string
 	
+	// 赛事类型筛选: match=对阵比赛, outright=冠军 / 赛季 / 阶段等非对阵赛事
+	EventType string
+	
 	// 开赛时间筛选 (左闭右闭)
 	StartTimeFrom *time.Time
 	StartTimeTo   *time.Time
@@ -204,6 +209,11 @@ func parseEventFilters(r *http.Request) *EventFilters {
 		}
 	}
 	
+	// 赛事类型筛选 (无效值忽略)
+	if eventType := r.URL.Query().Get("event_type"); eventType == services.EventFilterMatch || eventType == services.EventFilterOutright {
+		filters.EventType = eventType
+	}
+	
 	// 开赛时间筛选
 	if startFrom := r.URL.Query().Get("start_time_from"); startFrom != "" {
 		if t, err := parseDateTime(startFrom); err == nil {
@@ -305,7 +315,7 @@ func buildEventFilterQuery(filters *EventFilters) (string, []interface{}) {
 	`
 	
 	// 是否需要 JOIN markets 表
-	needMarketsJoin := len(filters.MarketIDs) > 0 // MarketGroup 暂时不支持
+	needMarketsJoin := len(filters.MarketIDs) > 0 || filterByMarketGroup(filters)
 	
 	if needMarketsJoin {
 		query += " LEFT JOIN markets m ON e.event_id = m.event_id"
@@ -352,6 +362,11 @@ func buildEventFilterQuery(filters *EventFilters) (string, []interface{}) {
 			}
 		}
 	
+	// 赛事类型筛选
+	if condition, err := services.EventTypeCondition("e.event_type", filters.EventType); err == nil && condition != "" {
+		conditions = append(conditions, condition)
+	}
+	
 	// 开赛时间筛选 (左闭右闭)
 	if filters.StartTimeFrom != nil {
 		conditions = append(conditions, fmt.Sprintf("e.schedule_time >= $%d", argIndex))
@@ -370,12 +385,12 @@ func buildEventFilterQuery(filters *EventFilters) (string, []interface{}) {
 		argIndex++
 	}
 	
-	// 盘口组筛选 (暂时禁用,因为 markets 表没有 groups 字段)
-	// if filters.MarketGroup != "" {
-	// 	conditions = append(conditions, fmt.Sprintf("m.groups LIKE $%d", argIndex))
-	// 	args = append(args, "%"+filters.MarketGroup+"%")
-	// 	argIndex++
-	// }
+	// 盘口组筛选 (markets.groups 为 "|" 分隔的组, "all" 不筛选)
+	if filterByMarketGroup(filters) {
+		conditions = append(conditions, services.MarketGroupCondition("m.groups", argIndex))
+		args = append(args, filters.MarketGroup)
+		argIndex++
+	}
 	
 		// 盘口类型筛选 (支持多选)
 		if len(filters.MarketIDs) > 0 {
@@ -501,7 +516,7 @@ func buildEventCountQuery(filters *EventFilters) (string, []interface{}) {
 	query := "SELECT COUNT(DISTINCT e.event_id) FROM tracked_events e"
 	
 	// 是否需要 JOIN markets 表
-	needMarketsJoin := len(filters.MarketIDs) > 0 // MarketGroup 暂时不支持
+	needMarketsJoin := len(filters.MarketIDs) > 0 || filterByMarketGroup(filters)
 	
 	if needMarketsJoin {
 		query += " LEFT JOIN markets m ON e.event_id = m.event_id"
@@ -544,6 +559,11 @@ func buildEventCountQuery(filters *EventFilters) (string, []interface{}) {
 		conditions = append(conditions, fmt.Sprintf("e.sport_id IN (%s)", strings.Join(placeholders, ", ")))
 	}
 	
+	// 赛事类型筛选
+	if condition, err := services.EventTypeCondition("e.event_type", filters.EventType); err == nil && condition != "" {
+		conditions = append(conditions, condition)
+	}
+	
 	if filters.StartTimeFrom != nil {
 		conditions = append(conditions, fmt.Sprintf("e.schedule_time >= $%d", argIndex))
 		args = append(args, filters.StartTimeFrom)
@@ -560,12 +580,12 @@ func buildEventCountQuery(filters *EventFilters) (string, []interface{}) {
 		argIndex++
 	}
 	
-	// 盘口组筛选 (暂时禁用,因为 markets 表没有 groups 字段)
-	// if filters.MarketGroup != "" {
-	// 	conditions = append(conditions, fmt.Sprintf("m.groups LIKE $%d", argIndex))
-	// 	args = append(args, "%"+filters.MarketGroup+"%")
-	// 	argIndex++
-	// }
+	// 盘口组筛选 (markets.groups 为 "|" 分隔的组, "all" 不筛选)
+	if filterByMarketGroup(filters) {
+		conditions = append(conditions, services.MarketGroupCondition("m.groups", argIndex))
+		args = append(args, filters.MarketGroup)
+		argIndex++
+	}
 	
 	// 盘口类型筛选 (支持多选)
 	if len(filters.MarketIDs) > 0 {
@@ -702,3 +722,7 @@ func (f *EventFilters) toMap() map[string]interface{} {
 	return m
 }
 
+// filterByMarketGroup 是否按盘口组筛选 (所有盘口都属于 "all")
+func filterByMarketGroup(filters *EventFilters) bool {
+	return filters.MarketGroup != "" && filters.MarketGroup != services.MarketGroupAll
+}
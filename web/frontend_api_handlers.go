@@ -10,6 +10,8 @@ import (
 	"time"
 	
 	"github.com/gorilla/mux"
+
+	"uof-service/services"
 )
 
 // MatchDetail 比赛详情结构
@@ -52,6 +54,18 @@ func (s *Server) handleGetMatchDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	includeMarkets := r.URL.Query().Get("include_markets") == "true"
+	if formatOdds && !includeMarkets {
+		// 不带盘口时没有可转换的赔率, 明确拒绝而不是静默忽略
+		http.Error(w, "odds_format requires include_markets=true", http.StatusBadRequest)
+		return
+	}
+	
 	log.Printf("[API] Getting match detail for: %s", eventID)
 	
 	query := `
@@ -65,7 +79,7 @@ func (s *Server) handleGetMatchDetail(w http.ResponseWriter, r *http.Request) {
 	`
 	
 	var match MatchDetail
-	err := s.db.QueryRow(query, eventID).Scan(
+	err = s.db.QueryRow(query, eventID).Scan(
 		&match.EventID,
 		&match.SRNID,
 		&match.SportID,
@@ -99,11 +113,63 @@ func (s *Server) handleGetMatchDetail(w http.ResponseWriter, r *http.Request) {
 	// 使用 SR 映射器转换数据
 	enhancedMatch := MapMatchDetail(match, s.srMapper)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	// 附带参赛方列表 (outright / season 等非对阵赛事的结果即参赛方)
+	competitors, err := services.LoadEventCompetitors(s.db, eventID)
+	if err != nil {
+		log.Printf("[API] Failed to get competitors for %s: %v", eventID, err)
+	} else {
+		enhancedMatch.Competitors = competitors
+	}
+
+	// 名称按 lang 参数翻译
+	lang := s.requestLanguage(r)
+	s.localizeMatch(&enhancedMatch, lang)
+	s.enrichMatch(&enhancedMatch)
+
+	// 附带完整赛事状态
+	if eventStatus, err := s.eventStatusService.Get(eventID); err != nil {
+		log.Printf("[API] Failed to get event status for %s: %v", eventID, err)
+	} else {
+		enhancedMatch.EventStatus = eventStatus
+	}
+
+	response := map[string]interface{}{
 		"success": true,
 		"match":   enhancedMatch,
-	})
+	}
+
+	// include_markets=true 时附带盘口和赔率 (支持 odds_format)
+	if includeMarkets {
+		homeTeamID, homeTeamName, awayTeamID, awayTeamName := "", "", "", ""
+		if match.HomeTeamID != nil {
+			homeTeamID = *match.HomeTeamID
+		}
+		if match.HomeTeamName != nil {
+			homeTeamName = *match.HomeTeamName
+		}
+		if match.AwayTeamID != nil {
+			awayTeamID = *match.AwayTeamID
+		}
+		if match.AwayTeamName != nil {
+			awayTeamName = *match.AwayTeamName
+		}
+		ctx := s.marketContext(competitors, homeTeamID, homeTeamName, awayTeamID, awayTeamName, lang)
+
+		markets, err := s.getEventMarketsWithProducer(eventID, r.URL.Query().Get("producer"), ctx)
+		if err != nil {
+			log.Printf("[API] Failed to get markets for %s: %v", eventID, err)
+		}
+		if markets == nil {
+			markets = []MarketInfo{}
+		}
+		if formatOdds {
+			formatMarketOutcomes(markets, oddsFormat)
+		}
+		response["markets"] = markets
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
 // handleGetLiveMatches 获取所有进行中的比赛 (分页)
@@ -203,6 +269,8 @@ func (s *Server) handleGetLiveMatches(w http.ResponseWriter, r *http.Request) {
 	
 	// 使用 SR 映射器转换数据
 	enhancedMatches := MapMatchList(matches, s.srMapper)
+	s.localizeMatches(enhancedMatches, s.requestLanguage(r))
+	s.enrichMatches(enhancedMatches)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -324,6 +392,8 @@ func (s *Server) handleGetUpcomingMatches(w http.ResponseWriter, r *http.Request
 	
 	// 使用 SR 映射器转换数据
 	enhancedMatches := MapMatchList(matches, s.srMapper)
+	s.localizeMatches(enhancedMatches, s.requestLanguage(r))
+	s.enrichMatches(enhancedMatches)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -402,6 +472,8 @@ func (s *Server) handleGetMatchesByStatus(w http.ResponseWriter, r *http.Request
 	
 	// 使用 SR 映射器转换数据
 	enhancedMatches := MapMatchList(matches, s.srMapper)
+	s.localizeMatches(enhancedMatches, s.requestLanguage(r))
+	s.enrichMatches(enhancedMatches)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -480,6 +552,8 @@ func (s *Server) handleSearchMatches(w http.ResponseWriter, r *http.Request) {
 	
 	// 使用 SR 映射器转换数据
 	enhancedMatches := MapMatchList(matches, s.srMapper)
+	s.localizeMatches(enhancedMatches, s.requestLanguage(r))
+	s.enrichMatches(enhancedMatches)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
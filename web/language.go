@@ -0,0 +1,103 @@
+package web
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"uof-service/services"
+)
+
+// requestLanguage 返回请求的名称语言 (lang 参数, 未配置的语言回退到 en)
+// 未指定 lang 时返回空字符串, 响应保持原有格式
+func (s *Server) requestLanguage(r *http.Request) string {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		return ""
+	}
+	return s.localization.NormalizeLanguage(lang)
+}
+
+// marketContext 构造盘口 / 结果名称模板上下文, 参赛方名称使用指定语言
+// 没有参赛方列表的赛事使用主客队名称
+func (s *Server) marketContext(competitors []services.EventCompetitor, homeTeamID, homeTeamName, awayTeamID, awayTeamName, lang string) *services.ReplacementContext {
+	ctx := s.localization.ReplacementContext(competitors, "", lang)
+	if ctx.HomeTeamName == "" {
+		ctx.HomeTeamName = s.localization.Localize(homeTeamID, lang, homeTeamName)
+	}
+	if ctx.AwayTeamName == "" {
+		ctx.AwayTeamName = s.localization.Localize(awayTeamID, lang, awayTeamName)
+	}
+	return ctx
+}
+
+// eventMarketContext 按赛事的参赛方 / 主客队构造名称模板上下文, 用于只有赛事 ID 的接口
+func (s *Server) eventMarketContext(eventID, lang string) *services.ReplacementContext {
+	var homeTeamID, homeTeamName, awayTeamID, awayTeamName sql.NullString
+	err := s.db.QueryRow(`
+		SELECT home_team_id, home_team_name, away_team_id, away_team_name
+		FROM tracked_events WHERE event_id = $1
+	`, eventID).Scan(&homeTeamID, &homeTeamName, &awayTeamID, &awayTeamName)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("[API] Failed to query teams for %s: %v", eventID, err)
+	}
+	competitors, err := services.LoadEventCompetitors(s.db, eventID)
+	if err != nil {
+		log.Printf("[API] Failed to load competitors for %s: %v", eventID, err)
+	}
+	return s.marketContext(competitors, homeTeamID.String, homeTeamName.String, awayTeamID.String, awayTeamName.String, lang)
+}
+
+// localizeTeamName 将队伍名称替换为指定语言 (缺少翻译时保留原名称)
+func (s *Server) localizeTeamName(teamID, teamName *string, lang string) *string {
+	if teamID == nil || teamName == nil {
+		return teamName
+	}
+	name := s.localization.Localize(*teamID, lang, *teamName)
+	return &name
+}
+
+// localizeEvent 将赛事的队伍 / 参赛方 / 运动 / 比赛状态名称替换为指定语言
+func (s *Server) localizeEvent(event *EnhancedEvent, lang string) {
+	if lang == "" {
+		return
+	}
+	event.HomeTeamName = s.localizeTeamName(event.HomeTeamID, event.HomeTeamName, lang)
+	event.AwayTeamName = s.localizeTeamName(event.AwayTeamID, event.AwayTeamName, lang)
+	event.Competitors = s.localization.LocalizeCompetitors(event.Competitors, lang)
+	if name := s.localization.SportName(event.SportID, lang); name != "" {
+		event.SportName = name
+	}
+	if event.MatchStatus != nil {
+		if name := s.localization.MatchStatusName(*event.MatchStatus, lang); name != "" {
+			event.MatchStatusName = name
+		}
+	}
+}
+
+// localizeMatch 同 localizeEvent, 用于前端比赛接口
+func (s *Server) localizeMatch(match *EnhancedMatchDetail, lang string) {
+	if lang == "" {
+		return
+	}
+	match.HomeTeamName = s.localizeTeamName(match.HomeTeamID, match.HomeTeamName, lang)
+	match.AwayTeamName = s.localizeTeamName(match.AwayTeamID, match.AwayTeamName, lang)
+	match.Competitors = s.localization.LocalizeCompetitors(match.Competitors, lang)
+	if match.SportID != nil {
+		if name := s.localization.SportName(*match.SportID, lang); name != "" {
+			match.SportName = name
+		}
+	}
+	if match.MatchStatus != nil {
+		if name := s.localization.MatchStatusName(*match.MatchStatus, lang); name != "" {
+			match.MatchStatusName = name
+		}
+	}
+}
+
+// localizeMatches 批量替换比赛列表的名称语言
+func (s *Server) localizeMatches(matches []EnhancedMatchDetail, lang string) {
+	for i := range matches {
+		s.localizeMatch(&matches[i], lang)
+	}
+}
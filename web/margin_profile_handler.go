@@ -0,0 +1,166 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+// handleListMarginProfiles 列出利润率配置
+// GET /api/margin-profiles
+func (s *Server) handleListMarginProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	profiles, err := s.marginService.ListProfiles()
+	if err != nil {
+		log.Printf("[API] Failed to list margin profiles: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"count":    len(profiles),
+		"profiles": profiles,
+	})
+}
+
+// handleGetMarginProfile 获取单个利润率配置
+// GET /api/margin-profiles/{id}
+func (s *Server) handleGetMarginProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid profile id")
+		return
+	}
+
+	profile, err := s.marginService.GetProfile(id)
+	if err != nil {
+		log.Printf("[API] Failed to get margin profile %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if profile == nil {
+		writeJSONError(w, http.StatusNotFound, "margin profile not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"profile": profile,
+	})
+}
+
+// decodeMarginProfile 解析请求体, enabled 缺省为 true
+func decodeMarginProfile(r *http.Request) (*services.MarginProfile, error) {
+	profile := &services.MarginProfile{
+		Mode:    services.MarginModeTarget,
+		Enabled: true,
+	}
+	if err := json.NewDecoder(r.Body).Decode(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// handleCreateMarginProfile 创建利润率配置
+// POST /api/margin-profiles
+func (s *Server) handleCreateMarginProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	profile, err := decodeMarginProfile(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.marginService.CreateProfile(profile)
+	if err != nil {
+		log.Printf("[API] Failed to create margin profile: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"profile": created,
+	})
+}
+
+// handleUpdateMarginProfile 更新利润率配置 (整体替换)
+// PUT /api/margin-profiles/{id}
+func (s *Server) handleUpdateMarginProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid profile id")
+		return
+	}
+
+	profile, err := decodeMarginProfile(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.marginService.UpdateProfile(id, profile)
+	if err != nil {
+		log.Printf("[API] Failed to update margin profile %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if updated == nil {
+		writeJSONError(w, http.StatusNotFound, "margin profile not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"profile": updated,
+	})
+}
+
+// handleDeleteMarginProfile 删除利润率配置
+// DELETE /api/margin-profiles/{id}
+func (s *Server) handleDeleteMarginProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid profile id")
+		return
+	}
+
+	found, err := s.marginService.DeleteProfile(id)
+	if err != nil {
+		log.Printf("[API] Failed to delete margin profile %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "margin profile not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "margin profile deleted",
+	})
+}
@@ -3,6 +3,7 @@ package web
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"uof-service/logger"
 	"uof-service/services"
@@ -74,3 +75,32 @@ logger.Println("[API] Bulk update of existing markets/outcomes requested")
 	})
 
 }
+
+// HandleGetChanges 查询描述变化记录 (刷新时与旧描述比较得到)
+// GET /api/market-descriptions/changes?market_id=18&limit=100
+func (h *MarketDescriptionsHandler) HandleGetChanges(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+
+	changes, err := h.service.GetDescriptionChanges(r.URL.Query().Get("market_id"), limit)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		logger.Printf("[API] ⚠️  Failed to query description changes: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"data": map[string]interface{}{
+			"count":   len(changes),
+			"changes": changes,
+		},
+	})
+}
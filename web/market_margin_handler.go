@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetEventMargins 获取赛事每个盘口的当前返还率、利润率和结果概率
+// GET /api/margins/{event_id}
+func (s *Server) handleGetEventMargins(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	w.Header().Set("Content-Type", "application/json")
+
+	margins, err := s.marketMarginService.GetEventMargins(eventID)
+	if err != nil {
+		log.Printf("[API] Failed to query margins for %s: %v", eventID, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    margins,
+	})
+}
+
+// handleGetMarginHistory 获取赛事盘口的返还率历史
+// GET /api/margins/{event_id}/history?market_id=1&specifiers=&limit=200
+func (s *Server) handleGetMarginHistory(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	history, err := s.marketMarginService.GetMarginHistory(eventID, q.Get("market_id"), q.Get("specifiers"), limit)
+	if err != nil {
+		log.Printf("[API] Failed to query margin history for %s: %v", eventID, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"event_id": eventID,
+		"count":    len(history),
+		"history":  history,
+	})
+}
+
+// handleGetMarginAnomalies 获取当前存在返还率异常的盘口
+// GET /api/margins/anomalies?type=under_round&limit=100
+func (s *Server) handleGetMarginAnomalies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	anomalies, err := s.marketMarginService.GetAnomalies(q.Get("type"), limit)
+	if err != nil {
+		log.Printf("[API] Failed to query margin anomalies: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"count":     len(anomalies),
+		"anomalies": anomalies,
+	})
+}
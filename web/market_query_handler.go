@@ -25,15 +25,28 @@ func (h *MarketQueryHandler) GetEventMarkets(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	markets, err := h.service.GetEventMarkets(eventID)
+	markets, err := h.service.GetEventMarkets(eventID, r.URL.Query().Get("lang"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 标签页按全部盘口统计, group 参数只筛选返回的盘口
+	tabs := services.BuildMarketGroupTabs(markets)
+	if group := r.URL.Query().Get("group"); group != "" {
+		filtered := make([]services.MarketInfo, 0, len(markets))
+		for _, market := range markets {
+			if services.HasMarketGroup(market.Groups, group) {
+				filtered = append(filtered, market)
+			}
+		}
+		markets = filtered
+	}
+
 	response := map[string]interface{}{
 		"event_id":      eventID,
 		"total_markets": len(markets),
+		"tabs":          tabs,
 		"markets":       markets,
 	}
 
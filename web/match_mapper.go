@@ -2,6 +2,7 @@ package web
 
 import (
 	"uof-service/services"
+	"uof-service/uof"
 )
 
 // EnhancedMatchDetail 增强的比赛详情结构(包含映射后的字段)
@@ -35,6 +36,19 @@ type EnhancedMatchDetail struct {
 	AwayTeamIDMapped   string `json:"away_team_id_mapped"` // "1002"
 	IsLive             bool   `json:"is_live"`             // true/false
 	IsEnded            bool   `json:"is_ended"`            // true/false
+
+	// 参赛方资料 (简称 / 国家代码, 资料未加载时为空)
+	HomeTeamAbbreviation string `json:"home_team_abbreviation,omitempty"`
+	HomeTeamCountryCode  string `json:"home_team_country_code,omitempty"`
+	AwayTeamAbbreviation string `json:"away_team_abbreviation,omitempty"`
+	AwayTeamCountryCode  string `json:"away_team_country_code,omitempty"`
+
+	// 赛事类型和参赛方列表 (非对阵赛事没有主客队)
+	EventType   string                     `json:"event_type,omitempty"`
+	Competitors []services.EventCompetitor `json:"competitors,omitempty"`
+
+	// 完整赛事状态 (分段比分 / 统计 / 时钟 / 运动特有属性)
+	EventStatus *services.EventStatusDetail `json:"event_status,omitempty"`
 }
 
 // MapMatchDetail 将原始比赛数据映射为增强的比赛详情
@@ -57,6 +71,7 @@ func MapMatchDetail(match MatchDetail, mapper *services.SRMapper) EnhancedMatchD
 		LastMessageAt: match.LastMessageAt,
 		CreatedAt:     match.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:     match.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		EventType:     uof.EventType(match.EventID),
 	}
 
 	// 映射运动类型
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"uof-service/services"
 )
 
 // MatchRecordsSummary 比赛记录概览
@@ -18,6 +20,7 @@ type MatchRecordsSummary struct {
 	BetStopsSummary []BetStopSummary   `json:"bet_stops_summary"`
 	BetSettlementsSummary []BetSettlementSummary `json:"bet_settlements_summary"`
 	MarketsSummary []MarketSummary     `json:"markets_summary"`
+	ClosingLines   []services.ClosingLine `json:"closing_lines"`
 	Statistics     *RecordStatistics   `json:"statistics"`
 }
 
@@ -153,7 +156,16 @@ func (s *Server) handleGetMatchRecords(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[MatchRecords] ⚠️  Failed to get markets summary: %v", err)
 	}
 
-	// 7. 统计信息
+	// 7. 获取收盘赔率
+	closingLines, err := s.closingLineService.GetClosingLines(eventID, "", "")
+	if err != nil {
+		log.Printf("[MatchRecords] ⚠️  Failed to get closing lines: %v", err)
+	}
+	if !s.canViewRawOdds(r) {
+		hideRawClosingOdds(closingLines)
+	}
+
+	// 8. 统计信息
 	statistics := &RecordStatistics{
 		TotalMessages:      len(messagesSummary),
 		TotalOddsChanges:   len(oddsChangesSummary),
@@ -176,6 +188,7 @@ func (s *Server) handleGetMatchRecords(w http.ResponseWriter, r *http.Request) {
 		BetStopsSummary:       betStopsSummary,
 		BetSettlementsSummary: betSettlementsSummary,
 		MarketsSummary:        marketsSummary,
+		ClosingLines:          closingLines,
 		Statistics:            statistics,
 	}
 
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetMatchTimeline 获取比赛时间线 (按 UOF 时间戳正序)
+// GET /api/matches/{event_id}/timeline?types=score_change,red_card&lang=zh
+func (s *Server) handleGetMatchTimeline(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+	w.Header().Set("Content-Type", "application/json")
+
+	var types []string
+	if v := r.URL.Query().Get("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	timeline, err := s.matchTimelineService.GetTimeline(eventID, types)
+	if err != nil {
+		log.Printf("[API] Failed to query timeline for %s: %v", eventID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 队伍 / 比赛状态名称按 lang 参数翻译
+	if lang := s.requestLanguage(r); lang != "" && len(timeline) > 0 {
+		ctx := s.eventMarketContext(eventID, lang)
+		for i := range timeline {
+			switch timeline[i].Team {
+			case "home":
+				timeline[i].TeamName = ctx.HomeTeamName
+			case "away":
+				timeline[i].TeamName = ctx.AwayTeamName
+			}
+			if timeline[i].MatchStatus != "" {
+				timeline[i].MatchStatusName = s.localization.MatchStatusName(timeline[i].MatchStatus, lang)
+			}
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"event_id": eventID,
+		"count":    len(timeline),
+		"timeline": timeline,
+	})
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+// handleListOddsAlertRules 列出赔率告警规则
+// GET /api/odds-alert-rules
+func (s *Server) handleListOddsAlertRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rules, err := s.oddsAlertService.ListRules()
+	if err != nil {
+		log.Printf("[API] Failed to list odds alert rules: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(rules),
+		"rules":   rules,
+	})
+}
+
+// handleGetOddsAlertRule 获取单个赔率告警规则
+// GET /api/odds-alert-rules/{id}
+func (s *Server) handleGetOddsAlertRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	rule, err := s.oddsAlertService.GetRule(id)
+	if err != nil {
+		log.Printf("[API] Failed to get odds alert rule %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rule == nil {
+		writeJSONError(w, http.StatusNotFound, "odds alert rule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"rule":    rule,
+	})
+}
+
+// decodeOddsAlertRule 解析请求体, severity 缺省为 warning, cooldown 缺省 300 秒, enabled 缺省为 true
+func decodeOddsAlertRule(r *http.Request) (*services.OddsAlertRule, error) {
+	rule := &services.OddsAlertRule{
+		Severity:        services.AlertSeverityWarning,
+		CooldownSeconds: 300,
+		Enabled:         true,
+	}
+	if err := json.NewDecoder(r.Body).Decode(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// handleCreateOddsAlertRule 创建赔率告警规则
+// POST /api/odds-alert-rules
+func (s *Server) handleCreateOddsAlertRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rule, err := decodeOddsAlertRule(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.oddsAlertService.CreateRule(rule)
+	if err != nil {
+		log.Printf("[API] Failed to create odds alert rule: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"rule":    created,
+	})
+}
+
+// handleUpdateOddsAlertRule 更新赔率告警规则 (整体替换)
+// PUT /api/odds-alert-rules/{id}
+func (s *Server) handleUpdateOddsAlertRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	rule, err := decodeOddsAlertRule(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.oddsAlertService.UpdateRule(id, rule)
+	if err != nil {
+		log.Printf("[API] Failed to update odds alert rule %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if updated == nil {
+		writeJSONError(w, http.StatusNotFound, "odds alert rule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"rule":    updated,
+	})
+}
+
+// handleDeleteOddsAlertRule 删除赔率告警规则
+// DELETE /api/odds-alert-rules/{id}
+func (s *Server) handleDeleteOddsAlertRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid rule id")
+		return
+	}
+
+	found, err := s.oddsAlertService.DeleteRule(id)
+	if err != nil {
+		log.Printf("[API] Failed to delete odds alert rule %d: %v", id, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "odds alert rule not found")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "odds alert rule deleted",
+	})
+}
+
+// handleGetOddsAlerts 查询触发的赔率告警 (不带 since_id 时最新的在前, 带 since_id 时按 ID 正序增量拉取)
+// GET /api/odds-alerts?event_id=sr:match:1&severity=critical&since_id=0&limit=100
+func (s *Server) handleGetOddsAlerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	sinceID, _ := strconv.ParseInt(q.Get("since_id"), 10, 64)
+
+	alerts, err := s.oddsAlertService.GetAlerts(q.Get("event_id"), q.Get("severity"), sinceID, limit)
+	if err != nil {
+		log.Printf("[API] Failed to query odds alerts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// next_since_id 为已返回的最大 ID, 用于下一次增量拉取
+	nextSinceID := sinceID
+	for _, alert := range alerts {
+		if alert.ID > nextSinceID {
+			nextSinceID = alert.ID
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"count":         len(alerts),
+		"next_since_id": nextSinceID,
+		"alerts":        alerts,
+	})
+}
@@ -0,0 +1,35 @@
+package web
+
+import (
+	"net/http"
+
+	"uof-service/services"
+)
+
+// parseOddsFormatParam 解析 odds_format 查询参数
+// 未传参数时 requested 为 false, 响应保持原样 (只返回十进制赔率)
+func parseOddsFormatParam(r *http.Request) (format services.OddsFormat, requested bool, err error) {
+	value := r.URL.Query().Get("odds_format")
+	if value == "" {
+		return services.OddsFormatDecimal, false, nil
+	}
+	format, err = services.ParseOddsFormat(value)
+	return format, err == nil, err
+}
+
+// formatMarketOutcomes 为盘口中每个结果填充转换后的赔率
+func formatMarketOutcomes(markets []MarketInfo, format services.OddsFormat) {
+	for i := range markets {
+		for j := range markets[i].Outcomes {
+			outcome := &markets[i].Outcomes[j]
+			outcome.Formatted = services.ConvertOdds(outcome.Odds, format)
+		}
+	}
+}
+
+// formatOddsDetails 为赔率列表填充转换后的赔率
+func formatOddsDetails(odds []services.OddsDetail, format services.OddsFormat) {
+	for i := range odds {
+		odds[i].Formatted = services.ConvertOdds(odds[i].OddsValue, format)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 	
 	"github.com/gorilla/mux"
 	"uof-service/services"
@@ -34,6 +35,14 @@ func (s *Server) handleGetEventMarkets(w http.ResponseWriter, r *http.Request) {
 		markets = []services.OddsMarketInfo{}
 	}
 	
+	// 名称按 lang 参数翻译
+	if lang := s.requestLanguage(r); lang != "" && len(markets) > 0 {
+		ctx := s.eventMarketContext(eventID, lang)
+		for i := range markets {
+			markets[i].MarketName = s.getMarketName(markets[i].MarketID, ctx, markets[i].Specifiers)
+		}
+	}
+	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":   true,
@@ -54,6 +63,12 @@ func (s *Server) handleGetMarketOdds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
 	log.Printf("[API] Getting odds for event: %s, market: %s", eventID, marketID)
 	
 	oddsParser := services.NewOddsParser(s.db, s.marketDescService)
@@ -67,6 +82,17 @@ func (s *Server) handleGetMarketOdds(w http.ResponseWriter, r *http.Request) {
 	if odds == nil {
 		odds = []services.OddsDetail{}
 	}
+	if formatOdds {
+		formatOddsDetails(odds, oddsFormat)
+	}
+	
+	// 名称按 lang 参数翻译
+	if lang := s.requestLanguage(r); lang != "" && len(odds) > 0 {
+		ctx := s.eventMarketContext(eventID, lang)
+		for i := range odds {
+			odds[i].OutcomeName = s.localizedOutcomeName(marketID, odds[i].OutcomeID, odds[i].OutcomeName, odds[i].Specifiers, ctx)
+		}
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -102,11 +128,18 @@ func (s *Server) handleGetOddsHistory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
 	log.Printf("[API] Getting odds history for event: %s, market: %s, outcome: %s, limit: %d", 
 		eventID, marketID, outcomeID, limit)
 	
+	specifiers := r.URL.Query().Get("specifiers")
 	oddsParser := services.NewOddsParser(s.db, s.marketDescService)
-	history, err := oddsParser.GetOddsHistory(eventID, marketID, outcomeID, limit)
+	history, err := oddsParser.GetOddsHistory(eventID, marketID, specifiers, outcomeID, limit)
 	if err != nil {
 		log.Printf("[API] Error querying odds history: %v", err)
 		http.Error(w, "Failed to query odds history", http.StatusInternalServerError)
@@ -116,15 +149,26 @@ func (s *Server) handleGetOddsHistory(w http.ResponseWriter, r *http.Request) {
 	if history == nil {
 		history = []services.OddsHistoryInfo{}
 	}
+	if formatOdds {
+		for i := range history {
+			history[i].Formatted = services.ConvertOdds(history[i].OddsValue, oddsFormat)
+		}
+	}
+	
+	// 盘口 / 结果名称 (按 lang 参数翻译)
+	ctx := s.eventMarketContext(eventID, s.requestLanguage(r))
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":    true,
-		"event_id":   eventID,
-		"market_id":  marketID,
-		"outcome_id": outcomeID,
-		"count":      len(history),
-		"history":    history,
+		"success":      true,
+		"event_id":     eventID,
+		"market_id":    marketID,
+		"market_name":  s.getMarketName(marketID, ctx, specifiers),
+		"specifiers":   specifiers,
+		"outcome_id":   outcomeID,
+		"outcome_name": s.getOutcomeName(marketID, outcomeID, ctx, specifiers),
+		"count":        len(history),
+		"history":      history,
 	})
 }
 
@@ -132,6 +176,12 @@ func (s *Server) handleGetOddsHistory(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetAllBookedMarketsOdds(w http.ResponseWriter, r *http.Request) {
 	log.Println("[API] Getting all booked matches markets and odds...")
 	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
 	// 1. 查询所有 active 状态的比赛
 	query := `
 		SELECT DISTINCT event_id 
@@ -190,6 +240,9 @@ func (s *Server) handleGetAllBookedMarketsOdds(w http.ResponseWriter, r *http.Re
 				log.Printf("[API] Error getting odds for market %s: %v", market.MarketID, err)
 				continue
 			}
+			if formatOdds {
+				formatOddsDetails(odds, oddsFormat)
+			}
 			
 			marketsWithOdds = append(marketsWithOdds, map[string]interface{}{
 				"sr_market_id":   market.MarketID,
@@ -219,3 +272,89 @@ func (s *Server) handleGetAllBookedMarketsOdds(w http.ResponseWriter, r *http.Re
 	})
 }
 
+
+// handleGetOddsCandles 获取赔率 K 线 (OHLC)
+// GET /api/odds/{event_id}/{market_id}/{outcome_id}/candles?interval=1m&specifiers=total=2.5&from=&to=&limit=300&lang=zh
+func (s *Server) handleGetOddsCandles(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID := vars["event_id"]
+	marketID := vars["market_id"]
+	outcomeID := vars["outcome_id"]
+	q := r.URL.Query()
+	
+	intervalName := q.Get("interval")
+	if intervalName == "" {
+		intervalName = "1m"
+	}
+	interval, ok := services.ParseCandleInterval(intervalName)
+	if !ok {
+		http.Error(w, "interval must be one of 1s, 10s, 1m, 5m", http.StatusBadRequest)
+		return
+	}
+	
+	limit := 300 // 默认 300 根
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = l
+		if limit > 1000 {
+			limit = 1000 // 最多 1000 根
+		}
+	}
+	
+	// 时间范围 (毫秒), 默认为最近 limit 个区间
+	to := time.Now().UnixMilli()
+	if v, err := strconv.ParseInt(q.Get("to"), 10, 64); err == nil && v > 0 {
+		to = v
+	}
+	from := to - int64(limit)*interval.Millis
+	if v, err := strconv.ParseInt(q.Get("from"), 10, 64); err == nil && v > 0 {
+		from = v
+	}
+	
+	oddsFormat, formatOdds, err := parseOddsFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	oddsParser := services.NewOddsParser(s.db, s.marketDescService)
+	candles, err := oddsParser.GetOddsCandles(eventID, marketID, q.Get("specifiers"), outcomeID, interval, from, to, limit)
+	if err != nil {
+		log.Printf("[API] Error querying odds candles: %v", err)
+		http.Error(w, "Failed to query odds candles", http.StatusInternalServerError)
+		return
+	}
+	if formatOdds {
+		for i := range candles {
+			candles[i].Formatted = services.ConvertOdds(candles[i].Close, oddsFormat)
+		}
+	}
+	
+	// 盘口 / 结果名称 (按 lang 参数翻译)
+	ctx := s.eventMarketContext(eventID, s.requestLanguage(r))
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      true,
+		"event_id":     eventID,
+		"market_id":    marketID,
+		"market_name":  s.getMarketName(marketID, ctx, q.Get("specifiers")),
+		"outcome_id":   outcomeID,
+		"outcome_name": s.getOutcomeName(marketID, outcomeID, ctx, q.Get("specifiers")),
+		"specifiers":   q.Get("specifiers"),
+		"interval":     interval.Name,
+		"from":         from,
+		"to":           to,
+		"count":        len(candles),
+		"candles":      candles,
+	})
+}
+
+// localizedOutcomeName 按名称模板上下文重新生成结果名称
+// 模板缺失时保留入库时的名称 (非对阵赛事的参赛方结果已按参赛方列表命名)
+func (s *Server) localizedOutcomeName(marketID, outcomeID, storedName, specifiers string, ctx *services.ReplacementContext) string {
+	name := s.getOutcomeName(marketID, outcomeID, ctx, specifiers)
+	if (name == outcomeID || name == "Outcome "+outcomeID) && storedName != "" {
+		return storedName
+	}
+	return name
+}
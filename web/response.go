@@ -0,0 +1,16 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// writeJSONError 输出 {"success": false, "error": message} 错误响应
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -35,7 +35,21 @@ type Server struct {
 	subscriptionSync    *services.SubscriptionSyncService
 	messageHistoryService *services.MessageHistoryService
 	marketQueryService  *services.MarketQueryService
+	eventSnapshotService *services.EventSnapshotService
+	apiKeyService       *services.APIKeyService
+	auditService        *services.AuditService
+	betValidationService *services.BetValidationService
+	settlementLedger    *services.SettlementLedgerService
+	betCancelService    *services.BetCancelService
+	marginService       *services.MarginService
+	marketMarginService *services.MarketMarginService
+	closingLineService  *services.ClosingLineService
+	oddsAlertService    *services.OddsAlertService
+	matchTimelineService *services.MatchTimelineService
+	eventStatusService   *services.EventStatusService
 	queryCache          *services.QueryCache
+	localization        *services.LocalizationService // 多语言名称 (lang 参数)
+	competitorService   *services.CompetitorService   // 参赛方资料 (可选)
 	sportradarAPIClient *services.SportradarAPIClient
 	httpServer          *http.Server
 	upgrader            websocket.Upgrader
@@ -59,7 +73,13 @@ func NewServer(cfg *config.Config, db *sql.DB, hub *Hub, larkNotifier *services.
 	sportradarAPIClient := services.NewSportradarAPIClient(cfg.APIBaseURL, cfg.AccessToken)
 	log.Println("[Server] Sportradar API client initialized")
 	
-	return &Server{
+	if cfg.APIAuthEnabled {
+		log.Println("[Server] 🔒 API key authentication enabled")
+	} else {
+		log.Println("[Server] ⚠️  API key authentication disabled (API_AUTH_ENABLED=false)")
+	}
+	
+	s := &Server{
 		config:          cfg,
 		db:              db,
 		wsHub:           hub,
@@ -74,17 +94,50 @@ func NewServer(cfg *config.Config, db *sql.DB, hub *Hub, larkNotifier *services.
 		marketDescService: marketDescService,
 		subscriptionSync:  services.NewSubscriptionSyncService(db, cfg.AccessToken, cfg.APIBaseURL, cfg.SubscriptionSyncIntervalMinutes),
 		messageHistoryService: services.NewMessageHistoryService(db),
-		marketQueryService: services.NewMarketQueryService(db),
+		marketQueryService: services.NewMarketQueryService(db, marketDescService),
+		eventSnapshotService: services.NewEventSnapshotService(db, marketDescService),
+		apiKeyService:   services.NewAPIKeyService(db),
+		auditService:    services.NewAuditService(db),
+		settlementLedger: services.NewSettlementLedgerService(db),
+		betCancelService: services.NewBetCancelService(db),
+		marginService:   services.NewMarginService(db, services.NewEventMetaCache(db, 10*time.Minute), time.Duration(cfg.MarginProfileRefreshSeconds)*time.Second),
+		marketMarginService: services.NewMarketMarginService(db),
+		closingLineService: services.NewClosingLineService(db),
+		matchTimelineService: services.NewMatchTimelineService(db),
+		eventStatusService:   services.NewEventStatusService(db),
+		oddsAlertService:   services.NewOddsAlertService(db, time.Duration(cfg.OddsAlertRuleRefreshSeconds)*time.Second, cfg.OddsAlertNotifyPerMinute),
 		queryCache:      services.NewQueryCache(30 * time.Second), // 30秒缓存
 		sportradarAPIClient: sportradarAPIClient,
 		upgrader: websocket.Upgrader{
 				ReadBufferSize:  1024,
 				WriteBufferSize: 1024,
-				CheckOrigin: func(r *http.Request) bool {
-					return true // 允许所有来源(生产环境需要限制)
-				},
+				Subprotocols:    []string{wsProtocol},
 			},
 		}
+	s.betValidationService = services.NewBetValidationService(db, s.producerMonitor, cfg.BetOddsTolerance)
+	
+	// 只允许 ALLOWED_ORIGINS 中的来源 (未配置时允许所有)
+	s.upgrader.CheckOrigin = s.checkOrigin
+	
+	return s
+}
+
+// SetLocalizationService 设置多语言名称服务, 用于响应中的 lang 参数
+func (s *Server) SetLocalizationService(localization *services.LocalizationService) {
+	s.localization = localization
+	s.eventSnapshotService.SetLocalizationService(localization)
+	s.marketQueryService.SetLocalizationService(localization)
+	s.wsHub.SetLocalizationService(localization)
+}
+
+// SetCompetitorService 设置参赛方资料服务, 用于 /api/competitors 和赛事列表中的简称 / 国家代码
+func (s *Server) SetCompetitorService(competitorService *services.CompetitorService) {
+	s.competitorService = competitorService
+}
+
+// SetStaticDataService 设置静态数据服务 (作废原因描述)
+func (s *Server) SetStaticDataService(staticDataService *services.StaticDataService) {
+	s.betCancelService.SetStaticDataService(staticDataService)
 }
 
 func (s *Server) Start() error {
@@ -116,6 +169,9 @@ func (s *Server) Start() error {
 
 	// API路由
 	api := router.PathPrefix("/api").Subrouter()
+	// 审计在鉴权之前, 被拒绝的写请求也会记录
+	api.Use(s.auditMiddleware)
+	api.Use(s.authMiddleware)
 	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 	api.HandleFunc("/messages", s.handleGetMessages).Methods("GET")
 	// 增强版 events API - 包含完整信息和盘口
@@ -180,6 +236,10 @@ func (s *Server) Start() error {
 	api.HandleFunc("/matches/status", s.handleGetMatchesByStatus).Methods("GET")
 	api.HandleFunc("/matches/search", s.handleSearchMatches).Methods("GET")
 	api.HandleFunc("/matches/{event_id}", s.handleGetMatchDetail).Methods("GET")
+	api.HandleFunc("/matches/{event_id}/timeline", s.handleGetMatchTimeline).Methods("GET")
+	
+	// 参赛方资料API
+	api.HandleFunc("/competitors/{competitor_id}", s.handleGetCompetitor).Methods("GET")
 	
 	// 联赛API
 	api.HandleFunc("/leagues", s.handleGetLeagues).Methods("GET")
@@ -189,6 +249,7 @@ func (s *Server) Start() error {
 	api.HandleFunc("/odds/{event_id}/markets", s.handleGetEventMarkets).Methods("GET")
 	api.HandleFunc("/odds/{event_id}/{market_id}", s.handleGetMarketOdds).Methods("GET")
 	api.HandleFunc("/odds/{event_id}/{market_id}/{outcome_id}/history", s.handleGetOddsHistory).Methods("GET")
+	api.HandleFunc("/odds/{event_id}/{market_id}/{outcome_id}/candles", s.handleGetOddsCandles).Methods("GET")
 	
 	// IP 查询API
 	api.HandleFunc("/ip", s.handleGetIP).Methods("GET")
@@ -197,11 +258,47 @@ func (s *Server) Start() error {
 	api.HandleFunc("/producer/status", s.handleGetProducerStatus).Methods("GET")
 	api.HandleFunc("/producer/bet-acceptance", s.handleGetBetAcceptance).Methods("GET")
 	
+	// 投注校验
+	api.HandleFunc("/bets/validate", s.handleValidateBets).Methods("POST")
+	
+	// 结算账本
+	api.HandleFunc("/settlements/ledger", s.handleGetSettlementLedger).Methods("GET")
+	api.HandleFunc("/settlements/flips", s.handleGetSettlementFlips).Methods("GET")
+	api.HandleFunc("/settlements/{event_id}", s.handleGetEventSettlements).Methods("GET")
+	
+	// 投注取消窗口
+	api.HandleFunc("/bet-cancels/{event_id}", s.handleGetBetCancelWindows).Methods("GET")
+	api.HandleFunc("/bet-cancels/{event_id}/check", s.handleCheckBetVoided).Methods("GET")
+	
+	// 利润率配置
+	api.HandleFunc("/margin-profiles", s.handleListMarginProfiles).Methods("GET")
+	api.HandleFunc("/margin-profiles", s.handleCreateMarginProfile).Methods("POST")
+	api.HandleFunc("/margin-profiles/{id}", s.handleGetMarginProfile).Methods("GET")
+	api.HandleFunc("/margin-profiles/{id}", s.handleUpdateMarginProfile).Methods("PUT")
+	api.HandleFunc("/margin-profiles/{id}", s.handleDeleteMarginProfile).Methods("DELETE")
+	
+	// 盘口返还率
+	api.HandleFunc("/margins/anomalies", s.handleGetMarginAnomalies).Methods("GET")
+	api.HandleFunc("/margins/{event_id}", s.handleGetEventMargins).Methods("GET")
+	api.HandleFunc("/margins/{event_id}/history", s.handleGetMarginHistory).Methods("GET")
+	
+	// 收盘赔率
+	api.HandleFunc("/closing-lines/{event_id}", s.handleGetClosingLines).Methods("GET")
+	
+	// 赔率异动告警
+	api.HandleFunc("/odds-alert-rules", s.handleListOddsAlertRules).Methods("GET")
+	api.HandleFunc("/odds-alert-rules", s.handleCreateOddsAlertRule).Methods("POST")
+	api.HandleFunc("/odds-alert-rules/{id}", s.handleGetOddsAlertRule).Methods("GET")
+	api.HandleFunc("/odds-alert-rules/{id}", s.handleUpdateOddsAlertRule).Methods("PUT")
+	api.HandleFunc("/odds-alert-rules/{id}", s.handleDeleteOddsAlertRule).Methods("DELETE")
+	api.HandleFunc("/odds-alerts", s.handleGetOddsAlerts).Methods("GET")
+	
 	// Market Descriptions API
 	marketDescHandler := NewMarketDescriptionsHandler(s.marketDescService)
 	api.HandleFunc("/market-descriptions/status", marketDescHandler.HandleGetStatus).Methods("GET")
 	api.HandleFunc("/market-descriptions/refresh", marketDescHandler.HandleForceRefresh).Methods("POST")
 	api.HandleFunc("/market-descriptions/bulk-update", marketDescHandler.HandleBulkUpdate).Methods("POST")
+	api.HandleFunc("/market-descriptions/changes", marketDescHandler.HandleGetChanges).Methods("GET")
 	
 	// 数据清理 API
 	api.HandleFunc("/cleanup/stats", s.handleGetTableStats).Methods("GET")
@@ -210,6 +307,9 @@ func (s *Server) Start() error {
 	// 数据库重置API（危险操作，需要确认）
 	api.HandleFunc("/database/reset", s.handleResetDatabase).Methods("POST")
 	
+	// 审计日志API
+	api.HandleFunc("/audit", s.handleGetAuditLog).Methods("GET")
+	
 	// 比赛记录查询 API
 	api.HandleFunc("/match/records", s.handleGetMatchRecords).Methods("GET")
 	api.HandleFunc("/record/detail", s.handleGetRecordDetail).Methods("GET")
@@ -225,8 +325,12 @@ func (s *Server) Start() error {
 	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./static")))
 
 	// CORS配置
+	allowedOrigins := s.config.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{"*"}
+	}
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
+		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
@@ -351,6 +455,16 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 
 // handleWebSocket WebSocket连接处理
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// 鉴权: 与 REST 使用相同的 API Key (浏览器通过 Sec-WebSocket-Protocol 传递)
+	var apiKey *services.APIKey
+	if s.config.APIAuthEnabled {
+		apiKey = s.apiKeyService.Authenticate(extractAPIKey(r, true))
+		if apiKey == nil {
+			writeAuthError(w, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -363,6 +477,9 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		send:     make(chan []byte, 256),
 		filters:  make(map[string]bool),
 		eventIDs: make(map[string]bool),
+		snapshotService: s.eventSnapshotService,
+		syncing:  make(map[string][]*WSMessage),
+		apiKey:   apiKey,
 	}
 
 	client.hub.register <- client
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uof-service/services"
+)
+
+// handleGetSettlementLedger 增量拉取结算账本
+// GET /api/settlements/ledger?since_id=0&event_id=sr:match:12345&limit=500
+func (s *Server) handleGetSettlementLedger(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sinceID, _ := strconv.ParseInt(q.Get("since_id"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	entries, err := s.settlementLedger.GetEntries(sinceID, q.Get("event_id"), limit)
+	if err != nil {
+		log.Printf("[API] Failed to query settlement ledger: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	nextSinceID := sinceID
+	if len(entries) > 0 {
+		nextSinceID = entries[len(entries)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"count":         len(entries),
+		"next_since_id": nextSinceID,
+		"entries":       entries,
+	})
+}
+
+// handleGetEventSettlements 获取赛事每个结果当前的有效结算
+// GET /api/settlements/{event_id}
+func (s *Server) handleGetEventSettlements(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["event_id"]
+
+	settlements, err := s.settlementLedger.GetCurrent(eventID)
+	if err != nil {
+		log.Printf("[API] Failed to query settlements for %s: %v", eventID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"event_id":    eventID,
+		"count":       len(settlements),
+		"settlements": settlements,
+	})
+}
+
+// handleGetSettlementFlips 提前结算 (certainty 1) 在确认 (certainty 2) 时结果变化的报告
+// GET /api/settlements/flips?event_id=sr:match:12345&producer_id=1&since=2024-01-01T00:00:00Z&limit=200
+func (s *Server) handleGetSettlementFlips(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := services.SettlementFlipFilter{EventID: q.Get("event_id")}
+	filter.ProducerID, _ = strconv.Atoi(q.Get("producer_id"))
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+	if v := q.Get("since"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			filter.Since = &t
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	flips, err := s.settlementLedger.GetFlips(filter)
+	if err != nil {
+		log.Printf("[API] Failed to query settlement flips: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	summary, err := s.settlementLedger.GetFlipSummary(filter.Since)
+	if err != nil {
+		log.Printf("[API] Failed to query settlement flip summary: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"summary": summary,
+		"count":   len(flips),
+		"flips":   flips,
+	})
+}
@@ -2,11 +2,14 @@ package web
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"uof-service/services"
 )
 
 // WSMessage WebSocket消息结构
@@ -15,9 +18,14 @@ type WSMessage struct {
 	MessageType string  `json:"message_type,omitempty"`
 	EventID     string  `json:"event_id,omitempty"`
 	ProductID   *int    `json:"product_id,omitempty"`
+	SportID     string  `json:"sport_id,omitempty"`
+	TournamentID string `json:"tournament_id,omitempty"`
 	RoutingKey  string  `json:"routing_key,omitempty"`
 	Timestamp   int64   `json:"timestamp,omitempty"`
 	Data        interface{} `json:"data,omitempty"`
+	Delta       interface{} `json:"-"` // 增量数据, 仅推送给 format=delta 的客户端
+	Localized   map[string]interface{} `json:"-"` // 其它语言的数据 (lang -> data), 推送给订阅了 lang 的客户端
+	LocalizedDelta map[string]interface{} `json:"-"` // 其它语言的增量数据 (lang -> delta)
 	// XML 字段已移除,使用 Data 字段传递结构化数据
 }
 
@@ -28,10 +36,34 @@ type Client struct {
 	send     chan []byte
 	filters  map[string]bool // 消息类型过滤器
 	eventIDs map[string]bool // 赛事ID过滤器
+
+	sportIDs      map[string]bool // 运动ID过滤器 (sr:sport:1)
+	tournamentIDs map[string]bool // 联赛ID过滤器 (sr:tournament:17)
+	productIDs    map[int]bool    // 生产者过滤器 (live=1, prematch=3)
+	marketIDs     map[string]bool // 盘口过滤器 (sr_market_id), 同时用于裁剪消息中的 markets
+	format        string          // 消息格式: "full" (默认) / "delta"
+	oddsFormat    services.OddsFormat // 赔率格式, 为空时只推送十进制赔率
+	traderAlerts  bool                // 是否接收 trader 告警流 (需要 trader 权限)
+	lang          string              // 名称语言, 为空时推送英文名称
+
+	snapshotService *services.EventSnapshotService
+	apiKey          *services.APIKey // 连接使用的 API Key (鉴权关闭时为 nil)
+
+	// 订阅快照期间, 对应赛事的实时消息先缓存, 快照发送后再按顺序推送
+	syncing map[string][]*WSMessage
+	closed  bool
+	mu      sync.Mutex
+}
+
+// producerAliases 订阅时可用的生产者别名
+var producerAliases = map[string]int{
+	"live":     1, // LiveOdds
+	"prematch": 3, // Ctrl (Pre-match)
 }
 
 // Hub WebSocket Hub
 type Hub struct {
+	localization *services.LocalizationService // 规范化客户端订阅的语言 (可选)
 	clients    map[*Client]bool
 	broadcast  chan *WSMessage
 	register   chan *Client
@@ -49,6 +81,11 @@ func NewHub() *Hub {
 	}
 }
 
+// SetLocalizationService 设置多语言名称服务 (可选)
+func (h *Hub) SetLocalizationService(localization *services.LocalizationService) {
+	h.localization = localization
+}
+
 // Run 运行Hub
 func (h *Hub) Run() {
 	for {
@@ -63,31 +100,38 @@ func (h *Hub) Run() {
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
-				close(client.send)
+				client.close()
 			}
 			h.mu.Unlock()
 			log.Printf("Client unregistered. Total clients: %d", len(h.clients))
 
 		case message := <-h.broadcast:
+			data := h.marshalMessage(message)
+			var deltaData []byte
+			if message.Delta != nil {
+				deltaData = h.marshalMessage(message.asDelta())
+			}
+
+			var slowClients []*Client
 			h.mu.RLock()
 			for client := range h.clients {
-				// 检查过滤器
-				if !client.shouldReceive(message) {
-					continue
+				if !client.deliver(message, data, deltaData) {
+					slowClients = append(slowClients, client)
 				}
+			}
+			h.mu.RUnlock()
 
-				select {
-				case client.send <- h.marshalMessage(message):
-				default:
-					h.mu.RUnlock()
-					h.mu.Lock()
-					close(client.send)
-					delete(h.clients, client)
-					h.mu.Unlock()
-					h.mu.RLock()
+			// 发送缓冲区已满的客户端直接断开
+			if len(slowClients) > 0 {
+				h.mu.Lock()
+				for _, client := range slowClients {
+					if _, ok := h.clients[client]; ok {
+						delete(h.clients, client)
+						client.close()
+					}
 				}
+				h.mu.Unlock()
 			}
-			h.mu.RUnlock()
 		}
 	}
 }
@@ -116,6 +160,12 @@ func (h *Hub) Broadcast(message interface{}) {
 		if v, ok := msgMap["product_id"].(*int); ok {
 			wsMsg.ProductID = v
 		}
+		if v, ok := msgMap["sport_id"].(string); ok {
+			wsMsg.SportID = v
+		}
+		if v, ok := msgMap["tournament_id"].(string); ok {
+			wsMsg.TournamentID = v
+		}
 		if v, ok := msgMap["routing_key"].(string); ok {
 			wsMsg.RoutingKey = v
 		}
@@ -125,11 +175,31 @@ func (h *Hub) Broadcast(message interface{}) {
 		if v, ok := msgMap["data"]; ok {
 			wsMsg.Data = v
 		}
+		if v, ok := msgMap["delta"]; ok {
+			wsMsg.Delta = v
+		}
+		if v, ok := msgMap["localized"].(map[string]interface{}); ok {
+			wsMsg.Localized = v
+		}
+		if v, ok := msgMap["localized_delta"].(map[string]interface{}); ok {
+			wsMsg.LocalizedDelta = v
+		}
 		
 		h.broadcast <- wsMsg
 	}
 }
 
+// asDelta 返回增量格式的消息副本
+func (m *WSMessage) asDelta() *WSMessage {
+	delta := *m
+	delta.Type = "delta"
+	delta.Data = m.Delta
+	delta.Delta = nil
+	delta.Localized = m.LocalizedDelta
+	delta.LocalizedDelta = nil
+	return &delta
+}
+
 // marshalMessage 序列化消息
 func (h *Hub) marshalMessage(message *WSMessage) []byte {
 	data, err := json.Marshal(message)
@@ -140,10 +210,226 @@ func (h *Hub) marshalMessage(message *WSMessage) []byte {
 	return data
 }
 
-// shouldReceive 检查客户端是否应该接收消息
+// deliver 向客户端投递一条广播消息, data/deltaData 为预先序列化的完整/增量格式
+// 返回 false 表示发送缓冲区已满, 需要断开该客户端
+func (c *Client) deliver(message *WSMessage, data, deltaData []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || !c.shouldReceive(message) {
+		return true
+	}
+
+	// 该赛事的快照尚未发送, 先缓存
+	if pending, ok := c.syncing[message.EventID]; ok && message.EventID != "" {
+		c.syncing[message.EventID] = append(pending, message)
+		return true
+	}
+
+	if len(c.marketIDs) > 0 || c.oddsFormat != "" || c.wantsLocalized(message) {
+		data = c.render(message)
+		if data == nil {
+			return true
+		}
+	} else if c.wantsDelta(message) {
+		data = deltaData
+	}
+
+	return c.enqueue(data)
+}
+
+// wantsDelta 客户端是否应收到增量格式 (调用方需持有 c.mu)
+func (c *Client) wantsDelta(message *WSMessage) bool {
+	return c.format == "delta" && message.Delta != nil
+}
+
+// wantsLocalized 客户端是否应收到其它语言的数据 (完整或增量格式, 调用方需持有 c.mu)
+func (c *Client) wantsLocalized(message *WSMessage) bool {
+	if c.lang == "" {
+		return false
+	}
+	if c.wantsDelta(message) {
+		return message.LocalizedDelta[c.lang] != nil
+	}
+	return message.Localized[c.lang] != nil
+}
+
+// render 按客户端的盘口过滤器裁剪并序列化消息 (调用方需持有 c.mu)
+// 原消息包含盘口但裁剪后为空时返回 nil, 表示无需推送
+func (c *Client) render(message *WSMessage) []byte {
+	if c.wantsDelta(message) {
+		message = message.asDelta()
+	}
+	if c.lang != "" && message.Localized[c.lang] != nil {
+		localized := *message
+		localized.Data = message.Localized[c.lang]
+		message = &localized
+	}
+
+	if len(c.marketIDs) > 0 {
+		trimmed, keep := trimMarkets(message, c.marketIDs)
+		if !keep {
+			return nil
+		}
+		message = trimmed
+	}
+
+	if c.oddsFormat != "" {
+		message = formatMessageOdds(message, c.oddsFormat)
+	}
+	return c.hub.marshalMessage(message)
+}
+
+// formatMessageOdds 为消息中每个结果添加转换后的赔率 (formatted), 返回消息副本
+func formatMessageOdds(message *WSMessage, format services.OddsFormat) *WSMessage {
+	switch data := message.Data.(type) {
+	case map[string]interface{}:
+		markets, ok := data["markets"].([]map[string]interface{})
+		if !ok {
+			return message
+		}
+
+		formattedMarkets := make([]map[string]interface{}, 0, len(markets))
+		for _, market := range markets {
+			copiedMarket := make(map[string]interface{}, len(market))
+			for k, v := range market {
+				copiedMarket[k] = v
+			}
+			if outcomes, ok := market["outcomes"].([]map[string]interface{}); ok {
+				formattedOutcomes := make([]map[string]interface{}, 0, len(outcomes))
+				for _, outcome := range outcomes {
+					copiedOutcome := make(map[string]interface{}, len(outcome)+1)
+					for k, v := range outcome {
+						copiedOutcome[k] = v
+					}
+					if odds, ok := outcome["odds"].(float64); ok {
+						if formatted := services.ConvertOdds(odds, format); formatted != nil {
+							copiedOutcome["formatted"] = formatted
+						}
+					}
+					formattedOutcomes = append(formattedOutcomes, copiedOutcome)
+				}
+				copiedMarket["outcomes"] = formattedOutcomes
+			}
+			formattedMarkets = append(formattedMarkets, copiedMarket)
+		}
+
+		copied := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			copied[k] = v
+		}
+		copied["markets"] = formattedMarkets
+
+		formatted := *message
+		formatted.Data = copied
+		return &formatted
+
+	case *services.EventSnapshot:
+		copied := *data
+		copied.Markets = make([]services.SnapshotMarket, 0, len(data.Markets))
+		for _, market := range data.Markets {
+			outcomes := make([]services.SnapshotOutcome, len(market.Outcomes))
+			copy(outcomes, market.Outcomes)
+			for i := range outcomes {
+				outcomes[i].Formatted = services.ConvertOdds(outcomes[i].Odds, format)
+			}
+			market.Outcomes = outcomes
+			copied.Markets = append(copied.Markets, market)
+		}
+
+		formatted := *message
+		formatted.Data = &copied
+		return &formatted
+	}
+
+	return message
+}
+
+// trimMarkets 只保留指定 sr_market_id 的盘口, 返回消息副本
+func trimMarkets(message *WSMessage, marketIDs map[string]bool) (*WSMessage, bool) {
+	switch data := message.Data.(type) {
+	case map[string]interface{}:
+		markets, ok := data["markets"].([]map[string]interface{})
+		if !ok {
+			return message, true
+		}
+
+		kept := make([]map[string]interface{}, 0, len(markets))
+		for _, market := range markets {
+			if marketIDs[fmt.Sprint(market["id"])] {
+				kept = append(kept, market)
+			}
+		}
+		if len(markets) > 0 && len(kept) == 0 {
+			return nil, false
+		}
+
+		copied := make(map[string]interface{}, len(data))
+		for k, v := range data {
+			copied[k] = v
+		}
+		copied["markets"] = kept
+
+		trimmed := *message
+		trimmed.Data = copied
+		return &trimmed, true
+
+	case *services.EventSnapshot:
+		kept := make([]services.SnapshotMarket, 0, len(data.Markets))
+		for _, market := range data.Markets {
+			if marketIDs[market.ID] {
+				kept = append(kept, market)
+			}
+		}
+
+		copied := *data
+		copied.Markets = kept
+
+		trimmed := *message
+		trimmed.Data = &copied
+		return &trimmed, true
+	}
+
+	return message, true
+}
+
+// enqueue 非阻塞写入发送缓冲区 (调用方需持有 c.mu)
+func (c *Client) enqueue(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 关闭发送通道 (只由 Hub 调用)
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// shouldReceive 检查客户端是否应该接收消息 (调用方需持有 c.mu)
 func (c *Client) shouldReceive(message *WSMessage) bool {
+	// trader 告警只推送给订阅了告警流的客户端, 只按运动和联赛过滤
+	if message.Type == "trader_alert" {
+		if !c.traderAlerts {
+			return false
+		}
+		if len(c.sportIDs) > 0 && !c.sportIDs[message.SportID] {
+			return false
+		}
+		return len(c.tournamentIDs) == 0 || c.tournamentIDs[message.TournamentID]
+	}
+
 	// 如果没有设置过滤器,接收所有消息
-	if len(c.filters) == 0 && len(c.eventIDs) == 0 {
+	if len(c.filters) == 0 && len(c.eventIDs) == 0 &&
+		len(c.sportIDs) == 0 && len(c.tournamentIDs) == 0 && len(c.productIDs) == 0 {
 		return true
 	}
 
@@ -164,6 +450,23 @@ func (c *Client) shouldReceive(message *WSMessage) bool {
 		}
 	}
 
+	// 检查运动过滤器
+	if len(c.sportIDs) > 0 && !c.sportIDs[message.SportID] {
+		return false
+	}
+
+	// 检查联赛过滤器
+	if len(c.tournamentIDs) > 0 && !c.tournamentIDs[message.TournamentID] {
+		return false
+	}
+
+	// 检查生产者过滤器
+	if len(c.productIDs) > 0 {
+		if message.ProductID == nil || !c.productIDs[*message.ProductID] {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -243,6 +546,8 @@ func (c *Client) handleMessage(message []byte) {
 
 	switch msgType {
 	case "subscribe":
+		c.mu.Lock()
+
 		// 订阅特定消息类型
 		if filters, ok := msg["message_types"].([]interface{}); ok {
 			c.filters = make(map[string]bool)
@@ -254,22 +559,202 @@ func (c *Client) handleMessage(message []byte) {
 		}
 
 		// 订阅特定赛事
+		var newEventIDs []string
 		if eventIDs, ok := msg["event_ids"].([]interface{}); ok {
 			c.eventIDs = make(map[string]bool)
 			for _, e := range eventIDs {
 				if eventID, ok := e.(string); ok {
 					c.eventIDs[eventID] = true
+					newEventIDs = append(newEventIDs, eventID)
+				}
+			}
+		}
+
+		// 订阅特定运动
+		if sportIDs, ok := msg["sport_ids"].([]interface{}); ok {
+			c.sportIDs = toStringSet(sportIDs)
+		}
+
+		// 订阅特定联赛
+		if tournamentIDs, ok := msg["tournament_ids"].([]interface{}); ok {
+			c.tournamentIDs = toStringSet(tournamentIDs)
+		}
+
+		// 订阅特定生产者: "live" / "prematch" 或 product ID
+		if producers, ok := msg["producers"].([]interface{}); ok {
+			c.productIDs = make(map[int]bool)
+			for _, p := range producers {
+				switch v := p.(type) {
+				case string:
+					if id, ok := producerAliases[v]; ok {
+						c.productIDs[id] = true
+					}
+				case float64:
+					c.productIDs[int(v)] = true
+				}
+			}
+		}
+
+		// 订阅特定盘口 (sr_market_id), 消息中的 markets 会被裁剪
+		if marketIDs, ok := msg["market_ids"].([]interface{}); ok {
+			c.marketIDs = toStringSet(marketIDs)
+		}
+
+		// 消息格式: full / delta
+		if format, ok := msg["format"].(string); ok {
+			if format == "delta" {
+				c.format = "delta"
+			} else {
+				c.format = "full"
+			}
+		}
+
+		// 赔率格式: decimal / fractional / american / hongkong / malay / indonesian
+		if value, ok := msg["odds_format"].(string); ok {
+			if value == "" {
+				c.oddsFormat = ""
+			} else if format, err := services.ParseOddsFormat(value); err == nil {
+				c.oddsFormat = format
+			} else if !c.closed {
+				c.enqueue(c.hub.marshalMessage(&WSMessage{
+					Type: "error",
+					Data: map[string]interface{}{"error": err.Error()},
+				}))
+			}
+		}
+
+		// 名称语言: 未配置的语言回退到英文
+		if value, ok := msg["lang"].(string); ok {
+			c.lang = c.hub.localization.NormalizeLanguage(value)
+			if c.lang == services.DefaultLanguage {
+				c.lang = ""
+			}
+		}
+
+		// trader 告警流 (赔率异动告警)
+		if value, ok := msg["trader_alerts"].(bool); ok {
+			if value && c.apiKey != nil && !c.apiKey.Role.Allows(services.RoleTrader) {
+				if !c.closed {
+					c.enqueue(c.hub.marshalMessage(&WSMessage{
+						Type: "error",
+						Data: map[string]interface{}{"error": "trader_alerts requires trader role"},
+					}))
+				}
+			} else {
+				c.traderAlerts = value
+			}
+		}
+
+		// 标记需要推送快照的赛事, 在快照发送前缓存其实时消息
+		if c.snapshotService != nil {
+			for _, eventID := range newEventIDs {
+				if _, ok := c.syncing[eventID]; !ok {
+					c.syncing[eventID] = make([]*WSMessage, 0)
 				}
 			}
 		}
 
-		log.Printf("Client subscribed with filters: %v, events: %v", c.filters, c.eventIDs)
+		log.Printf("Client subscribed with filters: %v, events: %v, sports: %v, tournaments: %v, producers: %v, markets: %v, format: %s, odds_format: %s, trader_alerts: %v, lang: %s",
+			c.filters, c.eventIDs, c.sportIDs, c.tournamentIDs, c.productIDs, c.marketIDs, c.format, c.oddsFormat, c.traderAlerts, c.lang)
+		lang := c.lang
+		c.mu.Unlock()
+
+		if c.snapshotService != nil {
+			for _, eventID := range newEventIDs {
+				c.sendSnapshot(eventID, lang)
+			}
+		}
 
 	case "unsubscribe":
 		// 取消订阅
+		c.mu.Lock()
 		c.filters = make(map[string]bool)
 		c.eventIDs = make(map[string]bool)
+		c.sportIDs = make(map[string]bool)
+		c.tournamentIDs = make(map[string]bool)
+		c.productIDs = make(map[int]bool)
+		c.marketIDs = make(map[string]bool)
+		c.traderAlerts = false
+		c.syncing = make(map[string][]*WSMessage)
+		c.mu.Unlock()
 		log.Println("Client unsubscribed")
 	}
 }
 
+// sendSnapshot 推送赛事快照 (名称使用客户端订阅的语言), 然后推送快照期间缓存的实时消息
+// 快照或缓存消息写不进发送缓冲区时断开客户端, 避免客户端在没有基准状态时收到增量
+func (c *Client) sendSnapshot(eventID, lang string) {
+	snapshot, err := c.snapshotService.GetEventSnapshot(eventID, lang)
+	if err != nil {
+		log.Printf("Failed to build snapshot for %s: %v", eventID, err)
+	}
+
+	if !c.flushSnapshot(eventID, snapshot) {
+		log.Printf("Client send buffer full while sending snapshot for %s, disconnecting", eventID)
+		c.conn.Close()
+	}
+}
+
+// flushSnapshot 写入快照和缓存的实时消息, 返回 false 表示发送缓冲区已满
+// 快照构建失败时只回复错误, 丢弃缓存的实时消息
+func (c *Client) flushSnapshot(eventID string, snapshot *services.EventSnapshot) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.syncing[eventID]
+	if !ok {
+		// 快照期间已取消订阅
+		return true
+	}
+	delete(c.syncing, eventID)
+
+	if c.closed {
+		return true
+	}
+
+	if snapshot == nil {
+		return c.enqueue(c.hub.marshalMessage(&WSMessage{
+			Type:    "error",
+			EventID: eventID,
+			Data:    map[string]interface{}{"error": "snapshot unavailable, resubscribe to retry"},
+		}))
+	}
+
+	if !c.enqueue(c.render(&WSMessage{
+		Type:      "snapshot",
+		EventID:   eventID,
+		SportID:   snapshot.SportID,
+		Timestamp: snapshot.GeneratedAt,
+		Data:      snapshot,
+	})) {
+		return false
+	}
+
+	for _, message := range pending {
+		if !c.shouldReceive(message) {
+			continue
+		}
+		data := c.render(message)
+		if data == nil {
+			continue
+		}
+		if !c.enqueue(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// toStringSet 将客户端传入的 ID 列表转换为集合 (数字按字符串处理)
+func toStringSet(values []interface{}) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		switch id := v.(type) {
+		case string:
+			set[id] = true
+		case float64:
+			set[fmt.Sprintf("%d", int(id))] = true
+		}
+	}
+	return set
+}